@@ -95,6 +95,11 @@ func init() {
 	flag.BoolVar(&config.ExpandEdge, "expand_edge", defaults.ExpandEdge,
 		"Enables the expand() feature. This is very expensive for large data loads because it"+
 			" doubles the number of mutations going on in the system.")
+	flag.BoolVar(&config.YesNoBools, "yes_no_bools", defaults.YesNoBools,
+		"Also accept yes and no as values for predicates of type bool.")
+	flag.StringVar(&config.JSONDefaults, "json_defaults", defaults.JSONDefaults,
+		"JSON object of the values set on the new nodes of JSON mutations which"+
+			" don't have the predicate, e.g. {\"status\": \"active\"}.")
 
 	flag.Float64Var(&config.AllottedMemory, "memory_mb", defaults.AllottedMemory,
 		"Estimated memory the process can take. "+
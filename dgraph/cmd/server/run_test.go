@@ -44,6 +44,7 @@ import (
 	"github.com/dgraph-io/dgraph/query"
 	"github.com/dgraph-io/dgraph/schema"
 	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/types/facets"
 	"github.com/dgraph-io/dgraph/worker"
 	"github.com/dgraph-io/dgraph/x"
 )
@@ -1157,6 +1158,56 @@ func TestMultipleValues(t *testing.T) {
 	require.JSONEq(t, `{"data": {"me":[{"occupations":["Software Engineer","Pianist"]}]}}`, res)
 }
 
+func TestMutateDeleteModes(t *testing.T) {
+	m := `
+		{
+			set {
+				<0x301> <knows> <0x302> (weight=0.05) .
+				<0x301> <knows> <0x303> (weight=0.5, since=2006) .
+				<0x301> <knows> <0x304> .
+			}
+		}
+	`
+	require.NoError(t, runMutation(m))
+
+	weight, err := facets.FacetFor("weight", "0.1")
+	require.NoError(t, err)
+	mutate := func(mu *protos.Mutation) {
+		mu.CommitImmediately = true
+		_, err := (&edgraph.Server{}).Mutate(defaultContext(), mu)
+		require.NoError(t, err)
+	}
+	// Only the edge with a weight below 0.1 is deleted.
+	mutate(&protos.Mutation{Del: []*protos.NQuad{
+		{Subject: "0x301", Predicate: "knows", ObjectId: "0x302", FacetCondOp: "<",
+			Facets: []*protos.Facet{weight}},
+		{Subject: "0x301", Predicate: "knows", ObjectId: "0x303", FacetCondOp: "<",
+			Facets: []*protos.Facet{weight}},
+	}})
+	// Only the facet is deleted, the edge stays.
+	mutate(&protos.Mutation{Set: []*protos.NQuad{
+		{Subject: "0x301", Predicate: "knows", ObjectId: "0x303", DelFacets: []string{"since"}},
+	}})
+	// The edge is deleted, leaving a tombstone which isn't read.
+	mutate(&protos.Mutation{Del: []*protos.NQuad{
+		{Subject: "0x301", Predicate: "knows", ObjectId: "0x304", Tombstone: true,
+			DeletedBy: "auditor"},
+	}})
+
+	q := `{
+			me(func: uid(0x301)) {
+				knows @facets {
+					uid
+				}
+				count(knows)
+			}
+		}`
+	res, err := runQuery(q)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"data": {"me":[{"knows":[{"uid":"0x303","knows|weight":0.5}],
+		"count(knows)":1}]}}`, res)
+}
+
 func TestListTypeSchemaChange(t *testing.T) {
 	schema.ParseBytes([]byte(""), 1)
 	m := `
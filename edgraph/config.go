@@ -21,6 +21,7 @@ import (
 	"path/filepath"
 
 	"github.com/dgraph-io/dgraph/posting"
+	"github.com/dgraph-io/dgraph/types"
 	"github.com/dgraph-io/dgraph/worker"
 	"github.com/dgraph-io/dgraph/x"
 )
@@ -43,6 +44,8 @@ type Options struct {
 	MaxPendingCount     uint64
 	ExpandEdge          bool
 	InMemoryComm        bool
+	YesNoBools          bool
+	JSONDefaults        string
 
 	ConfigFile string
 	DebugMode  bool
@@ -69,6 +72,8 @@ var DefaultConfig = Options{
 	MaxPendingCount:     1000,
 	ExpandEdge:          true,
 	InMemoryComm:        false,
+	YesNoBools:          false,
+	JSONDefaults:        "",
 
 	ConfigFile: "",
 	DebugMode:  false,
@@ -114,6 +119,8 @@ func setConfVar(conf Options) {
 	x.Conf.Set("max_pending_count", newInt(int(conf.MaxPendingCount)))
 	x.Conf.Set("num_pending_proposals", newInt(conf.NumPendingProposals))
 	x.Conf.Set("expand_edge", newIntFromBool(conf.ExpandEdge))
+	x.Conf.Set("yes_no_bools", newIntFromBool(conf.YesNoBools))
+	x.Conf.Set("json_defaults", newStr(conf.JSONDefaults))
 }
 
 func SetConfiguration(newConfig Options) {
@@ -136,6 +143,12 @@ func SetConfiguration(newConfig Options) {
 	worker.Config.ExpandEdge = Config.ExpandEdge
 	worker.Config.InMemoryComm = Config.InMemoryComm
 
+	types.Config.YesNoBools = Config.YesNoBools
+
+	defaults, err := parseJSONDefaults(Config.JSONDefaults)
+	x.Checkf(err, "While parsing --json_defaults")
+	jsonOptions.Defaults = defaults
+
 	x.Config.ConfigFile = Config.ConfigFile
 	x.Config.DebugMode = Config.DebugMode
 }
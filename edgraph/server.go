@@ -23,6 +23,7 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -255,6 +256,13 @@ func (s *Server) Mutate(ctx context.Context, mu *protos.Mutation) (resp *protos.
 	if err != nil {
 		return resp, err
 	}
+	if gmu.Cond != nil {
+		uid, err := query.UidForCond(ctx, mu.StartTs, nil, gmu.Cond)
+		if err != nil {
+			return resp, err
+		}
+		gmu.ResolveCond(uid)
+	}
 	newUids, err := query.AssignUids(ctx, gmu.Set)
 	if err != nil {
 		return resp, err
@@ -551,8 +559,68 @@ func tryParseAsGeo(b []byte, nq *protos.NQuad) (bool, error) {
 	return false, nil
 }
 
+// JSONOptions holds the options used while converting JSON to NQuads.
+type JSONOptions struct {
+	// Defaults maps predicates to the value they are set to for every new
+	// object which doesn't have them. It's only used for set operations.
+	Defaults map[string]*protos.Value
+}
+
+// jsonOptions are the options used for the JSON of mutations, set from Config
+// by SetConfiguration.
+var jsonOptions JSONOptions
+
+// parseJSONDefaults parses the JSON object of default values given with
+// --json_defaults. Values must be strings, numbers or bools.
+func parseJSONDefaults(s string) (map[string]*protos.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil, x.Wrapf(err, "Default values should be a JSON object")
+	}
+	defaults := make(map[string]*protos.Value, len(m))
+	for pred, v := range m {
+		nq := protos.NQuad{Predicate: pred}
+		if err := handleBasicType(pred, v, set, &nq); err != nil {
+			return nil, err
+		}
+		if len(nq.Lang) > 0 {
+			return nil, x.Errorf("Default value for %s can't have a language", pred)
+		}
+		defaults[pred] = nq.ObjectValue
+	}
+	return defaults, nil
+}
+
+// addDefaults appends the NQuads setting the default values for the predicates
+// missing from m. Only new objects get them, objects with a uid refer to an
+// existing node which is left alone.
+func addDefaults(mr *mapResponse, m map[string]interface{}, opts JSONOptions) {
+	if !strings.HasPrefix(mr.uid, "_:") {
+		return
+	}
+	preds := make([]string, 0, len(opts.Defaults))
+	for pred := range opts.Defaults {
+		if _, ok := m[pred]; !ok {
+			preds = append(preds, pred)
+		}
+	}
+	// Sort so that the output doesn't depend on the map iteration order.
+	sort.Strings(preds)
+	for _, pred := range preds {
+		mr.nquads = append(mr.nquads, &protos.NQuad{
+			Subject:     mr.uid,
+			Predicate:   pred,
+			ObjectValue: opts.Defaults[pred],
+		})
+	}
+}
+
 // TODO - Abstract these parameters to a struct.
-func mapToNquads(m map[string]interface{}, idx *int, op int, parentPred string) (mapResponse, error) {
+func mapToNquads(m map[string]interface{}, idx *int, op int, parentPred string,
+	opts JSONOptions) (mapResponse, error) {
 	var mr mapResponse
 	// Check field in map.
 	if uidVal, ok := m["uid"]; ok {
@@ -584,8 +652,9 @@ func mapToNquads(m map[string]interface{}, idx *int, op int, parentPred string)
 		}
 
 		if op == delete {
-			// This corresponds to predicate deletion.
-			if v == nil {
+			// Without a uid this corresponds to predicate deletion. With one, the
+			// value is deleted from that node only, below.
+			if v == nil && len(mr.uid) == 0 {
 				mr.nquads = append(mr.nquads, &protos.NQuad{
 					Subject:     x.Star,
 					Predicate:   pred,
@@ -651,10 +720,13 @@ func mapToNquads(m map[string]interface{}, idx *int, op int, parentPred string)
 				}
 			}
 
-			cr, err := mapToNquads(v.(map[string]interface{}), idx, op, pred)
+			cr, err := mapToNquads(v.(map[string]interface{}), idx, op, pred, opts)
 			if err != nil {
 				return mr, err
 			}
+			if op == delete && len(cr.uid) == 0 {
+				return mr, x.Errorf("Object for predicate %s must have a uid to be deleted", pred)
+			}
 
 			// Add the connecting edge beteween the entities.
 			nq.ObjectId = cr.uid
@@ -676,7 +748,7 @@ func mapToNquads(m map[string]interface{}, idx *int, op int, parentPred string)
 					}
 					mr.nquads = append(mr.nquads, &nq)
 				case map[string]interface{}:
-					cr, err := mapToNquads(iv, idx, op, pred)
+					cr, err := mapToNquads(iv, idx, op, pred, opts)
 					if err != nil {
 						return mr, err
 					}
@@ -695,6 +767,10 @@ func mapToNquads(m map[string]interface{}, idx *int, op int, parentPred string)
 		}
 	}
 
+	if op == set && len(opts.Defaults) > 0 {
+		addDefaults(&mr, m, opts)
+	}
+
 	fts, err := parseFacets(m, parentPred+query.FacetDelimeter)
 	mr.fcts = fts
 	return mr, err
@@ -706,6 +782,10 @@ const (
 )
 
 func nquadsFromJson(b []byte, op int) ([]*protos.NQuad, error) {
+	return nquadsFromJsonWithOptions(b, op, JSONOptions{})
+}
+
+func nquadsFromJsonWithOptions(b []byte, op int, opts JSONOptions) ([]*protos.NQuad, error) {
 	ms := make(map[string]interface{})
 	var list []interface{}
 	if err := json.Unmarshal(b, &ms); err != nil {
@@ -726,7 +806,7 @@ func nquadsFromJson(b []byte, op int) ([]*protos.NQuad, error) {
 			if _, ok := obj.(map[string]interface{}); !ok {
 				return nil, x.Errorf("Only array of map allowed at root.")
 			}
-			mr, err := mapToNquads(obj.(map[string]interface{}), &idx, op, "")
+			mr, err := mapToNquads(obj.(map[string]interface{}), &idx, op, "", opts)
 			if err != nil {
 				return mr.nquads, err
 			}
@@ -736,11 +816,25 @@ func nquadsFromJson(b []byte, op int) ([]*protos.NQuad, error) {
 		return nquads, nil
 	}
 
-	mr, err := mapToNquads(ms, &idx, op, "")
+	mr, err := mapToNquads(ms, &idx, op, "", opts)
 	checkForDeletion(&mr, ms, op)
 	return mr.nquads, err
 }
 
+// ParseJSONDelete returns the mutation deleting what the JSON describes. Each
+// key of an object with a uid deletes the value given for that predicate, or
+// all its values if the value is empty or null. A null value in an object
+// without a uid deletes the predicate from all nodes. Nested objects are
+// deleted as edges to the node with their uid, along with what they describe
+// of that node.
+func ParseJSONDelete(data []byte) (*gql.Mutation, error) {
+	nquads, err := nquadsFromJson(data, delete)
+	if err != nil {
+		return nil, err
+	}
+	return &gql.Mutation{Del: nquads}, nil
+}
+
 func parseNQuads(b []byte, op int) ([]*protos.NQuad, error) {
 	var nqs []*protos.NQuad
 	for _, line := range bytes.Split(b, []byte{'\n'}) {
@@ -760,7 +854,7 @@ func parseNQuads(b []byte, op int) ([]*protos.NQuad, error) {
 func parseMutationObject(mu *protos.Mutation) (*gql.Mutation, error) {
 	res := &gql.Mutation{}
 	if len(mu.SetJson) > 0 {
-		nqs, err := nquadsFromJson(mu.SetJson, set)
+		nqs, err := nquadsFromJsonWithOptions(mu.SetJson, set, jsonOptions)
 		if err != nil {
 			return nil, err
 		}
@@ -789,5 +883,15 @@ func parseMutationObject(mu *protos.Mutation) (*gql.Mutation, error) {
 	}
 	res.Set = append(res.Set, mu.Set...)
 	res.Del = append(res.Del, mu.Del...)
+	if cond := mu.Cond; cond != nil {
+		if !strings.HasPrefix(cond.Subject, "_:") {
+			return nil, x.Errorf("Subject %q of condition should be a blank node", cond.Subject)
+		}
+		res.Cond = &gql.Condition{
+			Subject:   cond.Subject,
+			Predicate: cond.Predicate,
+			Value:     cond.ObjectValue,
+		}
+	}
 	return res, nil
 }
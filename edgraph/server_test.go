@@ -2,9 +2,11 @@ package edgraph
 
 import (
 	"encoding/json"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/dgraph-io/dgraph/gql"
 	"github.com/dgraph-io/dgraph/protos"
 	"github.com/dgraph-io/dgraph/types"
 	"github.com/dgraph-io/dgraph/x"
@@ -253,3 +255,137 @@ func TestParseNQuadsDelete(t *testing.T) {
 		makeNquad("_:a", x.Star, &protos.Value{&protos.Value_DefaultVal{x.Star}}),
 	}, nqs)
 }
+
+func TestParseMutationObjectCond(t *testing.T) {
+	email := &protos.Value{Val: &protos.Value_StrVal{StrVal: "alice@dgraph.io"}}
+	mu := &protos.Mutation{
+		SetNquads: []byte(`_:user <name> "Alice" .`),
+		Cond:      &protos.NQuad{Subject: "_:user", Predicate: "email", ObjectValue: email},
+	}
+	gmu, err := parseMutationObject(mu)
+	require.NoError(t, err)
+	require.Equal(t, &gql.Condition{Subject: "_:user", Predicate: "email", Value: email},
+		gmu.Cond)
+
+	mu.Cond.Subject = "0x1"
+	_, err = parseMutationObject(mu)
+	require.Error(t, err)
+}
+
+func TestNquadsFromJsonDefaults(t *testing.T) {
+	opts := JSONOptions{Defaults: map[string]*protos.Value{
+		"status": &protos.Value{Val: &protos.Value_StrVal{StrVal: "active"}},
+	}}
+	json := `[{"name": "Alice"}, {"name": "Bob", "status": "inactive"}]`
+	nq, err := nquadsFromJsonWithOptions([]byte(json), set, opts)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(nq))
+	require.Contains(t, nq, makeNquad("_:blank-0", "status",
+		&protos.Value{Val: &protos.Value_StrVal{StrVal: "active"}}))
+	require.Contains(t, nq, makeNquad("_:blank-1", "status",
+		&protos.Value{Val: &protos.Value_StrVal{StrVal: "inactive"}}))
+}
+
+func TestNquadsFromJsonDefaultsSkipsReferences(t *testing.T) {
+	opts := JSONOptions{Defaults: map[string]*protos.Value{
+		"status": &protos.Value{Val: &protos.Value_StrVal{StrVal: "active"}},
+	}}
+	json := `{"uid": "0x1", "name": "Alice", "friend": {"uid": "0x2"}}`
+	nq, err := nquadsFromJsonWithOptions([]byte(json), set, opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(nq))
+	for _, n := range nq {
+		require.NotEqual(t, "status", n.Predicate)
+	}
+
+	// A new node nested in an existing one gets them.
+	json = `{"uid": "0x1", "friend": {"name": "Bob"}}`
+	nq, err = nquadsFromJsonWithOptions([]byte(json), set, opts)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(nq))
+	require.Contains(t, nq, makeNquad("_:blank-0", "status",
+		&protos.Value{Val: &protos.Value_StrVal{StrVal: "active"}}))
+}
+
+func TestParseJSONDefaults(t *testing.T) {
+	defaults, err := parseJSONDefaults(`{"status": "active", "score": 1, "verified": false}`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]*protos.Value{
+		"status":   &protos.Value{Val: &protos.Value_StrVal{StrVal: "active"}},
+		"score":    &protos.Value{Val: &protos.Value_DoubleVal{DoubleVal: 1}},
+		"verified": &protos.Value{Val: &protos.Value_BoolVal{BoolVal: false}},
+	}, defaults)
+
+	defaults, err = parseJSONDefaults("")
+	require.NoError(t, err)
+	require.Nil(t, defaults)
+
+	_, err = parseJSONDefaults(`["active"]`)
+	require.Error(t, err)
+	_, err = parseJSONDefaults(`{"friend": {"uid": "0x1"}}`)
+	require.Error(t, err)
+	_, err = parseJSONDefaults(`{"status@en": "active"}`)
+	require.Error(t, err)
+}
+
+func TestParseMutationObjectDefaults(t *testing.T) {
+	jsonOptions.Defaults = map[string]*protos.Value{
+		"status": &protos.Value{Val: &protos.Value_StrVal{StrVal: "active"}},
+	}
+	defer func() { jsonOptions.Defaults = nil }()
+
+	gmu, err := parseMutationObject(&protos.Mutation{
+		SetJson: []byte(`[{"name": "Alice"}, {"uid": "0x1", "name": "Bob"}]`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(gmu.Set))
+	require.Contains(t, gmu.Set, makeNquad("_:blank-0", "status",
+		&protos.Value{Val: &protos.Value_StrVal{StrVal: "active"}}))
+}
+
+func sortNquads(nqs []*protos.NQuad) {
+	sort.Slice(nqs, func(i, j int) bool {
+		if nqs[i].Subject != nqs[j].Subject {
+			return nqs[i].Subject < nqs[j].Subject
+		}
+		return nqs[i].Predicate < nqs[j].Predicate
+	})
+}
+
+func TestParseJSONDeleteScalar(t *testing.T) {
+	m, err := ParseJSONDelete([]byte(`{"uid": "0x3e8", "name": "Alice", "age": ""}`))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(m.Set))
+	sortNquads(m.Del)
+	require.Equal(t, []*protos.NQuad{
+		makeNquad("1000", "age", &protos.Value{&protos.Value_DefaultVal{x.Star}}),
+		makeNquad("1000", "name", &protos.Value{&protos.Value_StrVal{"Alice"}}),
+	}, m.Del)
+}
+
+func TestParseJSONDeleteNull(t *testing.T) {
+	m, err := ParseJSONDelete([]byte(`{"uid": 1000, "nickname": null}`))
+	require.NoError(t, err)
+	require.Equal(t, []*protos.NQuad{
+		makeNquad("1000", "nickname", &protos.Value{&protos.Value_DefaultVal{x.Star}}),
+	}, m.Del)
+
+	m, err = ParseJSONDelete([]byte(`{"nickname": null}`))
+	require.NoError(t, err)
+	require.Equal(t, []*protos.NQuad{
+		makeNquad(x.Star, "nickname", &protos.Value{&protos.Value_DefaultVal{x.Star}}),
+	}, m.Del)
+}
+
+func TestParseJSONDeleteNested(t *testing.T) {
+	m, err := ParseJSONDelete([]byte(`{"uid": 1000, "friend": {"uid": 1001, "name": "Bob"}}`))
+	require.NoError(t, err)
+	sortNquads(m.Del)
+	require.Equal(t, []*protos.NQuad{
+		makeNquadEdge("1000", "friend", "1001"),
+		makeNquad("1001", "name", &protos.Value{&protos.Value_StrVal{"Bob"}}),
+	}, m.Del)
+
+	_, err = ParseJSONDelete([]byte(`{"uid": 1000, "friend": {"nickname": null}}`))
+	require.Error(t, err)
+}
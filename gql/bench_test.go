@@ -18,9 +18,13 @@
 package gql
 
 import (
+	"fmt"
+	"runtime"
 	"testing"
 
+	"github.com/dgraph-io/dgraph/protos"
 	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -1148,3 +1152,72 @@ var m1000 = `mutation {
 <g.11b725m1sy>	<film.director.film>	<m.0crsr6l>	.
 	}
 }`
+
+var intVal = &protos.Value{Val: &protos.Value_IntVal{IntVal: 1234567}}
+
+func BenchmarkIntValMarshal(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		out := types.ValueForType(types.BinaryID)
+		if err := types.Marshal(typeValFrom(intVal), &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIntValByteVal(b *testing.B) {
+	nq := NQuad{&protos.NQuad{ObjectValue: intVal}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := byteVal(nq); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIntValAppendScalar(b *testing.B) {
+	buf := make([]byte, 0, 8)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _, _ = appendScalar(buf[:0], intVal)
+	}
+}
+
+// benchmarkRepeatedValues converts 100k edges with few distinct string values,
+// reporting the bytes still held by the edges afterwards.
+func benchmarkRepeatedValues(b *testing.B, intern bool) {
+	const n = 100000
+	m := Mutation{Set: make([]*protos.NQuad, 0, n)}
+	for i := 0; i < n; i++ {
+		m.Set = append(m.Set, &protos.NQuad{
+			Subject:   fmt.Sprintf("%#x", i+1),
+			Predicate: "category",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{
+				StrVal: fmt.Sprintf("category number %d", i%10)}},
+		})
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		var opts ConvertOptions
+		if intern {
+			opts.Values = NewValueInterner()
+		}
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		edges, err := m.ToEdges(nil, opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		retained += after.HeapAlloc - before.HeapAlloc
+		runtime.KeepAlive(edges)
+	}
+	b.ReportMetric(float64(retained)/float64(b.N)/n, "retained-B/edge")
+}
+
+func BenchmarkRepeatedValues(b *testing.B)         { benchmarkRepeatedValues(b, false) }
+func BenchmarkRepeatedValuesInterned(b *testing.B) { benchmarkRepeatedValues(b, true) }
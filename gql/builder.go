@@ -0,0 +1,543 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/types/facets"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// structBuilder keeps the state needed while walking a struct, so that the
+// blank nodes generated for nested structs are unique within one call.
+type structBuilder struct {
+	idx    int
+	nquads []NQuad
+	// seen maps the structs reached through pointers to their node, so that
+	// each is only added once.
+	seen map[structKey]string
+}
+
+// structKey identifies a struct reached through a pointer. The type is needed
+// as a struct and its first field have the same address.
+type structKey struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+func (b *structBuilder) blankNode() string {
+	id := fmt.Sprintf("_:blank-%d", b.idx)
+	b.idx++
+	return id
+}
+
+// SetStruct returns the NQuads which set the exported fields of v on subject.
+// The predicate for a field is taken from its dgraph tag, then its json tag
+// and lastly the field name. Nested structs are linked to the subject through
+// a uid edge, using their uid field if set and a new blank node otherwise.
+// Blank nodes are only unique within one call to SetStruct.
+func SetStruct(subject string, v interface{}) ([]NQuad, error) {
+	if len(subject) == 0 {
+		return nil, x.Errorf("Subject can't be empty for SetStruct")
+	}
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, x.Errorf("SetStruct expects a struct, got: %T", v)
+	}
+	var b structBuilder
+	if err := b.addStruct(subject, val); err != nil {
+		return nil, err
+	}
+	return b.nquads, nil
+}
+
+// SetMap returns the NQuads which set the entries of m on subject, with keys as
+// predicates. The type of scalar values is inferred as for SetStruct. Nested
+// maps are linked to the subject through a uid edge, using their uid entry if
+// set and a new blank node otherwise, and slices give one edge per element.
+// Entries are converted in the order of their keys.
+func SetMap(subject string, m map[string]interface{}) ([]NQuad, error) {
+	if len(subject) == 0 {
+		return nil, x.Errorf("Subject can't be empty for SetMap")
+	}
+	var b structBuilder
+	if err := b.addMap(subject, m); err != nil {
+		return nil, err
+	}
+	return b.nquads, nil
+}
+
+func (b *structBuilder) addMap(subject string, m map[string]interface{}) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k != "uid" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := b.addMapValue(subject, k, m[k]); err != nil {
+			return x.Wrapf(err, "while converting key %s", k)
+		}
+	}
+	return nil
+}
+
+func (b *structBuilder) addMapValue(subject, pred string, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		return x.Errorf("Value can't be nil")
+	case map[string]interface{}:
+		nq := &protos.NQuad{
+			Subject:   subject,
+			Predicate: pred,
+		}
+		nq.ObjectId, _ = v["uid"].(string)
+		if len(nq.ObjectId) == 0 {
+			nq.ObjectId = b.blankNode()
+		}
+		b.nquads = append(b.nquads, NQuad{nq})
+		return b.addMap(nq.ObjectId, v)
+	case []interface{}:
+		for i, elem := range v {
+			if err := b.addMapValue(subject, pred, elem); err != nil {
+				return x.Wrapf(err, "at index %d", i)
+			}
+		}
+		return nil
+	}
+	ov, err := objectValueOf(reflect.ValueOf(v))
+	if err != nil {
+		return err
+	}
+	b.nquads = append(b.nquads, NQuad{&protos.NQuad{
+		Subject:     subject,
+		Predicate:   pred,
+		ObjectValue: ov,
+	}})
+	return nil
+}
+
+// predicateFor returns the predicate that the field maps to and whether it
+// should be skipped if it has the zero value.
+func predicateFor(f reflect.StructField) (string, bool, bool) {
+	tag, ok := f.Tag.Lookup("dgraph")
+	if !ok {
+		tag = f.Tag.Get("json")
+	}
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	pred := parts[0]
+	if pred == "" {
+		pred = f.Name
+	}
+	omitEmpty := false
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return pred, omitEmpty, true
+}
+
+func (b *structBuilder) addStruct(subject string, val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if len(f.PkgPath) > 0 {
+			// Unexported field.
+			continue
+		}
+		pred, omitEmpty, ok := predicateFor(f)
+		if !ok || pred == "uid" {
+			continue
+		}
+		fv := val.Field(i)
+		if omitEmpty && isZero(fv) {
+			continue
+		}
+		if err := b.addField(subject, pred, fv); err != nil {
+			return x.Wrapf(err, "while converting field %s", f.Name)
+		}
+	}
+	return nil
+}
+
+// isZero returns whether v is the zero value of its type, as checked for
+// omitempty fields.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Complex64, reflect.Complex128:
+		return v.Complex() == 0
+	case reflect.String:
+		return v.Len() == 0
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !isZero(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !isZero(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice,
+		reflect.UnsafePointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+func (b *structBuilder) addField(subject, pred string, fv reflect.Value) error {
+	var ptr uintptr
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		ptr = fv.Pointer()
+		fv = fv.Elem()
+	}
+	// Slices of structs give an edge per element.
+	if fv.Kind() == reflect.Slice && isStructElem(fv.Type().Elem()) {
+		for i := 0; i < fv.Len(); i++ {
+			if err := b.addField(subject, pred, fv.Index(i)); err != nil {
+				return x.Wrapf(err, "at index %d", i)
+			}
+		}
+		return nil
+	}
+
+	nq := &protos.NQuad{
+		Subject:   subject,
+		Predicate: pred,
+	}
+	if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+		key := structKey{fv.Type(), ptr}
+		if id, ok := b.seen[key]; ok && ptr != 0 {
+			nq.ObjectId = id
+			b.nquads = append(b.nquads, NQuad{nq})
+			return nil
+		}
+		nq.ObjectId = b.nodeOf(fv)
+		if ptr != 0 {
+			if b.seen == nil {
+				b.seen = make(map[structKey]string)
+			}
+			b.seen[key] = nq.ObjectId
+		}
+		b.nquads = append(b.nquads, NQuad{nq})
+		return b.addStruct(nq.ObjectId, fv)
+	}
+
+	ov, err := objectValueOf(fv)
+	if err != nil {
+		return err
+	}
+	nq.ObjectValue = ov
+	b.nquads = append(b.nquads, NQuad{nq})
+	return nil
+}
+
+func isStructElem(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && typ != timeType
+}
+
+// nodeOf returns the uid of the struct if it has one, and a new blank node
+// otherwise.
+func (b *structBuilder) nodeOf(val reflect.Value) string {
+	if uid := uidOfStruct(val); len(uid) > 0 {
+		return uid
+	}
+	return b.blankNode()
+}
+
+// FromSubgraph returns the mutation setting the exported fields of root and
+// of all the structs reachable from it, in the same way as SetStruct, with
+// root given a node of its own. Structs reached through the same pointer
+// more than once, as in diamonds and cycles, are set once and share a node.
+// The blank nodes used are returned mapped to zero, ready to be assigned uids.
+func FromSubgraph(root interface{}) (*Mutation, map[string]uint64, error) {
+	val := reflect.ValueOf(root)
+	var ptr uintptr
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		ptr = val.Pointer()
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, nil, x.Errorf("FromSubgraph expects a struct, got: %T", root)
+	}
+	var b structBuilder
+	subject := b.nodeOf(val)
+	if ptr != 0 {
+		// Cycles back to the root link to it.
+		b.seen = map[structKey]string{{val.Type(), ptr}: subject}
+	}
+	if err := b.addStruct(subject, val); err != nil {
+		return nil, nil, err
+	}
+
+	m := &Mutation{}
+	blanks := make(map[string]uint64)
+	if strings.HasPrefix(subject, "_:") {
+		blanks[subject] = 0
+	}
+	for _, nq := range b.nquads {
+		m.Set = append(m.Set, nq.NQuad)
+		for _, id := range []string{nq.Subject, nq.ObjectId} {
+			if strings.HasPrefix(id, "_:") {
+				blanks[id] = 0
+			}
+		}
+	}
+	return m, blanks, nil
+}
+
+// uidOfStruct returns the value of the field mapping to the uid predicate, if any.
+func uidOfStruct(val reflect.Value) string {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if pred, _, ok := predicateFor(f); !ok || pred != "uid" {
+			continue
+		}
+		if fv := val.Field(i); fv.Kind() == reflect.String {
+			return fv.String()
+		}
+	}
+	return ""
+}
+
+// stringerOf returns the value as a fmt.Stringer if it implements it, either
+// directly or through a pointer receiver.
+func stringerOf(fv reflect.Value) (fmt.Stringer, bool) {
+	if !fv.CanInterface() || fv.Type() == timeType {
+		return nil, false
+	}
+	if s, ok := fv.Interface().(fmt.Stringer); ok {
+		return s, true
+	}
+	if fv.CanAddr() {
+		s, ok := fv.Addr().Interface().(fmt.Stringer)
+		return s, ok
+	}
+	return nil, false
+}
+
+// objectValueOf converts a Go value into the protos.Value for its scalar type.
+// Values implementing fmt.Stringer, other than time.Time, are stored as strings.
+func objectValueOf(fv reflect.Value) (*protos.Value, error) {
+	if s, ok := stringerOf(fv); ok {
+		return types.ObjectValue(types.StringID, s.String())
+	}
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return types.ObjectValue(types.IntID, fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := fv.Uint()
+		if u > math.MaxInt64 {
+			return nil, x.Errorf("Value %d overflows int64", u)
+		}
+		return types.ObjectValue(types.IntID, int64(u))
+	case reflect.Float32, reflect.Float64:
+		return types.ObjectValue(types.FloatID, fv.Float())
+	case reflect.String:
+		return types.ObjectValue(types.StringID, fv.String())
+	case reflect.Bool:
+		return types.ObjectValue(types.BoolID, fv.Bool())
+	case reflect.Struct:
+		if fv.Type() == timeType {
+			return types.ObjectValue(types.DateTimeID, fv.Interface())
+		}
+	}
+	return nil, x.Errorf("Unsupported type %s", fv.Type())
+}
+
+// NodeBuilder accumulates NQuads which share the same subject. The first error
+// hit while adding an edge is kept and returned by NQuads.
+type NodeBuilder struct {
+	subject  string
+	nquads   []NQuad
+	err      error
+	nilEmpty bool
+}
+
+// SubjectBuilder returns a NodeBuilder for the NQuads of the given subject.
+func SubjectBuilder(subject string) *NodeBuilder {
+	b := &NodeBuilder{subject: subject}
+	if len(subject) == 0 {
+		b.err = x.Errorf("Subject can't be empty for SubjectBuilder")
+	}
+	return b
+}
+
+// NilStringerAsEmpty makes Add store an empty string for nil pointers to types
+// implementing fmt.Stringer, which are rejected by default.
+func (b *NodeBuilder) NilStringerAsEmpty() *NodeBuilder {
+	b.nilEmpty = true
+	return b
+}
+
+// Add adds an edge from the subject to a scalar value. The type of the value is
+// inferred from its Go type, in the same way as for SetStruct.
+func (b *NodeBuilder) Add(predicate string, value interface{}) *NodeBuilder {
+	if b.err != nil {
+		return b
+	}
+	fv := reflect.ValueOf(value)
+	if !fv.IsValid() {
+		b.err = x.Errorf("Value can't be nil for predicate %s", predicate)
+		return b
+	}
+	if fv.Kind() == reflect.Ptr && fv.IsNil() {
+		if !b.nilEmpty || !fv.Type().Implements(stringerType) {
+			b.err = x.Errorf("Value can't be nil for predicate %s", predicate)
+			return b
+		}
+		fv = reflect.ValueOf("")
+	}
+	ov, err := objectValueOf(fv)
+	if err != nil {
+		b.err = x.Wrapf(err, "while adding predicate %s", predicate)
+		return b
+	}
+	b.nquads = append(b.nquads, NQuad{&protos.NQuad{
+		Subject:     b.subject,
+		Predicate:   predicate,
+		ObjectValue: ov,
+	}})
+	return b
+}
+
+// SetValueWithWeight is like Add, but also sets the weight of the edge as the
+// float facet WeightFacet. The weight must be a finite number.
+func (b *NodeBuilder) SetValueWithWeight(predicate string, value interface{},
+	weight float64) *NodeBuilder {
+	if b.err != nil {
+		return b
+	}
+	if math.IsNaN(weight) || math.IsInf(weight, 0) {
+		b.err = x.Errorf("Weight for predicate %s should be a finite number. Got: %v",
+			predicate, weight)
+		return b
+	}
+	wf, err := facets.FloatFacet(WeightFacet, weight)
+	if err != nil {
+		b.err = x.Wrapf(err, "while adding weight for predicate %s", predicate)
+		return b
+	}
+	if b.Add(predicate, value); b.err != nil {
+		return b
+	}
+	nq := b.nquads[len(b.nquads)-1]
+	nq.Facets = append(nq.Facets, wf)
+	return b
+}
+
+// SetRawValue adds an edge from the subject to a value which has already been
+// marshalled to bytes for the given type. Geo and datetime values are kept as
+// given, as that is how they are stored in NQuads. Values of other types are
+// decoded into the protos.Value for their type.
+func (b *NodeBuilder) SetRawValue(predicate string, typ types.TypeID, data []byte) *NodeBuilder {
+	if b.err != nil {
+		return b
+	}
+	// Decoding checks that the bytes are valid for the type.
+	v, err := types.Convert(types.Val{Tid: types.BinaryID, Value: data}, typ)
+	if err != nil {
+		b.err = x.Wrapf(err, "while decoding raw %s value for predicate %s",
+			typ.Name(), predicate)
+		return b
+	}
+	var ov *protos.Value
+	switch typ {
+	case types.GeoID:
+		ov = &protos.Value{Val: &protos.Value_GeoVal{GeoVal: data}}
+	case types.DateTimeID:
+		ov = &protos.Value{Val: &protos.Value_DatetimeVal{DatetimeVal: data}}
+	default:
+		if ov, err = types.ObjectValue(typ, v.Value); err != nil {
+			b.err = x.Wrapf(err, "while adding predicate %s", predicate)
+			return b
+		}
+	}
+	b.nquads = append(b.nquads, NQuad{&protos.NQuad{
+		Subject:     b.subject,
+		Predicate:   predicate,
+		ObjectValue: ov,
+	}})
+	return b
+}
+
+// AddUID adds an edge from the subject to the node with the given xid.
+func (b *NodeBuilder) AddUID(predicate, objectXid string) *NodeBuilder {
+	if b.err != nil {
+		return b
+	}
+	if len(objectXid) == 0 {
+		b.err = x.Errorf("Object can't be empty for predicate %s", predicate)
+		return b
+	}
+	b.nquads = append(b.nquads, NQuad{&protos.NQuad{
+		Subject:   b.subject,
+		Predicate: predicate,
+		ObjectId:  objectXid,
+	}})
+	return b
+}
+
+// NQuads returns the NQuads added so far, or the first error hit while adding them.
+func (b *NodeBuilder) NQuads() ([]NQuad, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.nquads, nil
+}
@@ -0,0 +1,410 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/types/facets"
+	geom "github.com/twpayne/go-geom"
+)
+
+type school struct {
+	Uid  string `json:"uid"`
+	Name string `json:"name"`
+}
+
+type person struct {
+	Name    string    `json:"name"`
+	Age     int       `json:"age"`
+	Height  float64   `dgraph:"height" json:"h"`
+	Married bool      `json:"married,omitempty"`
+	Secret  string    `json:"-"`
+	Born    time.Time `json:"born,omitempty"`
+	School  *school   `json:"school,omitempty"`
+	private string
+}
+
+func TestSetStructFlat(t *testing.T) {
+	p := person{Name: "Alice", Age: 26, Height: 1.7, Secret: "x", private: "y"}
+	nqs, err := SetStruct("_:alice", &p)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(nqs))
+
+	require.Equal(t, "_:alice", nqs[0].Subject)
+	require.Equal(t, "name", nqs[0].Predicate)
+	require.Equal(t, "Alice", nqs[0].ObjectValue.GetStrVal())
+	require.Equal(t, "age", nqs[1].Predicate)
+	require.Equal(t, int64(26), nqs[1].ObjectValue.GetIntVal())
+	require.Equal(t, "height", nqs[2].Predicate)
+	require.Equal(t, 1.7, nqs[2].ObjectValue.GetDoubleVal())
+}
+
+func TestSetStructTime(t *testing.T) {
+	born := time.Date(1991, 3, 4, 0, 0, 0, 0, time.UTC)
+	nqs, err := SetStruct("_:alice", person{Name: "Alice", Born: born})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(nqs))
+
+	nq := nqs[3]
+	require.Equal(t, "born", nq.Predicate)
+	src := types.Val{Tid: types.BinaryID, Value: nq.ObjectValue.GetDatetimeVal()}
+	dst, err := types.Convert(src, types.DateTimeID)
+	require.NoError(t, err)
+	require.True(t, born.Equal(dst.Value.(time.Time)))
+}
+
+func TestSetStructNested(t *testing.T) {
+	p := person{Name: "Alice", School: &school{Name: "Wellington"}}
+	nqs, err := SetStruct("_:alice", p)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(nqs))
+
+	link := nqs[3]
+	require.Equal(t, "school", link.Predicate)
+	require.Equal(t, "_:blank-0", link.ObjectId)
+	require.Nil(t, link.ObjectValue)
+	require.Equal(t, "_:blank-0", nqs[4].Subject)
+	require.Equal(t, "name", nqs[4].Predicate)
+	require.Equal(t, "Wellington", nqs[4].ObjectValue.GetStrVal())
+
+	p.School.Uid = "0x1f"
+	nqs, err = SetStruct("_:alice", p)
+	require.NoError(t, err)
+	require.Equal(t, "0x1f", nqs[3].ObjectId)
+	require.Equal(t, "0x1f", nqs[4].Subject)
+}
+
+func TestSetStructUnsupported(t *testing.T) {
+	_, err := SetStruct("_:a", struct {
+		Tags []string `json:"tags"`
+	}{[]string{"a"}})
+	require.Error(t, err)
+
+	_, err = SetStruct("_:a", 10)
+	require.Error(t, err)
+}
+
+func TestIsZero(t *testing.T) {
+	var nilPtr *school
+	for _, v := range []interface{}{0, "", false, 0.0, time.Time{}, [2]int{}, nilPtr,
+		[]string(nil), school{}} {
+		require.True(t, isZero(reflect.ValueOf(v)), "%#v", v)
+	}
+	for _, v := range []interface{}{1, "a", true, 0.5, time.Unix(0, 0), [2]int{0, 1},
+		&school{}, []string{}, school{Name: "A"}} {
+		require.False(t, isZero(reflect.ValueOf(v)), "%#v", v)
+	}
+}
+
+func TestSubjectBuilder(t *testing.T) {
+	nqs, err := SubjectBuilder("_:alice").
+		Add("name", "Alice").
+		Add("age", 26).
+		AddUID("friend", "_:bob").
+		Add("height", 1.7).
+		AddUID("school", "0x1f").
+		NQuads()
+	require.NoError(t, err)
+	require.Equal(t, 5, len(nqs))
+	for _, nq := range nqs {
+		require.Equal(t, "_:alice", nq.Subject)
+	}
+
+	require.Equal(t, "Alice", nqs[0].ObjectValue.GetStrVal())
+	require.Equal(t, int64(26), nqs[1].ObjectValue.GetIntVal())
+	require.Equal(t, "friend", nqs[2].Predicate)
+	require.Equal(t, "_:bob", nqs[2].ObjectId)
+	require.Nil(t, nqs[2].ObjectValue)
+	require.Equal(t, 1.7, nqs[3].ObjectValue.GetDoubleVal())
+	require.Equal(t, "0x1f", nqs[4].ObjectId)
+}
+
+func TestSubjectBuilderErrors(t *testing.T) {
+	_, err := SubjectBuilder("_:a").Add("tags", []string{"a"}).Add("name", "A").NQuads()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "tags")
+
+	_, err = SubjectBuilder("_:a").Add("name", nil).NQuads()
+	require.Error(t, err)
+
+	_, err = SubjectBuilder("_:a").AddUID("friend", "").NQuads()
+	require.Error(t, err)
+
+	_, err = SubjectBuilder("").Add("name", "A").NQuads()
+	require.Error(t, err)
+}
+
+type color int
+
+func (c color) String() string {
+	return [...]string{"red", "green", "blue"}[c]
+}
+
+type version struct {
+	major, minor int
+}
+
+func (v *version) String() string {
+	return fmt.Sprintf("v%d.%d", v.major, v.minor)
+}
+
+func TestSubjectBuilderStringer(t *testing.T) {
+	nqs, err := SubjectBuilder("_:car").
+		Add("color", color(2)).
+		Add("version", &version{1, 4}).
+		NQuads()
+	require.NoError(t, err)
+	require.Equal(t, "blue", nqs[0].ObjectValue.GetStrVal())
+	require.Equal(t, "v1.4", nqs[1].ObjectValue.GetStrVal())
+
+	nqs, err = SetStruct("_:car", struct {
+		Color color `json:"color"`
+	}{color(1)})
+	require.NoError(t, err)
+	require.Equal(t, "green", nqs[0].ObjectValue.GetStrVal())
+}
+
+func TestSubjectBuilderNilStringer(t *testing.T) {
+	var v *version
+	_, err := SubjectBuilder("_:car").Add("version", v).NQuads()
+	require.Error(t, err)
+
+	nqs, err := SubjectBuilder("_:car").NilStringerAsEmpty().Add("version", v).NQuads()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(nqs))
+	require.Equal(t, "", nqs[0].ObjectValue.GetStrVal())
+}
+
+func TestSubjectBuilderSetValueWithWeight(t *testing.T) {
+	nqs, err := SubjectBuilder("_:alice").
+		SetValueWithWeight("score", int64(7), 0.25).
+		NQuads()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(nqs))
+	require.Equal(t, int64(7), nqs[0].ObjectValue.GetIntVal())
+	require.Equal(t, 1, len(nqs[0].Facets))
+	require.Equal(t, WeightFacet, nqs[0].Facets[0].Key)
+	require.Equal(t, protos.Facet_FLOAT, nqs[0].Facets[0].ValType)
+	require.Equal(t, 0.25, facets.ValFor(nqs[0].Facets[0]).Value)
+
+	edge, err := nqs[0].ToEdgeUsing(map[string]uint64{"_:alice": 1})
+	require.NoError(t, err)
+	require.Equal(t, WeightFacet, edge.Facets[0].Key)
+
+	_, err = SubjectBuilder("_:alice").SetValueWithWeight("score", 7, math.NaN()).NQuads()
+	require.Error(t, err)
+	_, err = SubjectBuilder("_:alice").SetValueWithWeight("score", 7, math.Inf(1)).NQuads()
+	require.Error(t, err)
+}
+
+func TestSubjectBuilderSetRawValue(t *testing.T) {
+	marshal := func(v types.Val) []byte {
+		b := types.ValueForType(types.BinaryID)
+		require.NoError(t, types.Marshal(v, &b))
+		return b.Value.([]byte)
+	}
+	loc := geom.NewPoint(geom.XY).MustSetCoords(geom.Coord{1.5, 2.5})
+	geoBytes := marshal(types.Val{Tid: types.GeoID, Value: loc})
+	born := time.Date(1991, 3, 4, 0, 0, 0, 0, time.UTC)
+	timeBytes := marshal(types.Val{Tid: types.DateTimeID, Value: born})
+	intBytes := marshal(types.Val{Tid: types.IntID, Value: int64(26)})
+
+	nqs, err := SubjectBuilder("_:alice").
+		SetRawValue("loc", types.GeoID, geoBytes).
+		SetRawValue("born", types.DateTimeID, timeBytes).
+		SetRawValue("age", types.IntID, intBytes).
+		NQuads()
+	require.NoError(t, err)
+	require.Equal(t, geoBytes, nqs[0].ObjectValue.GetGeoVal())
+	require.Equal(t, timeBytes, nqs[1].ObjectValue.GetDatetimeVal())
+	require.Equal(t, int64(26), nqs[2].ObjectValue.GetIntVal())
+
+	// The raw bytes are used as they are for the edge.
+	edge, err := nqs[0].ToEdgeUsing(map[string]uint64{"_:alice": 1})
+	require.NoError(t, err)
+	require.Equal(t, geoBytes, edge.Value)
+	require.Equal(t, types.GeoID.Enum(), edge.ValueType)
+
+	_, err = SubjectBuilder("_:alice").SetRawValue("age", types.IntID, []byte{1}).NQuads()
+	require.Error(t, err)
+	_, err = SubjectBuilder("_:alice").SetRawValue("loc", types.GeoID, []byte("x")).NQuads()
+	require.Error(t, err)
+}
+
+func TestSetMapFlat(t *testing.T) {
+	nqs, err := SetMap("_:alice", map[string]interface{}{
+		"name":    "Alice",
+		"age":     26,
+		"height":  1.7,
+		"married": true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(nqs))
+
+	require.Equal(t, "age", nqs[0].Predicate)
+	require.Equal(t, int64(26), nqs[0].ObjectValue.GetIntVal())
+	require.Equal(t, "height", nqs[1].Predicate)
+	require.Equal(t, 1.7, nqs[1].ObjectValue.GetDoubleVal())
+	require.Equal(t, "married", nqs[2].Predicate)
+	require.Equal(t, true, nqs[2].ObjectValue.GetBoolVal())
+	require.Equal(t, "_:alice", nqs[3].Subject)
+	require.Equal(t, "name", nqs[3].Predicate)
+	require.Equal(t, "Alice", nqs[3].ObjectValue.GetStrVal())
+}
+
+func TestSetMapNested(t *testing.T) {
+	nqs, err := SetMap("_:alice", map[string]interface{}{
+		"name":   "Alice",
+		"school": map[string]interface{}{"name": "Wellington"},
+		"city":   map[string]interface{}{"uid": "0x5", "name": "Sydney"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 5, len(nqs))
+
+	require.Equal(t, "city", nqs[0].Predicate)
+	require.Equal(t, "0x5", nqs[0].ObjectId)
+	require.Equal(t, "0x5", nqs[1].Subject)
+	require.Equal(t, "Sydney", nqs[1].ObjectValue.GetStrVal())
+	require.Equal(t, "name", nqs[2].Predicate)
+	require.Equal(t, "school", nqs[3].Predicate)
+	require.Equal(t, "_:blank-0", nqs[3].ObjectId)
+	require.Equal(t, "_:blank-0", nqs[4].Subject)
+	require.Equal(t, "Wellington", nqs[4].ObjectValue.GetStrVal())
+}
+
+func TestSetMapSlice(t *testing.T) {
+	nqs, err := SetMap("_:alice", map[string]interface{}{
+		"nickname": []interface{}{"Al", "Ally"},
+		"friend": []interface{}{
+			map[string]interface{}{"name": "Bob"},
+			map[string]interface{}{"name": "Carol"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 6, len(nqs))
+
+	require.Equal(t, "friend", nqs[0].Predicate)
+	require.Equal(t, "_:blank-0", nqs[0].ObjectId)
+	require.Equal(t, "Bob", nqs[1].ObjectValue.GetStrVal())
+	require.Equal(t, "friend", nqs[2].Predicate)
+	require.Equal(t, "_:blank-1", nqs[2].ObjectId)
+	require.Equal(t, "Carol", nqs[3].ObjectValue.GetStrVal())
+	require.Equal(t, "nickname", nqs[4].Predicate)
+	require.Equal(t, "Al", nqs[4].ObjectValue.GetStrVal())
+	require.Equal(t, "nickname", nqs[5].Predicate)
+	require.Equal(t, "Ally", nqs[5].ObjectValue.GetStrVal())
+
+	_, err = SetMap("_:alice", map[string]interface{}{"nickname": []interface{}{"Al", nil}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "nickname")
+}
+
+type graphNode struct {
+	Uid      string       `json:"uid,omitempty"`
+	Name     string       `json:"name"`
+	Children []*graphNode `json:"child,omitempty"`
+}
+
+func TestFromSubgraphDiamond(t *testing.T) {
+	d := &graphNode{Name: "d"}
+	b := &graphNode{Name: "b", Children: []*graphNode{d}}
+	c := &graphNode{Name: "c", Children: []*graphNode{d}}
+	a := &graphNode{Name: "a", Children: []*graphNode{b, c}}
+
+	m, blanks, err := FromSubgraph(a)
+	require.NoError(t, err)
+	// a, b, c and d each get one node.
+	require.Equal(t, map[string]uint64{
+		"_:blank-0": 0, "_:blank-1": 0, "_:blank-2": 0, "_:blank-3": 0}, blanks)
+
+	names := make(map[string]string)
+	var links []string
+	for _, nq := range m.Set {
+		switch nq.Predicate {
+		case "name":
+			names[nq.Subject] = nq.ObjectValue.GetStrVal()
+		case "child":
+			links = append(links, nq.Subject+"->"+nq.ObjectId)
+		}
+	}
+	require.Equal(t, 4, len(names))
+	require.Equal(t, "a", names["_:blank-0"])
+	nodeOf := make(map[string]string)
+	for id, name := range names {
+		nodeOf[name] = id
+	}
+	require.Equal(t, []string{
+		nodeOf["a"] + "->" + nodeOf["b"],
+		nodeOf["b"] + "->" + nodeOf["d"],
+		nodeOf["a"] + "->" + nodeOf["c"],
+		nodeOf["c"] + "->" + nodeOf["d"],
+	}, links)
+	// d is only set once.
+	require.Equal(t, 4+4, len(m.Set))
+}
+
+type innerNode struct {
+	Name string `json:"name"`
+}
+
+type outerNode struct {
+	Inner innerNode  `json:"inner"`
+	Peer  *innerNode `json:"peer,omitempty"`
+}
+
+func TestFromSubgraphFirstField(t *testing.T) {
+	// Peer points to the first field of the root, which has the same address
+	// as the root, but is another node.
+	root := &outerNode{Inner: innerNode{Name: "a"}}
+	root.Peer = &root.Inner
+
+	m, blanks, err := FromSubgraph(root)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(blanks))
+	require.Equal(t, 4, len(m.Set))
+	require.Equal(t, "peer", m.Set[2].Predicate)
+	require.NotEqual(t, m.Set[2].Subject, m.Set[2].ObjectId)
+	require.Equal(t, m.Set[2].ObjectId, m.Set[3].Subject)
+	require.Equal(t, "a", m.Set[3].ObjectValue.GetStrVal())
+}
+
+func TestFromSubgraphCycle(t *testing.T) {
+	a := &graphNode{Name: "a"}
+	b := &graphNode{Uid: "0x2", Name: "b", Children: []*graphNode{a}}
+	a.Children = []*graphNode{b}
+
+	m, blanks, err := FromSubgraph(a)
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint64{"_:blank-0": 0}, blanks)
+	require.Equal(t, 4, len(m.Set))
+	require.Equal(t, "0x2", m.Set[1].ObjectId)
+	require.Equal(t, "0x2", m.Set[3].Subject)
+	require.Equal(t, "_:blank-0", m.Set[3].ObjectId)
+
+	_, _, err = FromSubgraph("a")
+	require.Error(t, err)
+}
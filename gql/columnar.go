@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"time"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// ColumnBatch is a batch of rows stored by column, as exported by analytics
+// tools. Row i is about the node with xid Subjects[i].
+type ColumnBatch struct {
+	Subjects []string
+	Columns  []Column
+}
+
+// Column holds the values of a predicate for the rows of a batch. Values is
+// one of []string, []int64, []float64, []bool and []time.Time, which gives
+// the type of the values. Nulls, if set, marks the rows without a value.
+type Column struct {
+	Predicate string
+	Values    interface{}
+	Nulls     []bool
+}
+
+// columnValues returns the type of the values of the column, their number, and
+// a function returning the value at a row.
+func (c Column) columnValues() (types.TypeID, int, func(int) interface{}, error) {
+	switch v := c.Values.(type) {
+	case []string:
+		return types.StringID, len(v), func(i int) interface{} { return v[i] }, nil
+	case []int64:
+		return types.IntID, len(v), func(i int) interface{} { return v[i] }, nil
+	case []float64:
+		return types.FloatID, len(v), func(i int) interface{} { return v[i] }, nil
+	case []bool:
+		return types.BoolID, len(v), func(i int) interface{} { return v[i] }, nil
+	case []time.Time:
+		return types.DateTimeID, len(v), func(i int) interface{} { return v[i] }, nil
+	}
+	return 0, 0, nil, x.Errorf("Unsupported type %T for column of predicate %s", c.Values,
+		c.Predicate)
+}
+
+// FromColumnBatch converts the batch to edges, row by row and column by column
+// within a row, using newToUid to determine the UIDs for the subjects. Null
+// values don't give an edge. All the columns must have a value or a null for
+// every row.
+func FromColumnBatch(b ColumnBatch,
+	newToUid map[string]uint64) ([]*protos.DirectedEdge, error) {
+	type column struct {
+		tid types.TypeID
+		at  func(int) interface{}
+	}
+	cols := make([]column, len(b.Columns))
+	for j, c := range b.Columns {
+		tid, n, at, err := c.columnValues()
+		if err != nil {
+			return nil, err
+		}
+		if n != len(b.Subjects) || (c.Nulls != nil && len(c.Nulls) != n) {
+			return nil, x.Errorf("Column of predicate %s has %d values for %d rows",
+				c.Predicate, n, len(b.Subjects))
+		}
+		cols[j] = column{tid, at}
+	}
+	var edges []*protos.DirectedEdge
+	for i, subject := range b.Subjects {
+		for j, c := range b.Columns {
+			if c.Nulls != nil && c.Nulls[i] {
+				continue
+			}
+			val, err := types.ObjectValue(cols[j].tid, cols[j].at(i))
+			if err != nil {
+				return nil, x.Wrapf(err, "while converting row %d of column %s", i, c.Predicate)
+			}
+			nq := NQuad{&protos.NQuad{
+				Subject:     subject,
+				Predicate:   c.Predicate,
+				ObjectValue: val,
+			}}
+			edge, err := nq.ToEdgeUsing(newToUid)
+			if err != nil {
+				return nil, x.Wrapf(err, "while converting row %d of column %s", i, c.Predicate)
+			}
+			edges = append(edges, edge)
+		}
+	}
+	return edges, nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/types"
+)
+
+func TestFromColumnBatch(t *testing.T) {
+	born := time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)
+	b := ColumnBatch{
+		Subjects: []string{"_:a", "_:b"},
+		Columns: []Column{
+			{Predicate: "name", Values: []string{"Alice", "Bob"}},
+			{Predicate: "age", Values: []int64{31, 0}, Nulls: []bool{false, true}},
+			{Predicate: "score", Values: []float64{1.5, 2.5}},
+			{Predicate: "active", Values: []bool{true, false}},
+			{Predicate: "born", Values: []time.Time{born, born}},
+		},
+	}
+	newToUid := map[string]uint64{"_:a": 1, "_:b": 2}
+	edges, err := FromColumnBatch(b, newToUid)
+	require.NoError(t, err)
+	// The null age of _:b gives no edge.
+	require.Equal(t, 9, len(edges))
+
+	var got []string
+	for _, e := range edges {
+		got = append(got, e.Attr)
+	}
+	require.Equal(t, []string{"name", "age", "score", "active", "born",
+		"name", "score", "active", "born"}, got)
+
+	require.Equal(t, uint64(1), edges[1].Entity)
+	require.Equal(t, types.IntID.Enum(), edges[1].ValueType)
+	require.Equal(t, types.FloatID.Enum(), edges[2].ValueType)
+	require.Equal(t, types.BoolID.Enum(), edges[3].ValueType)
+	require.Equal(t, types.DateTimeID.Enum(), edges[4].ValueType)
+	require.Equal(t, uint64(2), edges[5].Entity)
+	require.Equal(t, "Bob", string(edges[5].Value))
+	require.Equal(t, protos.DirectedEdge_SET, edges[5].Op)
+}
+
+func TestFromColumnBatchErrors(t *testing.T) {
+	_, err := FromColumnBatch(ColumnBatch{
+		Subjects: []string{"_:a", "_:b"},
+		Columns:  []Column{{Predicate: "name", Values: []string{"Alice"}}},
+	}, nil)
+	require.Error(t, err)
+
+	_, err = FromColumnBatch(ColumnBatch{
+		Subjects: []string{"_:a"},
+		Columns:  []Column{{Predicate: "age", Values: []int{31}}},
+	}, nil)
+	require.Error(t, err)
+
+	_, err = FromColumnBatch(ColumnBatch{
+		Subjects: []string{"_:a"},
+		Columns: []Column{{Predicate: "age", Values: []int64{31},
+			Nulls: []bool{false, true}}},
+	}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// edgeNode holds the edges of one subject, in the order given.
+type edgeNode struct {
+	preds  []string // In the order they were first seen.
+	values map[string][]interface{}
+	uids   map[string][]uint64
+}
+
+// EdgesToJSON renders the set edges as the response to a query block named q
+// which would fetch them, for mocking the server in tests. The edges are
+// grouped by subject, and the subjects which aren't the object of another
+// subject are the roots of the result, or all of them if there are none.
+// Objects nest their own edges, and refer to nodes already being rendered by
+// uid, so that cycles end. Predicates with a single value have it as is,
+// while uid predicates always have a list. Values in a language are keyed by
+// pred@lang. Facets are left out. resolveXid gives the uid shown for a node,
+// which defaults to its uid in hex.
+func EdgesToJSON(edges []*protos.DirectedEdge,
+	resolveXid func(uint64) string) ([]byte, error) {
+	if resolveXid == nil {
+		resolveXid = func(uid uint64) string { return fmt.Sprintf("%#x", uid) }
+	}
+	nodes := make(map[uint64]*edgeNode)
+	var subjects []uint64
+	isObject := make(map[uint64]bool)
+	for i, e := range edges {
+		if e.Op != protos.DirectedEdge_SET {
+			return nil, x.Errorf("Edge at index %d has op %s, only sets can be rendered", i,
+				e.Op)
+		}
+		n, ok := nodes[e.Entity]
+		if !ok {
+			n = &edgeNode{
+				values: make(map[string][]interface{}),
+				uids:   make(map[string][]uint64),
+			}
+			nodes[e.Entity] = n
+			subjects = append(subjects, e.Entity)
+		}
+		pred := e.Attr
+		if len(e.Lang) > 0 {
+			pred += "@" + e.Lang
+		}
+		if len(n.values[pred]) == 0 && len(n.uids[pred]) == 0 {
+			n.preds = append(n.preds, pred)
+		}
+		if e.ValueId != 0 {
+			n.uids[pred] = append(n.uids[pred], e.ValueId)
+			if e.ValueId != e.Entity {
+				isObject[e.ValueId] = true
+			}
+			continue
+		}
+		tid := types.TypeID(e.ValueType)
+		v, err := types.Convert(types.Val{Tid: types.BinaryID, Value: e.Value}, tid)
+		if err != nil {
+			return nil, x.Wrapf(err, "while decoding value of edge at index %d", i)
+		}
+		n.values[pred] = append(n.values[pred], v)
+	}
+
+	var render func(uid uint64, path map[uint64]bool) map[string]interface{}
+	render = func(uid uint64, path map[uint64]bool) map[string]interface{} {
+		out := map[string]interface{}{"uid": resolveXid(uid)}
+		n, ok := nodes[uid]
+		if !ok || path[uid] {
+			return out
+		}
+		path[uid] = true
+		defer delete(path, uid)
+		for _, pred := range n.preds {
+			if uids := n.uids[pred]; len(uids) > 0 {
+				children := make([]interface{}, 0, len(uids))
+				for _, c := range uids {
+					children = append(children, render(c, path))
+				}
+				out[pred] = children
+				continue
+			}
+			if vals := n.values[pred]; len(vals) == 1 {
+				out[pred] = vals[0]
+			} else {
+				out[pred] = vals
+			}
+		}
+		return out
+	}
+
+	roots := make([]interface{}, 0, len(subjects))
+	for _, uid := range subjects {
+		if !isObject[uid] {
+			roots = append(roots, render(uid, make(map[uint64]bool)))
+		}
+	}
+	if len(roots) == 0 {
+		for _, uid := range subjects {
+			roots = append(roots, render(uid, make(map[uint64]bool)))
+		}
+	}
+	return json.Marshal(map[string]interface{}{"q": roots})
+}
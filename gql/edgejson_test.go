@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dgraph-io/dgraph/protos"
+)
+
+func TestEdgesToJSON(t *testing.T) {
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "name", ObjectValue: &protos.Value{
+			Val: &protos.Value_StrVal{StrVal: "Alice"}}},
+		{Subject: "0x1", Predicate: "age", ObjectValue: &protos.Value{
+			Val: &protos.Value_IntVal{IntVal: 31}}},
+		{Subject: "0x1", Predicate: "name", Lang: "fr", ObjectValue: &protos.Value{
+			Val: &protos.Value_StrVal{StrVal: "Alix"}}},
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"},
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x3"},
+		{Subject: "0x2", Predicate: "name", ObjectValue: &protos.Value{
+			Val: &protos.Value_StrVal{StrVal: "Bob"}}},
+		{Subject: "0x2", Predicate: "friend", ObjectId: "0x1"},
+	}}
+	edges, err := m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+
+	out, err := EdgesToJSON(edges, nil)
+	require.NoError(t, err)
+	// 0x1 is a friend of 0x2 too, so with no root left both are roots, and the
+	// cycle ends at the node being rendered.
+	require.JSONEq(t, `{"q": [
+		{"uid": "0x1", "name": "Alice", "age": 31, "name@fr": "Alix",
+			"friend": [
+				{"uid": "0x2", "name": "Bob", "friend": [{"uid": "0x1"}]},
+				{"uid": "0x3"}]},
+		{"uid": "0x2", "name": "Bob",
+			"friend": [
+				{"uid": "0x1", "name": "Alice", "age": 31, "name@fr": "Alix",
+					"friend": [{"uid": "0x2"}, {"uid": "0x3"}]}]}
+	]}`, string(out))
+
+	names := map[uint64]string{1: "_:alice", 2: "_:bob", 3: "_:carol"}
+	out, err = EdgesToJSON(edges[:5], func(uid uint64) string { return names[uid] })
+	require.NoError(t, err)
+	require.JSONEq(t, `{"q": [
+		{"uid": "_:alice", "name": "Alice", "age": 31, "name@fr": "Alix",
+			"friend": [{"uid": "_:bob"}, {"uid": "_:carol"}]}
+	]}`, string(out))
+}
+
+func TestEdgesToJSONErrors(t *testing.T) {
+	_, err := EdgesToJSON([]*protos.DirectedEdge{
+		{Entity: 1, Attr: "name", Value: []byte("A"), Op: protos.DirectedEdge_DEL},
+	}, nil)
+	require.Error(t, err)
+	_, err = EdgesToJSON([]*protos.DirectedEdge{
+		{Entity: 1, Attr: "age", Value: []byte{1}, ValueType: protos.Posting_INT},
+	}, nil)
+	require.Error(t, err)
+}
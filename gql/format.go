@@ -0,0 +1,277 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/types/facets"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// formatValue returns the RDF literal for the value, like "13"^^<xs:int>.
+func formatValue(val *protos.Value, lang string) (string, error) {
+	if val.GetDefaultVal() == x.Star {
+		return "*", nil
+	}
+	str, tid, err := stringOf(val)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(quoteLiteral(str))
+	if len(lang) > 0 {
+		buf.WriteByte('@')
+		buf.WriteString(lang)
+	} else if tid != types.DefaultID {
+		rdfType, ok := tid.RDFType()
+		if !ok {
+			return "", x.Errorf("No RDF type for value of type: %s", tid.Name())
+		}
+		buf.WriteString("^^<")
+		buf.WriteString(rdfType)
+		buf.WriteByte('>')
+	}
+	return buf.String(), nil
+}
+
+// stringOf returns the value as a string, along with its type. Binary values
+// are base64 encoded.
+func stringOf(val *protos.Value) (string, types.TypeID, error) {
+	p := typeValFrom(val)
+	// Geo and datetime values are already stored as bytes.
+	b, ok := p.Value.([]byte)
+	if !ok {
+		bv := types.ValueForType(types.BinaryID)
+		if err := types.Marshal(p, &bv); err != nil {
+			return "", p.Tid, err
+		}
+		b = bv.Value.([]byte)
+	}
+
+	if p.Tid == types.BinaryID {
+		// xs:base64Binary values are expected to be base64 encoded.
+		return base64.StdEncoding.EncodeToString(b), p.Tid, nil
+	}
+	sv, err := types.Convert(types.Val{Tid: p.Tid, Value: b}, types.StringID)
+	if err != nil {
+		return "", p.Tid, err
+	}
+	str := sv.Value.(string)
+	if (p.Tid == types.StringID || p.Tid == types.DefaultID) && str == "_nil_" {
+		str = ""
+	}
+	return str, p.Tid, nil
+}
+
+// quoteLiteral quotes s as an RDF literal, using only the escapes allowed by
+// the N-Quads grammar. Unlike strconv.Quote, it never writes \a, \v or \x.
+func quoteLiteral(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			switch {
+			case unicode.IsPrint(r):
+				buf.WriteRune(r)
+			case r > 0xFFFF:
+				fmt.Fprintf(&buf, `\U%08X`, r)
+			default:
+				fmt.Fprintf(&buf, `\u%04X`, r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// formatNode returns the RDF form of a subject or an object given by its xid
+// or by a variable.
+func formatNode(xid, varName string) string {
+	switch {
+	case len(varName) > 0:
+		return "uid(" + varName + ")"
+	case xid == x.Star:
+		return "*"
+	case strings.HasPrefix(xid, "_:"):
+		return xid
+	default:
+		return "<" + xid + ">"
+	}
+}
+
+func formatFacets(buf *bytes.Buffer, fcs []*protos.Facet) error {
+	if len(fcs) == 0 {
+		return nil
+	}
+	buf.WriteString(" (")
+	for i, f := range fcs {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		fVal := &types.Val{Tid: types.StringID}
+		if err := types.Marshal(facets.ValFor(f), fVal); err != nil {
+			return err
+		}
+		if facets.TypeIDFor(f) == types.StringID {
+			buf.WriteString(strconv.Quote(fVal.Value.(string)))
+		} else {
+			buf.WriteString(fVal.Value.(string))
+		}
+	}
+	buf.WriteByte(')')
+	return nil
+}
+
+// checkRDF returns an error if the NQuad has options which can't be written
+// in RDF, so that they aren't silently lost.
+func (nq NQuad) checkRDF() error {
+	switch {
+	case nq.Increment:
+		return x.Errorf("Increment of predicate %s can't be written in RDF", nq.Predicate)
+	case nq.CreateOnly:
+		return x.Errorf("Create only nquad for predicate %s can't be written in RDF",
+			nq.Predicate)
+	case nq.DefaultLang:
+		return x.Errorf("Default language value for predicate %s can't be written in RDF",
+			nq.Predicate)
+	case len(nq.ObjectIds) > 0:
+		return x.Errorf("List of uids for predicate %s should be expanded with "+
+			"ExpandObjectIds to be written in RDF", nq.Predicate)
+	}
+	return nil
+}
+
+// ToRDF returns the NQuad as a line in RDF N-Quad format, without the newline.
+// NQuads with options that RDF has no syntax for give an error.
+func (nq NQuad) ToRDF() (string, error) {
+	if err := nq.checkRDF(); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(formatNode(nq.Subject, nq.SubjectVar))
+	buf.WriteByte(' ')
+	if nq.Predicate == x.Star {
+		buf.WriteByte('*')
+	} else {
+		buf.WriteByte('<')
+		buf.WriteString(nq.Predicate)
+		buf.WriteByte('>')
+	}
+	buf.WriteByte(' ')
+	if nq.ObjectValue != nil {
+		val, err := formatValue(nq.ObjectValue, nq.Lang)
+		if err != nil {
+			return "", x.Wrapf(err, "while formatting value for predicate %s", nq.Predicate)
+		}
+		buf.WriteString(val)
+	} else {
+		buf.WriteString(formatNode(nq.ObjectId, nq.ObjectVar))
+	}
+	if len(nq.Label) > 0 {
+		buf.WriteString(" <")
+		buf.WriteString(nq.Label)
+		buf.WriteByte('>')
+	}
+	if err := formatFacets(&buf, nq.Facets); err != nil {
+		return "", err
+	}
+	buf.WriteString(" .")
+	return buf.String(), nil
+}
+
+func writeBlock(buf *bytes.Buffer, op string, lines []string) {
+	buf.WriteString("  ")
+	buf.WriteString(op)
+	buf.WriteString(" {\n")
+	for _, line := range lines {
+		buf.WriteString("    ")
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("  }\n")
+}
+
+// ToGraphQL returns the mutation in the text form accepted by the mutation
+// endpoint, with the set, delete and schema blocks in that order. Empty blocks
+// are left out. NQuads with a list of uids are written as a line for each uid.
+func (m Mutation) ToGraphQL() (string, error) {
+	rdfLines := func(nquads []*protos.NQuad) ([]string, error) {
+		lines := make([]string, 0, len(nquads))
+		for i, nq := range nquads {
+			expanded, err := NQuad{nq}.ExpandObjectIds()
+			if err != nil {
+				return nil, x.Wrapf(err, "while formatting nquad at index %d", i)
+			}
+			for _, enq := range expanded {
+				line, err := enq.ToRDF()
+				if err != nil {
+					return nil, x.Wrapf(err, "while formatting nquad at index %d", i)
+				}
+				lines = append(lines, line)
+			}
+		}
+		return lines, nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	if len(m.Set) > 0 {
+		lines, err := rdfLines(m.Set)
+		if err != nil {
+			return "", err
+		}
+		writeBlock(&buf, "set", lines)
+	}
+	if len(m.Del) > 0 {
+		lines, err := rdfLines(m.Del)
+		if err != nil {
+			return "", err
+		}
+		writeBlock(&buf, "delete", lines)
+	}
+	if schema := strings.TrimSpace(m.Schema); len(schema) > 0 {
+		writeBlock(&buf, "schema", strings.Split(schema, "\n"))
+	}
+	buf.WriteString("}\n")
+	return buf.String(), nil
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/rdf"
+	"github.com/dgraph-io/dgraph/types/facets"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+func TestMutationToGraphQLSet(t *testing.T) {
+	since, err := facets.FacetFor("since", "2006")
+	require.NoError(t, err)
+	m := Mutation{Set: []*protos.NQuad{
+		{
+			Subject:     "_:alice",
+			Predicate:   "name",
+			ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: "Alice \"A\""}},
+		},
+		{
+			Subject:     "0x1",
+			Predicate:   "age",
+			ObjectValue: &protos.Value{Val: &protos.Value_IntVal{IntVal: 26}},
+		},
+		{
+			Subject:     "_:alice",
+			Predicate:   "name",
+			Lang:        "fr",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}},
+		},
+		{
+			Subject:   "_:alice",
+			Predicate: "friend",
+			ObjectId:  "_:bob",
+			Facets:    []*protos.Facet{since},
+		},
+		{
+			SubjectVar: "a",
+			Predicate:  "friend",
+			ObjectVar:  "b",
+		},
+	}}
+	out, err := m.ToGraphQL()
+	require.NoError(t, err)
+	require.Equal(t, `{
+  set {
+    _:alice <name> "Alice \"A\"" .
+    <0x1> <age> "26"^^<xs:int> .
+    _:alice <name> "Alice"@fr .
+    _:alice <friend> _:bob (since=2006) .
+    uid(a) <friend> uid(b) .
+  }
+}
+`, out)
+}
+
+func TestMutationToGraphQLDelete(t *testing.T) {
+	star := &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: x.Star}}
+	m := Mutation{Del: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"},
+		{Subject: "0x1", Predicate: "name", ObjectValue: star},
+		{Subject: "0x1", Predicate: x.Star, ObjectValue: star},
+	}}
+	out, err := m.ToGraphQL()
+	require.NoError(t, err)
+	require.Equal(t, `{
+  delete {
+    <0x1> <friend> <0x2> .
+    <0x1> <name> * .
+    <0x1> * * .
+  }
+}
+`, out)
+}
+
+func TestMutationToGraphQLSchema(t *testing.T) {
+	m := Mutation{
+		Set: []*protos.NQuad{{
+			Subject:     "_:a",
+			Predicate:   "score",
+			ObjectValue: &protos.Value{Val: &protos.Value_DoubleVal{DoubleVal: 1.5}},
+		}},
+		Schema: "name: string @index(exact) .\nscore: float .\n",
+	}
+	out, err := m.ToGraphQL()
+	require.NoError(t, err)
+	require.Equal(t, `{
+  set {
+    _:a <score> "1.5E+00"^^<xs:float> .
+  }
+  schema {
+    name: string @index(exact) .
+    score: float .
+  }
+}
+`, out)
+}
+
+func TestToRDFEscapesRoundTrip(t *testing.T) {
+	for _, val := range []string{
+		"She said \"hi\"",
+		"line one\nline two\r\n",
+		"tab\tand\\backslash",
+		"Zürich \x00 \x07 \v \U0001F600  ",
+	} {
+		nq := NQuad{&protos.NQuad{
+			Subject:     "alice",
+			Predicate:   "bio",
+			ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: val}},
+		}}
+		line, err := nq.ToRDF()
+		require.NoError(t, err)
+		parsed, err := rdf.Parse(line)
+		require.NoError(t, err, "while parsing %s", line)
+		require.Equal(t, val, parsed.ObjectValue.GetDefaultVal(), "for line %s", line)
+	}
+}
+
+func TestToRDFJSONRoundTrip(t *testing.T) {
+	nq := NQuad{&protos.NQuad{
+		Subject:     "alice",
+		Predicate:   "profile",
+		ObjectValue: &protos.Value{Val: &protos.Value_JsonVal{JsonVal: []byte(`{"a":[1,2]}`)}},
+	}}
+	line, err := nq.ToRDF()
+	require.NoError(t, err)
+	require.Equal(t, `<alice> <profile> "{\"a\":[1,2]}"^^<json> .`, line)
+	parsed, err := rdf.Parse(line)
+	require.NoError(t, err)
+	require.Equal(t, nq.ObjectValue, parsed.ObjectValue)
+}
+
+func TestToRDFUnsupportedOptions(t *testing.T) {
+	value := &protos.Value{Val: &protos.Value_IntVal{IntVal: 1}}
+	for _, nq := range []*protos.NQuad{
+		{Subject: "0x1", Predicate: "visits", ObjectValue: value, Increment: true},
+		{Subject: "0x1", Predicate: "visits", ObjectValue: value, CreateOnly: true},
+		{Subject: "0x1", Predicate: "visits", ObjectValue: value, DefaultLang: true},
+		{Subject: "0x1", Predicate: "friend", ObjectIds: []string{"0x2"}},
+	} {
+		_, err := NQuad{nq}.ToRDF()
+		require.Error(t, err, "%+v", nq)
+	}
+
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "friend", ObjectIds: []string{"0x2", "_:b"}},
+	}}
+	out, err := m.ToGraphQL()
+	require.NoError(t, err)
+	require.Equal(t, `{
+  set {
+    <0x1> <friend> <0x2> .
+    <0x1> <friend> _:b .
+  }
+}
+`, out)
+
+	m.Set[0].Increment = true
+	_, err = m.ToGraphQL()
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/types"
+)
+
+// Warning points out a Set NQuad which is valid, but likely not what the
+// client meant, or which had to be changed to be accepted.
+type Warning struct {
+	Index     int // Index of the NQuad in the Set NQuads.
+	Predicate string
+	Message   string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("set nquad at index %d for predicate %s: %s", w.Index, w.Predicate,
+		w.Message)
+}
+
+var emailRe = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// lintString returns why the string value s is suspicious, or "" if it isn't.
+func lintString(s string) string {
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return fmt.Sprintf("string value %q is a number, consider storing it as int or float", s)
+	}
+	if _, err := types.ParseTime(s); err == nil {
+		return fmt.Sprintf("string value %q is a date, consider storing it as dateTime", s)
+	}
+	if emailRe.MatchString(s) {
+		return fmt.Sprintf("string value %q is an email address, make sure it's validated", s)
+	}
+	return ""
+}
+
+// LintMutation returns warnings for the Set NQuads with values of type string
+// which look like they should have another type. At most one warning is
+// returned per NQuad. Untyped values are converted to the type in the schema,
+// so they aren't checked.
+func LintMutation(m *Mutation) []Warning {
+	var warnings []Warning
+	for i, nq := range m.Set {
+		str, ok := nq.ObjectValue.GetVal().(*protos.Value_StrVal)
+		if !ok {
+			continue
+		}
+		if msg := lintString(str.StrVal); msg != "" {
+			warnings = append(warnings, Warning{Index: i, Predicate: nq.Predicate, Message: msg})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dgraph-io/dgraph/protos"
+)
+
+func lintNQuad(pred, val string) *protos.NQuad {
+	return &protos.NQuad{
+		Subject:     "_:a",
+		Predicate:   pred,
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: val}},
+	}
+}
+
+func TestLintMutation(t *testing.T) {
+	m := &Mutation{Set: []*protos.NQuad{
+		lintNQuad("name", "Alice"),
+		lintNQuad("age", "31"),
+		lintNQuad("score", "-2.5e3"),
+		lintNQuad("born", "1986-03-14T08:00:00Z"),
+		lintNQuad("mail", "alice@dgraph.io"),
+		{Subject: "_:a", Predicate: "height",
+			ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: "1.70"}}},
+	}}
+	warnings := LintMutation(m)
+	require.Equal(t, 4, len(warnings))
+
+	require.Equal(t, 1, warnings[0].Index)
+	require.Equal(t, "age", warnings[0].Predicate)
+	require.Contains(t, warnings[0].Message, "number")
+	require.Equal(t, 2, warnings[1].Index)
+	require.Contains(t, warnings[1].Message, "number")
+	require.Equal(t, 3, warnings[2].Index)
+	require.Contains(t, warnings[2].Message, "dateTime")
+	require.Equal(t, 4, warnings[3].Index)
+	require.Contains(t, warnings[3].Message, "email")
+	require.Contains(t, warnings[3].String(), "index 4")
+}
+
+func TestLintMutationClean(t *testing.T) {
+	m := &Mutation{
+		Set: []*protos.NQuad{
+			lintNQuad("name", "Alice"),
+			lintNQuad("bio", "Born in 1986, moved to Sydney."),
+			lintNQuad("handle", "@alice"),
+			lintNQuad("nan", "NaN"),
+			{Subject: "_:a", Predicate: "friend", ObjectId: "_:b"},
+		},
+		Del: []*protos.NQuad{lintNQuad("age", "31")},
+	}
+	require.Empty(t, LintMutation(m))
+}
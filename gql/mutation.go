@@ -18,16 +18,36 @@
 package gql
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dgryski/go-farm"
+	"github.com/golang/protobuf/proto"
+	geom "github.com/twpayne/go-geom"
+	"golang.org/x/net/context"
 
 	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/tok"
 	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/types/facets"
 	"github.com/dgraph-io/dgraph/x"
 )
 
 var (
-	ErrInvalidUID = errors.New("UID has to be greater than one.")
+	ErrInvalidUID  = errors.New("UID has to be greater than one.")
+	ErrReservedUID = errors.New("UID is in the reserved range.")
 )
 
 // Mutation stores the strings corresponding to set and delete operations.
@@ -36,6 +56,33 @@ type Mutation struct {
 	Del     []*protos.NQuad
 	DropAll bool
 	Schema  string
+	// Cond, if set, identifies a node of the Set NQuads by the value of a
+	// unique predicate, see UpsertByPredicate. The server resolves it with
+	// ResolveCond before applying the mutation.
+	Cond *Condition
+}
+
+// Condition stands for the node whose Predicate has the value Value. Subject
+// is the blank node used for it in the NQuads of the mutation, which is to be
+// replaced by the uid of the node if it exists, see Mutation.ResolveCond.
+type Condition struct {
+	Subject   string
+	Predicate string
+	Value     *protos.Value
+}
+
+// Function returns the eq function matching the nodes which satisfy the
+// condition.
+func (c *Condition) Function() (*Function, error) {
+	if len(c.Predicate) == 0 || c.Value == nil || c.Value.Val == nil {
+		return nil, x.Errorf("Condition needs a predicate and a value")
+	}
+	str, _, err := stringOf(c.Value)
+	if err != nil {
+		return nil, x.Wrapf(err, "While converting the value of the condition on %s",
+			c.Predicate)
+	}
+	return &Function{Name: "eq", Attr: c.Predicate, Args: []Arg{{Value: str}}}, nil
 }
 
 // HasOps returns true iff the mutation has at least one non-empty
@@ -44,6 +91,638 @@ func (m Mutation) HasOps() bool {
 	return len(m.Set) > 0 || len(m.Del) > 0 || len(m.Schema) > 0 || m.DropAll
 }
 
+// Clone returns a deep copy of the mutation, which can be modified without
+// affecting m. That includes the facets and values of the NQuads.
+func (m Mutation) Clone() *Mutation {
+	clone := func(nquads []*protos.NQuad) []*protos.NQuad {
+		if nquads == nil {
+			return nil
+		}
+		out := make([]*protos.NQuad, len(nquads))
+		for i, nq := range nquads {
+			out[i] = proto.Clone(nq).(*protos.NQuad)
+		}
+		return out
+	}
+	out := &Mutation{
+		Set:     clone(m.Set),
+		Del:     clone(m.Del),
+		DropAll: m.DropAll,
+		Schema:  m.Schema,
+	}
+	if m.Cond != nil {
+		cond := *m.Cond
+		cond.Value = proto.Clone(m.Cond.Value).(*protos.Value)
+		out.Cond = &cond
+	}
+	return out
+}
+
+// UpsertByPredicate returns a mutation setting setNQuads on the node whose
+// predicate has the given value, or on a new node with that value if there is
+// none. The NQuads must all have the same blank node as subject, which becomes
+// the subject of the condition of the mutation. The NQuad setting the value is
+// added unless it's already there, so that a new node can be found next time.
+func UpsertByPredicate(predicate string, value *protos.Value,
+	setNQuads []NQuad) (*Mutation, error) {
+	if len(predicate) == 0 || value == nil || value.Val == nil {
+		return nil, x.Errorf("Upsert needs a predicate and a value")
+	}
+	if len(setNQuads) == 0 {
+		return nil, x.Errorf("Upsert of %s needs at least one nquad to set", predicate)
+	}
+	subject := setNQuads[0].Subject
+	if !strings.HasPrefix(subject, "_:") {
+		return nil, x.Errorf("Subject %q of upsert should be a blank node", subject)
+	}
+	m := &Mutation{
+		Cond: &Condition{Subject: subject, Predicate: predicate, Value: value},
+	}
+	hasValue := false
+	for i, nq := range setNQuads {
+		if nq.Subject != subject || len(nq.SubjectVar) > 0 {
+			return nil, x.Errorf("Set nquad at index %d has subject %q, expected %q", i,
+				nq.Subject, subject)
+		}
+		if nq.Predicate == predicate && len(nq.Lang) == 0 &&
+			proto.Equal(nq.ObjectValue, value) {
+			hasValue = true
+		}
+		m.Set = append(m.Set, nq.NQuad)
+	}
+	if !hasValue {
+		m.Set = append(m.Set, &protos.NQuad{
+			Subject:     subject,
+			Predicate:   predicate,
+			ObjectValue: value,
+		})
+	}
+	return m, nil
+}
+
+// ResolveCond replaces the subject of the condition of the mutation with uid,
+// the node found to satisfy it, in all the NQuads. If no node was found, uid is
+// empty and the blank node is left to be assigned a new uid. The condition is
+// dropped either way.
+func (m *Mutation) ResolveCond(uid string) {
+	if m.Cond == nil {
+		return
+	}
+	blank := m.Cond.Subject
+	m.Cond = nil
+	if len(uid) == 0 {
+		return
+	}
+	replace := func(nquads []*protos.NQuad) {
+		for i, nq := range nquads {
+			inList := false
+			for _, id := range nq.ObjectIds {
+				inList = inList || id == blank
+			}
+			if nq.Subject != blank && nq.ObjectId != blank && !inList {
+				continue
+			}
+			cp := *nq
+			if cp.Subject == blank {
+				cp.Subject = uid
+			}
+			if cp.ObjectId == blank {
+				cp.ObjectId = uid
+			}
+			if inList {
+				// Copy the list, so that the NQuad given is left as it is.
+				cp.ObjectIds = make([]string, len(nq.ObjectIds))
+				for j, id := range nq.ObjectIds {
+					if id == blank {
+						id = uid
+					}
+					cp.ObjectIds[j] = id
+				}
+			}
+			nquads[i] = &cp
+		}
+	}
+	replace(m.Set)
+	replace(m.Del)
+}
+
+// SchemaOptions holds the options used by Mutation.ParseSchema.
+type SchemaOptions struct {
+	// LastDuplicateWins keeps the last declaration of a predicate which is
+	// declared more than once. By default, differing declarations are an error.
+	LastDuplicateWins bool
+}
+
+// ParseSchema parses the schema of the mutation. A predicate declared more
+// than once the same way is only returned once. A predicate declared more than
+// once in different ways is an error, unless opts.LastDuplicateWins is set, in
+// which case its last declaration is returned where the first one was.
+func (m Mutation) ParseSchema(opts SchemaOptions) ([]*protos.SchemaUpdate, error) {
+	updates, err := schema.Parse(m.Schema)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	out := make([]*protos.SchemaUpdate, 0, len(updates))
+	for _, update := range updates {
+		i, ok := index[update.Predicate]
+		if !ok {
+			index[update.Predicate] = len(out)
+			out = append(out, update)
+			continue
+		}
+		if proto.Equal(out[i], update) {
+			continue
+		}
+		if !opts.LastDuplicateWins {
+			return nil, x.Errorf("Predicate %s is declared more than once with different schemas",
+				update.Predicate)
+		}
+		out[i] = update
+	}
+	return out, nil
+}
+
+// Map replaces every Set and Del NQuad with the result of applying f to it.
+// It stops at the first error, leaving the NQuads before it already replaced.
+func (m Mutation) Map(f func(NQuad) (NQuad, error)) error {
+	apply := func(nquads []*protos.NQuad, op string) error {
+		for i, nq := range nquads {
+			out, err := f(NQuad{nq})
+			if err != nil {
+				return x.Wrapf(err, "while mapping %s nquad at index %d", op, i)
+			}
+			if out.NQuad == nil {
+				return x.Errorf("Map returned nil for %s nquad at index %d", op, i)
+			}
+			nquads[i] = out.NQuad
+		}
+		return nil
+	}
+	if err := apply(m.Set, "set"); err != nil {
+		return err
+	}
+	return apply(m.Del, "delete")
+}
+
+// PredicatePolicy says how Mutation.NormalizePredicates rewrites predicates.
+type PredicatePolicy struct {
+	TrimSpace bool
+	Lowercase bool
+}
+
+// PredicateACL says which predicates may be written, see ConvertOptions.ACL.
+type PredicateACL struct {
+	// Allow, if not empty, lists the only predicates which may be written.
+	Allow []string
+	// Deny lists the predicates which may not be written.
+	Deny []string
+}
+
+// Check returns an error if the ACL forbids writing to pred. The reverse
+// predicate ~P is checked as P. Predicate * is forbidden by any list, as there
+// is no telling which predicates it touches.
+func (acl PredicateACL) Check(pred string) error {
+	if len(acl.Allow) == 0 && len(acl.Deny) == 0 {
+		return nil
+	}
+	if pred == x.Star {
+		return x.Errorf("Predicate * isn't allowed by the predicate ACL")
+	}
+	name := strings.TrimPrefix(pred, "~")
+	for _, d := range acl.Deny {
+		if d == name {
+			return x.Errorf("Predicate %s is denied by the predicate ACL", pred)
+		}
+	}
+	if len(acl.Allow) == 0 {
+		return nil
+	}
+	for _, a := range acl.Allow {
+		if a == name {
+			return nil
+		}
+	}
+	return x.Errorf("Predicate %s isn't allowed by the predicate ACL", pred)
+}
+
+// ToEdgeUsing is like NQuad.ToEdgeUsing, but gives an error if the ACL
+// forbids writing to the predicate of the NQuad.
+func (acl PredicateACL) ToEdgeUsing(nq NQuad,
+	newToUid map[string]uint64) (*protos.DirectedEdge, error) {
+	resolve := func(xid string) (uint64, error) {
+		return toUid(xid, newToUid)
+	}
+	return nq.toEdgeFor(protos.DirectedEdge_SET, acl, resolve, resolve)
+}
+
+// ToDeleteEdgeUsing is like NQuad.ToDeleteEdgeUsing, but gives an error if the
+// ACL forbids writing to the predicate of the NQuad.
+func (acl PredicateACL) ToDeleteEdgeUsing(nq NQuad,
+	newToUid map[string]uint64) (*protos.DirectedEdge, error) {
+	resolve := func(xid string) (uint64, error) {
+		return toUid(xid, newToUid)
+	}
+	edge, err := nq.toEdgeFor(protos.DirectedEdge_DEL, acl, resolve, resolve)
+	if err != nil {
+		return nil, err
+	}
+	edge.Op = protos.DirectedEdge_DEL
+	return edge, nil
+}
+
+// Normalize returns pred rewritten according to the policy.
+func (p PredicatePolicy) Normalize(pred string) string {
+	if p.TrimSpace {
+		pred = strings.TrimSpace(pred)
+	}
+	if p.Lowercase {
+		pred = strings.ToLower(pred)
+	}
+	return pred
+}
+
+// NormalizePredicates rewrites the predicates of the Set and Del NQuads and of
+// the schema of the mutation according to the policy, so that the data keeps
+// matching its schema. The schema is expected to declare one predicate per
+// line, which is how it is written and exported.
+func (m *Mutation) NormalizePredicates(policy PredicatePolicy) {
+	m.renamePredicates(policy.Normalize)
+}
+
+// RenamePredicate renames the predicate old to new in the Set and Del NQuads,
+// including its reverse ~old, and in the schema of the mutation, as with
+// NormalizePredicates. It returns the number of NQuads and schema lines
+// changed.
+func (m *Mutation) RenamePredicate(old, new string) int {
+	return m.renamePredicates(func(pred string) string {
+		switch pred {
+		case old:
+			return new
+		case "~" + old:
+			return "~" + new
+		}
+		return pred
+	})
+}
+
+// renamePredicates applies rename to the predicates of the NQuads and schema
+// of the mutation, returning the number of NQuads and schema lines changed.
+func (m *Mutation) renamePredicates(rename func(string) string) int {
+	changed := 0
+	// The function given never fails.
+	_ = m.Map(func(nq NQuad) (NQuad, error) {
+		pred := rename(nq.Predicate)
+		if pred == nq.Predicate {
+			return nq, nil
+		}
+		changed++
+		cp := *nq.NQuad
+		cp.Predicate = pred
+		return NQuad{&cp}, nil
+	})
+	if len(m.Schema) == 0 {
+		return changed
+	}
+	lines := strings.Split(m.Schema, "\n")
+	for i, line := range lines {
+		if lines[i] = renameSchemaLine(line, rename); lines[i] != line {
+			changed++
+		}
+	}
+	m.Schema = strings.Join(lines, "\n")
+	return changed
+}
+
+// renameSchemaLine rewrites the predicate declared by a line of schema.
+// Lines which don't declare a predicate are returned as is.
+func renameSchemaLine(line string, rename func(string) string) string {
+	rest := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(rest)]
+	if strings.HasPrefix(rest, "<") {
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return line
+		}
+		return indent + "<" + rename(rest[1:end]) + rest[end:]
+	}
+	colon := strings.IndexByte(rest, ':')
+	if colon <= 0 {
+		return line
+	}
+	// Keep the space before the colon, so that only the predicate changes.
+	pred := strings.TrimRight(rest[:colon], " \t")
+	return indent + rename(pred) + rest[len(pred):]
+}
+
+// indexFor returns the tokenizers of which any lets the function fn be used on
+// values of type tid, with the one to suggest first, or nil if fn needs no
+// index or can't be used with the type. ineq is whether the predicate is also
+// compared with an inequality, for which strings need an exact index.
+func indexFor(fn string, tid types.TypeID, ineq bool) []string {
+	isString := tid == types.StringID || tid == types.DefaultID
+	switch fn {
+	case "eq":
+		if isString && !ineq {
+			return []string{"hash", "exact"}
+		}
+		if tid == types.BoolID {
+			return []string{"bool"}
+		}
+		return indexFor("lt", tid, ineq)
+	case "lt", "le", "gt", "ge":
+		switch {
+		case isString:
+			return []string{"exact"}
+		case tid == types.IntID:
+			return []string{"int"}
+		case tid == types.FloatID:
+			return []string{"float"}
+		case tid == types.DateTimeID:
+			return []string{"year", "month", "day", "hour"}
+		}
+	case "allofterms", "anyofterms":
+		if isString {
+			return []string{"term"}
+		}
+	case "alloftext", "anyoftext":
+		if isString {
+			return []string{tok.FTSTokenizerName}
+		}
+	case "regexp":
+		if isString {
+			return []string{"trigram"}
+		}
+	case "near", "within", "contains", "intersects":
+		if tid == types.GeoID {
+			return []string{"geo"}
+		}
+	}
+	return nil
+}
+
+// SuggestIndexes returns the schema updates adding the indexes needed to use
+// the filter functions in queryPreds, keyed by predicate, on the predicates of
+// the schema of the mutation. Predicates missing from the schema are taken to
+// be untyped. Each update keeps the indexes the predicate has, and adds the
+// tokenizers for the functions none of them supports. Predicates needing no
+// new index, and functions which need no index or don't apply to the type of
+// the predicate, are left out. The updates are sorted by predicate.
+func (m Mutation) SuggestIndexes(queryPreds map[string][]string) ([]*protos.SchemaUpdate,
+	error) {
+	updates, err := m.ParseSchema(SchemaOptions{})
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]*protos.SchemaUpdate, len(updates))
+	for _, update := range updates {
+		current[update.Predicate] = update
+	}
+	var out []*protos.SchemaUpdate
+	for pred, fns := range queryPreds {
+		update := &protos.SchemaUpdate{Predicate: pred}
+		if cur, ok := current[pred]; ok {
+			update = proto.Clone(cur).(*protos.SchemaUpdate)
+		}
+		tid := types.TypeID(update.ValueType)
+		has := make(map[string]bool)
+		for _, t := range update.Tokenizer {
+			has[t] = true
+		}
+		ineq := false
+		for _, fn := range fns {
+			switch fn {
+			case "lt", "le", "gt", "ge":
+				ineq = true
+			}
+		}
+		added := false
+	nextFn:
+		for _, fn := range fns {
+			tokenizers := indexFor(fn, tid, ineq)
+			if len(tokenizers) == 0 {
+				continue
+			}
+			for _, t := range tokenizers {
+				if has[t] {
+					continue nextFn
+				}
+			}
+			has[tokenizers[0]] = true
+			update.Tokenizer = append(update.Tokenizer, tokenizers[0])
+			added = true
+		}
+		if !added {
+			continue
+		}
+		update.Directive = protos.SchemaUpdate_INDEX
+		sort.Strings(update.Tokenizer)
+		out = append(out, update)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Predicate < out[j].Predicate })
+	return out, nil
+}
+
+// ShardFor returns the shard, out of numShards, which serves the predicate.
+// The reverse predicate ~P is served by the shard of P. With one shard or
+// less, everything is served by shard 0.
+func ShardFor(pred string, numShards int) int {
+	if numShards <= 1 {
+		return 0
+	}
+	pred = strings.TrimPrefix(pred, "~")
+	return int(farm.Fingerprint64([]byte(pred)) % uint64(numShards))
+}
+
+// SplitByShard splits the mutation into a mutation per shard, keyed by shard,
+// with the Set and Del NQuads and the schema lines for the predicates served
+// by the shard, see ShardFor. NQuads for predicate *, which touch all the
+// predicates of their subject, and DropAll go to every shard. Shards which
+// would get an empty mutation are left out. Schema lines not declaring a
+// predicate are dropped.
+func (m Mutation) SplitByShard(numShards int) map[int]*Mutation {
+	if numShards < 1 {
+		numShards = 1
+	}
+	shards := make(map[int]*Mutation)
+	shard := func(i int) *Mutation {
+		sm, ok := shards[i]
+		if !ok {
+			sm = &Mutation{Cond: m.Cond}
+			shards[i] = sm
+		}
+		return sm
+	}
+	route := func(nq *protos.NQuad, add func(*Mutation)) {
+		if nq.Predicate != x.Star {
+			add(shard(ShardFor(nq.Predicate, numShards)))
+			return
+		}
+		for i := 0; i < numShards; i++ {
+			add(shard(i))
+		}
+	}
+	for _, nq := range m.Set {
+		route(nq, func(sm *Mutation) { sm.Set = append(sm.Set, nq) })
+	}
+	for _, nq := range m.Del {
+		route(nq, func(sm *Mutation) { sm.Del = append(sm.Del, nq) })
+	}
+	for _, line := range strings.Split(m.Schema, "\n") {
+		var pred string
+		renameSchemaLine(line, func(p string) string {
+			pred = p
+			return p
+		})
+		if len(pred) == 0 {
+			continue
+		}
+		sm := shard(ShardFor(pred, numShards))
+		if len(sm.Schema) > 0 {
+			sm.Schema += "\n"
+		}
+		sm.Schema += line
+	}
+	if m.DropAll {
+		for i := 0; i < numShards; i++ {
+			shard(i).DropAll = true
+		}
+	}
+	return shards
+}
+
+// ToProto converts the mutation to the form the server applies, with the Set
+// and Del NQuads converted to edges using newToUid and the schema parsed. The
+// NQuads can't use variables, as those are only known when running a query.
+func (m Mutation) ToProto(newToUid map[string]uint64) (*protos.Mutations, error) {
+	if vars := m.NeededVars(); len(vars) > 0 {
+		return nil, x.Errorf("Mutation uses variables %v, which need a query", vars)
+	}
+	edges, err := m.ToEdges(newToUid, ConvertOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var updates []*protos.SchemaUpdate
+	if len(m.Schema) > 0 {
+		if updates, err = m.ParseSchema(SchemaOptions{}); err != nil {
+			return nil, x.Wrapf(err, "while parsing schema")
+		}
+	}
+	return &protos.Mutations{
+		Edges:   edges,
+		Schema:  updates,
+		DropAll: m.DropAll,
+	}, nil
+}
+
+// ReverseEdgePreview returns the reverse edges the Set NQuads of the mutation
+// would add for the predicates in reversible, to gauge their volume before
+// adding @reverse to the schema. A reverse edge goes from the object to the
+// subject, with the predicate ~P. Only uid objects have reverse edges. Xids
+// are resolved with GetUid, and NQuads using variables, which are only known
+// when running a query, are left out.
+func (m Mutation) ReverseEdgePreview(reversible map[string]bool) []*protos.DirectedEdge {
+	var edges []*protos.DirectedEdge
+	for _, nq := range m.Set {
+		if !reversible[nq.Predicate] || len(nq.SubjectVar) > 0 {
+			continue
+		}
+		objects := nq.ObjectIds
+		if len(nq.ObjectId) > 0 {
+			objects = []string{nq.ObjectId}
+		}
+		if len(objects) == 0 {
+			continue
+		}
+		sUid, err := GetUid(nq.Subject)
+		if err != nil {
+			continue
+		}
+		for _, id := range objects {
+			oUid, err := GetUid(id)
+			if err != nil {
+				continue
+			}
+			edges = append(edges, &protos.DirectedEdge{
+				Entity:  oUid,
+				Attr:    "~" + nq.Predicate,
+				ValueId: sUid,
+				Op:      protos.DirectedEdge_SET,
+			})
+		}
+	}
+	return edges
+}
+
+// AssertSingleSubject returns the uid of the subject of the Set and Del NQuads
+// of the mutation, resolved with GetUid, or an error if they don't all have
+// the same one. Subjects given as variables can't be resolved, so they are an
+// error too.
+func (m Mutation) AssertSingleSubject() (uint64, error) {
+	var uid uint64
+	check := func(nquads []*protos.NQuad, name string) error {
+		for i, nq := range nquads {
+			if len(nq.SubjectVar) > 0 {
+				return x.Errorf("Subject of %s nquad at index %d is variable %s, which "+
+					"can't be resolved", name, i, nq.SubjectVar)
+			}
+			sUid, err := GetUid(nq.Subject)
+			if err != nil {
+				return x.Wrapf(err, "while resolving subject of %s nquad at index %d", name, i)
+			}
+			if uid != 0 && sUid != uid {
+				return x.Errorf("Mutation should have a single subject, but %s nquad at index "+
+					"%d has subject %s (%#x) instead of %#x", name, i, nq.Subject, sUid, uid)
+			}
+			uid = sUid
+		}
+		return nil
+	}
+	if err := check(m.Set, "set"); err != nil {
+		return 0, err
+	}
+	if err := check(m.Del, "delete"); err != nil {
+		return 0, err
+	}
+	if uid == 0 {
+		return 0, x.Errorf("Mutation has no nquads to take the subject from")
+	}
+	return uid, nil
+}
+
+// NeededVars returns the sorted names of the variables used by the Set and Del
+// NQuads of the mutation, through uid(var) subjects and objects.
+func (m Mutation) NeededVars() []string {
+	var vars []string
+	for _, nqs := range [][]*protos.NQuad{m.Set, m.Del} {
+		for _, nq := range nqs {
+			if len(nq.SubjectVar) > 0 {
+				vars = append(vars, nq.SubjectVar)
+			}
+			if len(nq.ObjectVar) > 0 {
+				vars = append(vars, nq.ObjectVar)
+			}
+		}
+	}
+	return x.RemoveDuplicates(vars)
+}
+
+// CheckVars returns an error listing the variables used by the mutation which
+// aren't among the defined ones, usually those defined by the query run along
+// with the mutation.
+func (m Mutation) CheckVars(defined map[string]bool) error {
+	var missing []string
+	for _, v := range m.NeededVars() {
+		if !defined[v] {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) > 0 {
+		return x.Errorf("Some variables are used but not defined: %v", missing)
+	}
+	return nil
+}
+
 // Gets the uid corresponding
 func ParseUid(xid string) (uint64, error) {
 	// If string represents a UID, convert to uint64 and return.
@@ -57,10 +736,98 @@ func ParseUid(xid string) (uint64, error) {
 	return uid, nil
 }
 
+// GetUid returns the uid for the xid. Numeric xids are parsed as uids, while
+// any other xid, including blank nodes, maps to its fingerprint. Unlike the
+// uids leased while running a mutation, these are stable across calls, which
+// makes them useful to analyze data before loading it.
+func GetUid(xid string) (uint64, error) {
+	if len(xid) == 0 {
+		return 0, x.Errorf("Xid can't be empty")
+	}
+	uid, err := ParseUid(xid)
+	if err == nil || err == ErrInvalidUID {
+		return uid, err
+	}
+	return FingerprintXid(xid), nil
+}
+
+// GetUidOutside is like GetUid, but returns ErrReservedUID for numeric xids
+// below reserved, so that clients can't write to the nodes kept for internal
+// use. Fingerprints of other xids aren't checked.
+func GetUidOutside(xid string, reserved uint64) (uint64, error) {
+	if len(xid) == 0 {
+		return 0, x.Errorf("Xid can't be empty")
+	}
+	uid, err := ParseUid(xid)
+	switch {
+	case err == ErrInvalidUID:
+		return 0, err
+	case err != nil:
+		return FingerprintXid(xid), nil
+	case uid < reserved:
+		return 0, ErrReservedUID
+	}
+	return uid, nil
+}
+
+// FoldXid returns the xid in lower case, for xids from case insensitive
+// systems such as emails, so that Alice@dgraph.io and alice@dgraph.io are the
+// same node. Numeric xids and blank nodes are returned as they are, as blank
+// nodes are keyed by their exact name in the map given by AssignUids.
+func FoldXid(xid string) string {
+	if strings.HasPrefix(xid, "_:") {
+		return xid
+	}
+	if _, err := ParseUid(xid); err == nil || err == ErrInvalidUID {
+		return xid
+	}
+	return strings.ToLower(xid)
+}
+
+// GetUidFolded is like GetUid, but with the xid folded by FoldXid first. The
+// uids it gives differ from those of GetUid for xids with upper case letters,
+// so data loaded with one can't be updated with the other.
+func GetUidFolded(xid string) (uint64, error) {
+	return GetUid(FoldXid(xid))
+}
+
+// FingerprintXid returns the fingerprint GetUid uses as the uid of a
+// non-numeric xid. It's the 64 bit farmhash fingerprint (Fingerprint64) of the
+// bytes of the xid, blank node prefix included, so clients in other languages
+// can compute the same uids. It must not change, as stored data depends on it.
+func FingerprintXid(xid string) uint64 {
+	return farm.Fingerprint64([]byte(xid))
+}
+
 type NQuad struct {
 	*protos.NQuad
 }
 
+// Equals returns true if both NQuads have exactly the same fields.
+func (nq NQuad) Equals(other NQuad) bool {
+	return nq.EqualsWithin(other, 0)
+}
+
+// EqualsWithin is like Equals, but treats float object values as equal if they
+// differ by at most epsilon. With an epsilon of zero they must be exactly equal.
+func (nq NQuad) EqualsWithin(other NQuad, epsilon float64) bool {
+	if nq.NQuad == nil || other.NQuad == nil {
+		return nq.NQuad == other.NQuad
+	}
+	fa, okA := nq.ObjectValue.GetVal().(*protos.Value_DoubleVal)
+	fb, okB := other.ObjectValue.GetVal().(*protos.Value_DoubleVal)
+	if epsilon <= 0 || !okA || !okB {
+		return proto.Equal(nq.NQuad, other.NQuad)
+	}
+	if math.Abs(fa.DoubleVal-fb.DoubleVal) > epsilon {
+		return false
+	}
+	// Compare the rest of the fields on copies without the object values.
+	a, b := *nq.NQuad, *other.NQuad
+	a.ObjectValue, b.ObjectValue = nil, nil
+	return proto.Equal(&a, &b)
+}
+
 func typeValFrom(val *protos.Value) types.Val {
 	switch val.Val.(type) {
 	case *protos.Value_BytesVal:
@@ -80,8 +847,15 @@ func typeValFrom(val *protos.Value) types.Val {
 		return types.Val{types.GeoID, val.GetGeoVal()}
 	case *protos.Value_DatetimeVal:
 		return types.Val{types.DateTimeID, val.GetDatetimeVal()}
+	case *protos.Value_DateVal:
+		// Dates are stored as datetimes.
+		return types.Val{types.DateTimeID, val.GetDateVal()}
 	case *protos.Value_PasswordVal:
 		return types.Val{types.PasswordID, val.GetPasswordVal()}
+	case *protos.Value_UriVal:
+		return types.Val{types.UriID, val.GetUriVal()}
+	case *protos.Value_JsonVal:
+		return types.Val{types.JsonID, val.GetJsonVal()}
 	case *protos.Value_DefaultVal:
 		if val.GetDefaultVal() == "" {
 			return types.Val{types.DefaultID, "_nil_"}
@@ -92,14 +866,59 @@ func typeValFrom(val *protos.Value) types.Val {
 	return types.Val{types.StringID, ""}
 }
 
+// appendScalar appends the binary encoding of an int, float or bool value to
+// dst, the same as types.Marshal would give. It doesn't go through types.Val,
+// so with a dst of enough capacity it doesn't allocate. The bool returned is
+// false for values of other types, which are left to the generic path.
+func appendScalar(dst []byte, val *protos.Value) ([]byte, types.TypeID, bool) {
+	switch v := val.GetVal().(type) {
+	case *protos.Value_IntVal:
+		return appendUint64(dst, uint64(v.IntVal)), types.IntID, true
+	case *protos.Value_DoubleVal:
+		return appendUint64(dst, math.Float64bits(v.DoubleVal)), types.FloatID, true
+	case *protos.Value_BoolVal:
+		if v.BoolVal {
+			return append(dst, 1), types.BoolID, true
+		}
+		return append(dst, 0), types.BoolID, true
+	}
+	return dst, types.DefaultID, false
+}
+
+// appendUint64 appends u in little endian order, as encoding/binary would.
+func appendUint64(dst []byte, u uint64) []byte {
+	return append(dst, byte(u), byte(u>>8), byte(u>>16), byte(u>>24),
+		byte(u>>32), byte(u>>40), byte(u>>48), byte(u>>56))
+}
+
 func byteVal(nq NQuad) ([]byte, types.TypeID, error) {
+	// Ints, floats and bools are the most common, so skip the generic path for
+	// them. The result is owned by the edge, so it gets its own buffer, which
+	// append only allocates for those values.
+	if b, tid, ok := appendScalar(nil, nq.ObjectValue); ok {
+		return b, tid, nil
+	}
 	// We infer object type from type of value. We set appropriate type in parse
 	// function or the Go client has already set.
 	p := typeValFrom(nq.ObjectValue)
 	// These three would have already been marshalled to bytes by the client or
-	// in parse function.
+	// in parse function. Make sure they decode, so that corrupt bytes are caught
+	// before they reach the server.
 	if p.Tid == types.GeoID || p.Tid == types.DateTimeID {
-		return p.Value.([]byte), p.Tid, nil
+		b := p.Value.([]byte)
+		if _, err := types.Convert(types.Val{Tid: types.BinaryID, Value: b}, p.Tid); err != nil {
+			return []byte{}, p.Tid, x.Wrapf(err, "Invalid %s value for predicate %s",
+				p.Tid.Name(), nq.Predicate)
+		}
+		return b, p.Tid, nil
+	}
+	// JSON values are stored as given, once we know they are valid.
+	if p.Tid == types.JsonID {
+		b := p.Value.([]byte)
+		if !json.Valid(b) {
+			return []byte{}, p.Tid, x.Errorf("Invalid JSON value for predicate %s", nq.Predicate)
+		}
+		return b, p.Tid, nil
 	}
 
 	p1 := types.ValueForType(types.BinaryID)
@@ -109,6 +928,32 @@ func byteVal(nq NQuad) ([]byte, types.TypeID, error) {
 	return []byte(p1.Value.([]byte)), p.Tid, nil
 }
 
+// MarshalCheck returns an error if the object value of any of the Set or Del
+// NQuads fails to marshal, without building any edges. The error lists every
+// failing NQuad with its index.
+func (m Mutation) MarshalCheck() error {
+	var msgs []string
+	check := func(nquads []*protos.NQuad, name string) {
+		for i, nq := range nquads {
+			if nq.ObjectValue == nil {
+				continue
+			}
+			// Go through the checks done while converting, like that of
+			// NaN and infinite floats, not just the marshalling.
+			if err := copyValue(&protos.DirectedEdge{}, NQuad{nq}); err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s nquad at index %d: %v", name, i, err))
+			}
+		}
+	}
+	check(m.Set, "set")
+	check(m.Del, "delete")
+	if len(msgs) > 0 {
+		return x.Errorf("Values of %d nquads don't marshal: %s", len(msgs),
+			strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
 func toUid(subject string, newToUid map[string]uint64) (uid uint64, err error) {
 	x.AssertTrue(len(subject) > 0)
 	if id, err := ParseUid(subject); err == nil || err == ErrInvalidUID {
@@ -128,11 +973,13 @@ func (nq NQuad) createEdge(subjectUid uint64, newToUid map[string]uint64) (*prot
 	var objectUid uint64
 
 	out := &protos.DirectedEdge{
-		Entity: subjectUid,
-		Attr:   nq.Predicate,
-		Label:  nq.Label,
-		Lang:   nq.Lang,
-		Facets: nq.Facets,
+		Entity:      subjectUid,
+		Attr:        nq.Predicate,
+		Label:       nq.Label,
+		Lang:        nq.Lang,
+		Facets:      nq.Facets,
+		DefaultLang: nq.DefaultLang,
+		CreateOnly:  nq.CreateOnly,
 	}
 
 	switch nq.valueType() {
@@ -143,45 +990,168 @@ func (nq NQuad) createEdge(subjectUid uint64, newToUid map[string]uint64) (*prot
 		}
 		x.AssertTrue(objectUid > 0)
 		out.ValueId = objectUid
-	case x.ValuePlain, x.ValueMulti:
+	case x.ValuePlain, x.ValueMulti, x.ValueIncrement:
 		if err = copyValue(out, nq); err != nil {
 			return &emptyEdge, err
 		}
+	case x.ValueLabel:
+		// The label set above is all that's needed.
 	default:
 		return &emptyEdge, errors.New("unknow value type")
 	}
 	return out, nil
 }
 
-func (nq NQuad) createEdgePrototype(subjectUid uint64) *protos.DirectedEdge {
-	return &protos.DirectedEdge{
-		Entity: subjectUid,
-		Attr:   nq.Predicate,
-		Label:  nq.Label,
-		Lang:   nq.Lang,
-		Facets: nq.Facets,
+// ExpandObjectIds returns a copy of the NQuad for each of the uids in
+// ObjectIds, with the uid as its ObjectId, so that each of them can be
+// converted to an edge. NQuads without ObjectIds are returned as they are,
+// except for an NQuad with an empty list and no other object, which sets an
+// empty list and gives no NQuads.
+func (nq NQuad) ExpandObjectIds() ([]NQuad, error) {
+	if nq.ObjectIds != nil && len(nq.ObjectIds) == 0 && nq.valueType() == x.ValueEmpty {
+		return nil, nil
+	}
+	if len(nq.ObjectIds) == 0 {
+		return []NQuad{nq}, nil
 	}
+	if len(nq.ObjectId) > 0 || len(nq.ObjectVar) > 0 || nq.ObjectValue != nil {
+		return nil, x.Errorf("NQuad can't have both a list of uids and another object: %+v",
+			nq)
+	}
+	nquads := make([]NQuad, 0, len(nq.ObjectIds))
+	for _, id := range nq.ObjectIds {
+		c := *nq.NQuad
+		c.ObjectIds = nil
+		c.ObjectId = id
+		nquads = append(nquads, NQuad{&c})
+	}
+	return nquads, nil
 }
 
-func (nq NQuad) CreateUidEdge(subjectUid uint64, objectUid uint64) *protos.DirectedEdge {
-	out := nq.createEdgePrototype(subjectUid)
-	out.ValueId = objectUid
-	return out
+// ExpandSubjectVar returns an edge for each of subjectUids, the uids of the
+// variable which is the subject of the NQuad, in the order of subjectUids, or
+// sorted by uid if sortUids is true, so that expanding the same set of uids
+// always gives the same edges. The object can't be a variable, and xids are
+// resolved with GetUid.
+func ExpandSubjectVar(nq NQuad, subjectUids []uint64,
+	sortUids bool) ([]*protos.DirectedEdge, error) {
+	if len(nq.SubjectVar) == 0 {
+		return nil, x.Errorf("Subject should be a variable to be expanded: %+v", nq)
+	}
+	if len(nq.ObjectVar) > 0 {
+		return nil, x.Errorf("Object can't be variable %s when expanding subject variable %s",
+			nq.ObjectVar, nq.SubjectVar)
+	}
+	if sortUids {
+		subjectUids = append([]uint64(nil), subjectUids...)
+		sort.Slice(subjectUids, func(i, j int) bool { return subjectUids[i] < subjectUids[j] })
+	}
+	edges := make([]*protos.DirectedEdge, 0, len(subjectUids))
+	for _, uid := range subjectUids {
+		edge, err := nq.toEdgeWith(func(string) (uint64, error) {
+			return uid, nil
+		}, GetUid)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, edge)
+	}
+	return edges, nil
 }
 
-func (nq NQuad) CreateValueEdge(subjectUid uint64) (*protos.DirectedEdge, error) {
-	var err error
-
-	out := nq.createEdgePrototype(subjectUid)
-	if err = copyValue(out, nq); err != nil {
-		return &emptyEdge, err
+// ExpandListStream sends an edge from subjectUid for each of the uids in the
+// ObjectIds of the NQuad to out, one at a time, so that huge lists don't need
+// all their edges in memory. Xids in the list are resolved with GetUid. It
+// returns early with the error of the context if it's done before all the
+// edges are sent. out isn't closed.
+func ExpandListStream(ctx context.Context, nq NQuad, subjectUid uint64,
+	out chan<- *protos.DirectedEdge) error {
+	if len(nq.ObjectId) > 0 || len(nq.ObjectVar) > 0 || nq.ObjectValue != nil {
+		return x.Errorf("NQuad can't have both a list of uids and another object: %+v",
+			nq)
 	}
-	return out, nil
+	for i, id := range nq.ObjectIds {
+		uid, err := GetUid(id)
+		if err != nil {
+			return x.Wrapf(err, "while resolving uid at index %d", i)
+		}
+		select {
+		case out <- nq.CreateUidEdge(subjectUid, uid):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
 }
 
-func (nq NQuad) ToDeletePredEdge() (*protos.DirectedEdge, error) {
-	if nq.Subject != x.Star && nq.ObjectValue.String() != x.Star {
-		return &emptyEdge, x.Errorf("Subject and object both should be *. Got: %+v", nq)
+// WeightFacet is the reserved facet holding the weight of an edge.
+const WeightFacet = "weight"
+
+// CreateWeightedEdge returns the edge from subjectUid for the NQuad, with the
+// object resolved using newToUid, and with weight set as the float facet
+// WeightFacet, for clients storing weighted graphs. The weight must be a finite
+// number, and the NQuad can't have a WeightFacet facet of its own.
+func (nq NQuad) CreateWeightedEdge(subjectUid uint64, newToUid map[string]uint64,
+	weight float64) (*protos.DirectedEdge, error) {
+	if math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return &emptyEdge, x.Errorf("Weight for predicate %s should be a finite number. Got: %v",
+			nq.Predicate, weight)
+	}
+	for _, f := range nq.Facets {
+		if f.Key == WeightFacet {
+			return &emptyEdge, x.Errorf("Facet %s is reserved for the edge weight", WeightFacet)
+		}
+	}
+	out, err := nq.createEdge(subjectUid, newToUid)
+	if err != nil {
+		return out, err
+	}
+	wf, err := facets.FloatFacet(WeightFacet, weight)
+	if err != nil {
+		return &emptyEdge, err
+	}
+	// Copy the facets so that those of the NQuad are left as they are.
+	fcs := make([]*protos.Facet, 0, len(out.Facets)+1)
+	fcs = append(fcs, out.Facets...)
+	fcs = append(fcs, wf)
+	if err := facets.SortAndValidate(fcs); err != nil {
+		return &emptyEdge, err
+	}
+	out.Facets = fcs
+	return out, nil
+}
+
+func (nq NQuad) createEdgePrototype(subjectUid uint64) *protos.DirectedEdge {
+	return &protos.DirectedEdge{
+		Entity:      subjectUid,
+		Attr:        nq.Predicate,
+		Label:       nq.Label,
+		Lang:        nq.Lang,
+		Facets:      nq.Facets,
+		DefaultLang: nq.DefaultLang,
+		CreateOnly:  nq.CreateOnly,
+	}
+}
+
+func (nq NQuad) CreateUidEdge(subjectUid uint64, objectUid uint64) *protos.DirectedEdge {
+	out := nq.createEdgePrototype(subjectUid)
+	out.ValueId = objectUid
+	return out
+}
+
+func (nq NQuad) CreateValueEdge(subjectUid uint64) (*protos.DirectedEdge, error) {
+	var err error
+
+	out := nq.createEdgePrototype(subjectUid)
+	if err = copyValue(out, nq); err != nil {
+		return &emptyEdge, err
+	}
+	return out, nil
+}
+
+func (nq NQuad) ToDeletePredEdge() (*protos.DirectedEdge, error) {
+	if nq.Subject != x.Star && nq.ObjectValue.String() != x.Star {
+		return &emptyEdge, x.Errorf("Subject and object both should be *. Got: %+v", nq)
 	}
 
 	out := &protos.DirectedEdge{
@@ -201,46 +1171,1922 @@ func (nq NQuad) ToDeletePredEdge() (*protos.DirectedEdge, error) {
 	return out, nil
 }
 
-// ToEdgeUsing determines the UIDs for the provided XIDs and populates the
-// xidToUid map.
+// ToScanDeleteEdges returns the delete edges for an NQuad of the form * P V,
+// which deletes the edges of predicate P with value V from subjectUids, the
+// subjects having it, as found by the client with a query such as eq(P, V).
+// The server only deletes the value of a subject if it's still V. A value is
+// required, so that a mistake can't delete the whole predicate, which is done
+// with * P * instead.
+func (nq NQuad) ToScanDeleteEdges(subjectUids []uint64) ([]*protos.DirectedEdge, error) {
+	if nq.Subject != x.Star {
+		return nil, x.Errorf("Subject should be * to delete by value. Got: %+v", nq)
+	}
+	if nq.Predicate == x.Star || len(nq.Predicate) == 0 {
+		return nil, x.Errorf("Predicate is required to delete by value. Got: %+v", nq)
+	}
+	if nq.ObjectValue == nil || nq.ObjectValue.GetDefaultVal() == x.Star ||
+		nq.ObjectValue.GetStrVal() == x.Star {
+		return nil, x.Errorf("Value is required to delete predicate %s by value. Use alter "+
+			"to delete the whole predicate", nq.Predicate)
+	}
+	edges := make([]*protos.DirectedEdge, 0, len(subjectUids))
+	for _, uid := range subjectUids {
+		if uid == 0 {
+			return nil, x.Errorf("Subject uid can't be 0 while deleting predicate %s by value",
+				nq.Predicate)
+		}
+		out := nq.createEdgePrototype(uid)
+		if err := copyValue(out, nq); err != nil {
+			return nil, err
+		}
+		out.Op = protos.DirectedEdge_DEL
+		edges = append(edges, out)
+	}
+	return edges, nil
+}
+
+// ToEdgeUsing determines the UIDs for the provided XIDs using the newToUid map.
+// The map is only read from and never written to, so it's safe to call
+// ToEdgeUsing concurrently with the same map as long as no one modifies it.
+// No ACL is checked, use PredicateACL.ToEdgeUsing for that.
 func (nq NQuad) ToEdgeUsing(newToUid map[string]uint64) (*protos.DirectedEdge, error) {
+	return PredicateACL{}.ToEdgeUsing(nq, newToUid)
+}
+
+// ToDeleteEdgeUsing is like ToEdgeUsing, but returns a delete edge. Unlike a
+// set, a delete can have the predicate *, as in S * * and S * <value>.
+func (nq NQuad) ToDeleteEdgeUsing(newToUid map[string]uint64) (*protos.DirectedEdge, error) {
+	return PredicateACL{}.ToDeleteEdgeUsing(nq, newToUid)
+}
+
+// ToEdgeUsingMaps is like ToEdgeUsing, but looks the subject up in subjectMap
+// and the object in objectMap, for loaders keeping separate namespaces for
+// them. Xids missing from their map are resolved with GetUid.
+func (nq NQuad) ToEdgeUsingMaps(subjectMap,
+	objectMap map[string]uint64) (*protos.DirectedEdge, error) {
+	lookup := func(m map[string]uint64) func(string) (uint64, error) {
+		return func(xid string) (uint64, error) {
+			if uid, ok := m[xid]; ok {
+				return uid, nil
+			}
+			return GetUid(xid)
+		}
+	}
+	return nq.toEdgeWith(lookup(subjectMap), lookup(objectMap))
+}
+
+// toEdge builds the edge for the NQuad, using resolve to determine the UIDs for
+// the subject and the object.
+func (nq NQuad) toEdge(resolve func(string) (uint64, error)) (*protos.DirectedEdge, error) {
+	return nq.toEdgeWith(resolve, resolve)
+}
+
+// toEdgeWith is like toEdge, but with separate functions to resolve the subject
+// and the object.
+func (nq NQuad) toEdgeWith(resolveSubject,
+	resolveObject func(string) (uint64, error)) (*protos.DirectedEdge, error) {
+	return nq.toEdgeFor(protos.DirectedEdge_SET, PredicateACL{}, resolveSubject, resolveObject)
+}
+
+// toEdgeFor builds the edge for the NQuad as part of a mutation with the
+// given op, if acl allows writing to its predicate. The predicate * is only
+// accepted for deletes.
+func (nq NQuad) toEdgeFor(op protos.DirectedEdge_Op, acl PredicateACL, resolveSubject,
+	resolveObject func(string) (uint64, error)) (*protos.DirectedEdge, error) {
+	if err := acl.Check(nq.Predicate); err != nil {
+		return nil, err
+	}
+	if err := nq.checkLang(); err != nil {
+		return nil, err
+	}
+	if nq.Predicate == x.Star && op != protos.DirectedEdge_DEL {
+		return nil, x.Errorf("Predicate * is only allowed in delete mutations: %+v", nq)
+	}
+	if len(nq.ObjectIds) > 0 {
+		return nil, x.Errorf("NQuad with a list of uids should be expanded with "+
+			"ExpandObjectIds first: %+v", nq)
+	}
+	// S * <value> deletes the value from all the predicates of S, but there's no
+	// telling which predicates a uid should be deleted from.
+	if nq.Predicate == x.Star && (len(nq.ObjectId) > 0 || len(nq.ObjectVar) > 0) {
+		return nil, x.Errorf("Predicate * can't be used with a uid object: %+v", nq)
+	}
 	var edge *protos.DirectedEdge
-	sUid, err := toUid(nq.Subject, newToUid)
+	sUid, err := resolveSubject(nq.Subject)
 	if err != nil {
 		return nil, err
 	}
 
 	switch nq.valueType() {
 	case x.ValueUid:
-		oUid, err := toUid(nq.ObjectId, newToUid)
+		oUid, err := resolveObject(nq.ObjectId)
 		if err != nil {
 			return nil, err
 		}
 		edge = nq.CreateUidEdge(sUid, oUid)
-	case x.ValuePlain, x.ValueMulti:
+	case x.ValuePlain, x.ValueMulti, x.ValueIncrement:
 		edge, err = nq.CreateValueEdge(sUid)
+	case x.ValueLabel:
+		edge = nq.createEdgePrototype(sUid)
 	default:
 		return &emptyEdge, x.Errorf("unknown value type for nquad: %+v", nq)
 	}
 	if err != nil {
 		return nil, err
 	}
+	if edge.Op != protos.DirectedEdge_INC {
+		edge.Op = op
+	}
+	if err := nq.applyModes(edge); err != nil {
+		return nil, err
+	}
 	return edge, nil
 }
 
-func copyValue(out *protos.DirectedEdge, nq NQuad) error {
-	var err error
-	var t types.TypeID
-	if out.Value, t, err = byteVal(nq); err != nil {
+// applyModes restricts what the edge does as asked by the NQuad: deleting only
+// an edge satisfying a facet condition, deleting only facets of the edge, or
+// deleting the edge by leaving a tombstone.
+func (nq NQuad) applyModes(edge *protos.DirectedEdge) error {
+	if len(nq.FacetCondOp) > 0 {
+		fn, ok := facetCondOps[nq.FacetCondOp]
+		if !ok {
+			return x.Errorf("Invalid operator %q for facet condition", nq.FacetCondOp)
+		}
+		if len(edge.Facets) != 1 {
+			return x.Errorf("Facet condition for predicate %s needs exactly one facet, got %d",
+				nq.Predicate, len(edge.Facets))
+		}
+		c := FacetCondition{Op: fn, Facet: edge.Facets[0]}
+		edge.Facets = nil
+		if err := c.Apply(edge); err != nil {
+			return err
+		}
+	}
+	if len(nq.DelFacets) > 0 {
+		if err := DeleteFacets(edge, nq.DelFacets...); err != nil {
+			return err
+		}
+	}
+	if !nq.Tombstone {
+		if len(nq.DeletedBy) > 0 {
+			return x.Errorf("Deleted by is only recorded on tombstones: %+v", nq)
+		}
+		return nil
+	}
+	if edge.Op != protos.DirectedEdge_DEL {
+		return x.Errorf("Tombstone is only allowed in delete mutations: %+v", nq)
+	}
+	t := Tombstone{DeletedBy: nq.DeletedBy, DeletedAt: time.Now()}
+	return t.markTombstone(edge)
+}
+
+// ToEdgesParallel converts the NQuads to edges using the given number of
+// goroutines. The edges are returned in the same order as the NQuads. resolve
+// is called concurrently, so it must be safe for concurrent use. If conversion
+// fails for more than one NQuad, the error for the first of them is returned.
+func ToEdgesParallel(nquads []NQuad, resolve func(string) (uint64, error),
+	workers int) ([]*protos.DirectedEdge, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	edges := make([]*protos.DirectedEdge, len(nquads))
+	errs := make([]error, len(nquads))
+	idxCh := make(chan int, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idxCh {
+				// Every goroutine writes to different indices, so no locking is needed.
+				edges[i], errs[i] = nquads[i].toEdge(resolve)
+			}
+		}()
+	}
+	for i := range nquads {
+		idxCh <- i
+	}
+	close(idxCh)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, x.Wrapf(err, "while converting nquad at index %d", i)
+		}
+	}
+	return edges, nil
+}
+
+// EstimateBytes returns an estimate of the size of the NQuad once serialized,
+// for callers splitting mutations into batches of about the same size. It
+// adds up the sizes of all the fields, facets, language and label included,
+// with a fixed overhead for the tag and length of each field set.
+func EstimateBytes(nq NQuad) int {
+	const fieldOverhead = 2
+	n := 0
+	add := func(s string) {
+		if len(s) > 0 {
+			n += len(s) + fieldOverhead
+		}
+	}
+	add(nq.Subject)
+	add(nq.Predicate)
+	add(nq.ObjectId)
+	add(nq.Label)
+	add(nq.Lang)
+	add(nq.SubjectVar)
+	add(nq.ObjectVar)
+	for _, id := range nq.ObjectIds {
+		add(id)
+	}
+	if nq.Increment {
+		n += fieldOverhead
+	}
+	if nq.ObjectValue != nil {
+		// The value is nested in the Value message.
+		n += valueBytes(nq.ObjectValue) + 2*fieldOverhead
+	}
+	for _, f := range nq.Facets {
+		n += len(f.Key) + len(f.Value) + len(f.Val) + 4*fieldOverhead
+		for _, tok := range f.Tokens {
+			n += len(tok) + fieldOverhead
+		}
+	}
+	return n
+}
+
+// valueBytes returns the size of the value held by v.
+func valueBytes(v *protos.Value) int {
+	switch val := v.Val.(type) {
+	case *protos.Value_DefaultVal:
+		return len(val.DefaultVal)
+	case *protos.Value_StrVal:
+		return len(val.StrVal)
+	case *protos.Value_PasswordVal:
+		return len(val.PasswordVal)
+	case *protos.Value_UriVal:
+		return len(val.UriVal)
+	case *protos.Value_BytesVal:
+		return len(val.BytesVal)
+	case *protos.Value_GeoVal:
+		return len(val.GeoVal)
+	case *protos.Value_DateVal:
+		return len(val.DateVal)
+	case *protos.Value_DatetimeVal:
+		return len(val.DatetimeVal)
+	case *protos.Value_JsonVal:
+		return len(val.JsonVal)
+	case *protos.Value_BoolVal:
+		return 1
+	}
+	// Ints and uids are varints, which take up to 10 bytes, and doubles 8.
+	return 8
+}
+
+// InferSchema returns the type for every predicate in nquads, inferred from the
+// types of its object values. Predicates only pointing to nodes are of type uid.
+// Ints and floats mix into float, and any other mix of types falls back to string.
+func InferSchema(nquads []NQuad) map[string]types.TypeID {
+	inferred := make(map[string]types.TypeID)
+	for _, nq := range nquads {
+		tid := types.UidID
+		if nq.ObjectValue != nil {
+			tid = typeValFrom(nq.ObjectValue).Tid
+		}
+		prev, ok := inferred[nq.Predicate]
+		switch {
+		case !ok || prev == tid:
+			inferred[nq.Predicate] = tid
+		case (prev == types.IntID || prev == types.FloatID) &&
+			(tid == types.IntID || tid == types.FloatID):
+			inferred[nq.Predicate] = types.FloatID
+		default:
+			inferred[nq.Predicate] = types.StringID
+		}
+	}
+	return inferred
+}
+
+// singleValueKey identifies the value of a single valued predicate. Values in
+// different languages are counted separately, as each language has its own.
+type singleValueKey struct {
+	subject, subjectVar, pred, lang string
+}
+
+func singleValueKeyOf(nq *protos.NQuad) singleValueKey {
+	return singleValueKey{nq.Subject, nq.SubjectVar, nq.Predicate, nq.Lang}
+}
+
+// CheckSingleValued returns an error if any predicate in preds is set more
+// than once for the same subject and language in the Set NQuads of the
+// mutation. A single valued @lang predicate can have a value in each language,
+// so only two values in the same language conflict.
+func CheckSingleValued(mutation *Mutation, preds map[string]bool) error {
+	seen := make(map[singleValueKey]bool)
+	for i, nq := range mutation.Set {
+		if !preds[nq.Predicate] {
+			continue
+		}
+		k := singleValueKeyOf(nq)
+		if seen[k] && len(nq.Lang) > 0 {
+			return x.Errorf("Predicate %s can only have one value per language, but is set "+
+				"again in language %s for subject %s by set nquad at index %d", nq.Predicate,
+				nq.Lang, nq.Subject+nq.SubjectVar, i)
+		}
+		if seen[k] {
+			return x.Errorf("Predicate %s can only have one value, but is set again for "+
+				"subject %s by set nquad at index %d", nq.Predicate, nq.Subject+nq.SubjectVar, i)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+// CheckPredicateConsistency returns an error if a predicate is used with a
+// value by some NQuad of the mutation and with a uid by another. Deletes of all
+// the values of a predicate fit either use, so they aren't checked.
+func CheckPredicateConsistency(m *Mutation) error {
+	// isUid records how each predicate was first used.
+	isUid := make(map[string]bool)
+	check := func(nquads []*protos.NQuad) error {
+		for _, nq := range nquads {
+			if nq.ObjectValue.GetDefaultVal() == x.Star {
+				continue
+			}
+			uid := nq.ObjectValue == nil
+			prev, ok := isUid[nq.Predicate]
+			if !ok {
+				isUid[nq.Predicate] = uid
+				continue
+			}
+			if prev != uid {
+				return x.Errorf("Predicate %s is used both with values and with uids",
+					nq.Predicate)
+			}
+		}
+		return nil
+	}
+	if err := check(m.Set); err != nil {
 		return err
 	}
-	out.ValueType = t.Enum()
+	return check(m.Del)
+}
+
+// CoalesceSingleValued drops all but the last Set NQuad for each subject and
+// predicate in preds, so that the mutation passes CheckSingleValued. Values in
+// different languages are kept apart, so name@en set twice keeps the last of
+// them along with name@fr. The order of the remaining NQuads is kept.
+func (m *Mutation) CoalesceSingleValued(preds map[string]bool) {
+	last := make(map[singleValueKey]int)
+	for i, nq := range m.Set {
+		if preds[nq.Predicate] {
+			last[singleValueKeyOf(nq)] = i
+		}
+	}
+	out := m.Set[:0]
+	for i, nq := range m.Set {
+		if preds[nq.Predicate] && last[singleValueKeyOf(nq)] != i {
+			continue
+		}
+		out = append(out, nq)
+	}
+	m.Set = out
+}
+
+// SetEncoding says how the string values set for a predicate used as a set,
+// e.g. of tags, are stored.
+type SetEncoding int
+
+const (
+	// SetAsEdges stores each value as an edge of its own. This is the default.
+	SetAsEdges SetEncoding = iota
+	// SetAsSortedValue stores the values set for a subject as a single bytes
+	// value, with the values sorted and deduplicated, see EncodeSortedSet. It's
+	// more compact, but the values can't be queried or indexed one by one.
+	SetAsSortedValue
+)
+
+// EncodeSets replaces the Set NQuads of the predicates with SetAsSortedValue
+// in encodings by one NQuad per subject, at the position of the first of
+// them, with their values encoded with EncodeSortedSet. The values must be
+// strings without a language or facets. On error, the mutation is left as is.
+//
+// Only the values in this mutation are encoded. The server stores the encoded
+// set like any other value, so a later mutation setting the predicate for the
+// same subject replaces the stored set, or adds a second one if the predicate
+// is a list, instead of merging the values. To add to a set, read it with
+// DecodeSortedSet and set the union of the old and new values.
+func (m *Mutation) EncodeSets(encodings map[string]SetEncoding) error {
+	type key struct {
+		subject, subjectVar, pred string
+	}
+	first := make(map[key]int)
+	values := make(map[key][]string)
+	out := make([]*protos.NQuad, 0, len(m.Set))
+	for i, nq := range m.Set {
+		if encodings[nq.Predicate] != SetAsSortedValue {
+			out = append(out, nq)
+			continue
+		}
+		var val string
+		switch v := nq.ObjectValue.GetVal().(type) {
+		case *protos.Value_StrVal:
+			val = v.StrVal
+		case *protos.Value_DefaultVal:
+			val = v.DefaultVal
+		default:
+			return x.Errorf("Set nquad at index %d for predicate %s should have a string "+
+				"value to be encoded as a set", i, nq.Predicate)
+		}
+		if len(nq.Lang) > 0 || len(nq.Facets) > 0 {
+			return x.Errorf("Set nquad at index %d for predicate %s can't have a language "+
+				"or facets to be encoded as a set", i, nq.Predicate)
+		}
+		k := key{nq.Subject, nq.SubjectVar, nq.Predicate}
+		if _, ok := first[k]; !ok {
+			first[k] = len(out)
+			out = append(out, nq)
+		}
+		values[k] = append(values[k], val)
+	}
+	for k, j := range first {
+		cp := *out[j]
+		cp.ObjectValue = &protos.Value{Val: &protos.Value_BytesVal{
+			BytesVal: EncodeSortedSet(values[k])}}
+		out[j] = &cp
+	}
+	m.Set = out
 	return nil
 }
 
-func (nq NQuad) valueType() x.ValueTypeInfo {
-	hasValue := nq.ObjectValue != nil
-	hasLang := len(nq.Lang) > 0
-	hasSpecialId := len(nq.ObjectId) == 0
-	return x.ValueType(hasValue, hasLang, hasSpecialId)
+// EncodeSortedSet encodes the values sorted and without duplicates, as their
+// number followed by each value prefixed with its size, all as uvarints.
+func EncodeSortedSet(values []string) []byte {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	var b []byte
+	n := 0
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			sorted[n] = v
+			n++
+		}
+	}
+	b = x.AppendUvarint(b, uint64(n))
+	for _, v := range sorted[:n] {
+		b = x.AppendUvarint(b, uint64(len(v)))
+		b = append(b, v...)
+	}
+	return b
+}
+
+// DecodeSortedSet returns the values encoded by EncodeSortedSet.
+func DecodeSortedSet(b []byte) ([]string, error) {
+	n, sz := binary.Uvarint(b)
+	if sz <= 0 || n > uint64(len(b)) {
+		return nil, x.Errorf("Invalid size of sorted set")
+	}
+	b = b[sz:]
+	values := make([]string, 0, n)
+	for i := uint64(0); i < n; i++ {
+		l, sz := binary.Uvarint(b)
+		if sz <= 0 || l > uint64(len(b)-sz) {
+			return nil, x.Errorf("Invalid size of value at index %d of sorted set", i)
+		}
+		values = append(values, string(b[sz:sz+int(l)]))
+		b = b[sz+int(l):]
+	}
+	if len(b) > 0 {
+		return nil, x.Errorf("Sorted set has %d bytes after its values", len(b))
+	}
+	return values, nil
+}
+
+// DiffAgainst drops the Set NQuads which would set a value the node already
+// has, given the current values as subject to predicate to value. Values in a
+// language are looked up as predicate@lang. NQuads with facets are kept, as
+// they change the edge even if the value is the same, and so are increments,
+// whose value is a delta rather than the new value.
+func (m *Mutation) DiffAgainst(current map[string]map[string]*protos.Value) {
+	out := m.Set[:0]
+	for _, nq := range m.Set {
+		if !isNoopSet(nq, current) {
+			out = append(out, nq)
+		}
+	}
+	m.Set = out
+}
+
+func isNoopSet(nq *protos.NQuad, current map[string]map[string]*protos.Value) bool {
+	if nq.ObjectValue == nil || len(nq.Facets) > 0 || nq.Increment {
+		return false
+	}
+	pred := nq.Predicate
+	if len(nq.Lang) > 0 {
+		pred += "@" + nq.Lang
+	}
+	cur, ok := current[nq.Subject][pred]
+	if !ok || cur == nil {
+		return false
+	}
+	if typeValFrom(cur).Tid != typeValFrom(nq.ObjectValue).Tid {
+		return false
+	}
+	a, _, err := byteVal(NQuad{&protos.NQuad{ObjectValue: cur}})
+	if err != nil {
+		return false
+	}
+	b, _, err := byteVal(NQuad{&protos.NQuad{ObjectValue: nq.ObjectValue}})
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(a, b)
+}
+
+// SubjectPredicate identifies the values of a predicate of a subject.
+type SubjectPredicate struct {
+	Subject, Predicate string
+}
+
+// GroupLangValues groups the values of the Set NQuads of the mutation by
+// subject and predicate, each mapped by language. The value without a
+// language is under "". A value set more than once keeps the last one.
+func GroupLangValues(m *Mutation) map[SubjectPredicate]map[string]*protos.Value {
+	groups := make(map[SubjectPredicate]map[string]*protos.Value)
+	for _, nq := range m.Set {
+		if nq.ObjectValue == nil {
+			continue
+		}
+		k := SubjectPredicate{Subject: nq.Subject, Predicate: nq.Predicate}
+		if groups[k] == nil {
+			groups[k] = make(map[string]*protos.Value)
+		}
+		groups[k][nq.Lang] = nq.ObjectValue
+	}
+	return groups
+}
+
+// edgeKey identifies the edge an NQuad sets, regardless of its facets.
+type edgeKey struct {
+	subject, subjectVar, pred, lang string
+	objectId, objectVar, value      string
+	// objectIds holds the list of uids, if any, separated by NUL bytes.
+	objectIds string
+}
+
+func edgeKeyOf(nq *protos.NQuad) (edgeKey, error) {
+	k := edgeKey{
+		subject:    nq.Subject,
+		subjectVar: nq.SubjectVar,
+		pred:       nq.Predicate,
+		lang:       nq.Lang,
+		objectId:   nq.ObjectId,
+		objectVar:  nq.ObjectVar,
+		objectIds:  strings.Join(nq.ObjectIds, "\x00"),
+	}
+	if nq.ObjectValue != nil {
+		b, err := nq.ObjectValue.Marshal()
+		if err != nil {
+			return k, err
+		}
+		k.value = string(b)
+	}
+	return k, nil
+}
+
+// MergeEdgeFacets replaces the Set NQuads which set the same edge by a single
+// NQuad, at the position of the first one, with the union of their facets. It
+// returns an error if the NQuads give different values for the same facet key.
+// Increments are never merged, as each of them adds to the value.
+func (m *Mutation) MergeEdgeFacets() error {
+	first := make(map[edgeKey]int)
+	// Build a new slice, so that the mutation is left as is on error.
+	out := make([]*protos.NQuad, 0, len(m.Set))
+	for i, nq := range m.Set {
+		if nq.Increment {
+			out = append(out, nq)
+			continue
+		}
+		k, err := edgeKeyOf(nq)
+		if err != nil {
+			return x.Wrapf(err, "while reading set nquad at index %d", i)
+		}
+		j, ok := first[k]
+		if !ok {
+			first[k] = len(out)
+			out = append(out, nq)
+			continue
+		}
+		merged, err := mergeFacets(out[j].Facets, nq.Facets)
+		if err != nil {
+			return x.Wrapf(err, "while merging set nquad at index %d", i)
+		}
+		cp := *out[j]
+		cp.Facets = merged
+		out[j] = &cp
+	}
+	m.Set = out
+	return nil
+}
+
+// mergeFacets returns the union of the facets in a and b, sorted by key.
+func mergeFacets(a, b []*protos.Facet) ([]*protos.Facet, error) {
+	byKey := make(map[string]*protos.Facet, len(a))
+	merged := make([]*protos.Facet, 0, len(a)+len(b))
+	for _, f := range a {
+		byKey[f.Key] = f
+		merged = append(merged, f)
+	}
+	for _, f := range b {
+		prev, ok := byKey[f.Key]
+		if !ok {
+			byKey[f.Key] = f
+			merged = append(merged, f)
+			continue
+		}
+		if !facets.SameFacets([]*protos.Facet{prev}, []*protos.Facet{f}) {
+			return nil, x.Errorf("Conflicting values for facet %s", f.Key)
+		}
+	}
+	if err := facets.SortAndValidate(merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// FacetCondition restricts a delete to the edges with a facet which compares
+// to Facet with the function Op, e.g. lt for less than.
+type FacetCondition struct {
+	Op    string
+	Facet *protos.Facet
+}
+
+var facetCondOps = map[string]string{
+	"<":  "lt",
+	"<=": "le",
+	">":  "gt",
+	">=": "ge",
+	"=":  "eq",
+}
+
+// NewFacetCondition returns the condition that the facet key of an edge
+// compares to val with op, which is one of <, <=, >, >= or =. The type of val
+// is inferred in the same way as for facets in RDF.
+func NewFacetCondition(op, key, val string) (FacetCondition, error) {
+	fn, ok := facetCondOps[op]
+	if !ok {
+		return FacetCondition{}, x.Errorf("Invalid operator %q for facet condition", op)
+	}
+	f, err := facets.FacetFor(key, val)
+	if err != nil {
+		return FacetCondition{}, err
+	}
+	return FacetCondition{Op: fn, Facet: f}, nil
+}
+
+// Apply makes the delete edge only delete an edge satisfying the condition.
+func (c FacetCondition) Apply(edge *protos.DirectedEdge) error {
+	if edge.Op != protos.DirectedEdge_DEL {
+		return x.Errorf("Facet conditions only apply to deletes, got op %s for predicate %s",
+			edge.Op, edge.Attr)
+	}
+	if len(edge.Facets) > 0 || len(edge.FacetCondOp) > 0 {
+		return x.Errorf("Delete for predicate %s already has facets", edge.Attr)
+	}
+	edge.FacetCondOp = c.Op
+	edge.Facets = []*protos.Facet{c.Facet}
+	return nil
+}
+
+// DeleteFacets makes the set edge only delete the facets with the given keys
+// from the existing edge, which is otherwise kept as it is, value included.
+func DeleteFacets(edge *protos.DirectedEdge, keys ...string) error {
+	if edge.Op != protos.DirectedEdge_SET {
+		return x.Errorf("Facets can only be deleted by sets, got op %s for predicate %s",
+			edge.Op, edge.Attr)
+	}
+	if len(keys) == 0 {
+		return x.Errorf("No facets to delete given for predicate %s", edge.Attr)
+	}
+	if len(edge.Facets) > 0 {
+		return x.Errorf("Set for predicate %s already has facets", edge.Attr)
+	}
+	edge.DelFacets = append(edge.DelFacets, keys...)
+	return nil
+}
+
+// FacetsToMap returns the values of the facets keyed by their keys, decoded to
+// the Go types used for their types: int64, float64, bool, string and
+// time.Time. Facets with the same key are an error.
+func FacetsToMap(fcs []*protos.Facet) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, len(fcs))
+	for _, f := range fcs {
+		if _, ok := m[f.Key]; ok {
+			return nil, x.Errorf("Duplicate facet key %s", f.Key)
+		}
+		if _, ok := protos.Facet_ValType_name[int32(f.ValType)]; !ok {
+			return nil, x.Errorf("Unknown type %d for facet %s", f.ValType, f.Key)
+		}
+		v, err := types.Convert(types.Val{Tid: types.BinaryID, Value: f.Value},
+			facets.TypeIDFor(f))
+		if err != nil {
+			return nil, x.Wrapf(err, "while decoding facet %s", f.Key)
+		}
+		m[f.Key] = v.Value
+	}
+	return m, nil
+}
+
+// ValidateOptions holds the optional checks run by Mutation.Validate. With the
+// zero value, only the checks which conversion would also fail on are run.
+type ValidateOptions struct {
+	// MaxValueBytes is the maximum size of an object value, once encoded. Zero
+	// means unlimited.
+	MaxValueBytes int
+	// MaxCardinality is the maximum number of objects a mutation may set for
+	// one subject, keyed by predicate. Predicates which aren't in the map are
+	// unlimited.
+	MaxCardinality map[string]int
+	// Ratios are the predicates whose values must be numbers between 0 and 1,
+	// both included. Strings are converted to floats to be checked.
+	Ratios map[string]bool
+	// RejectControlChars rejects string values with control characters other
+	// than tab, newline and carriage return, such as NUL, which can corrupt
+	// text indexes. ConvertOptions.StripControlChars removes them instead.
+	RejectControlChars bool
+	// MaxRunes limits the length in runes of the string values, keyed by
+	// predicate.
+	MaxRunes map[string]RuneLimit
+}
+
+// RuneLimit is the maximum length in runes of the string values of a
+// predicate. Zero means unlimited.
+type RuneLimit struct {
+	// Max applies to the values without a language, and to those in a
+	// language without an override in Langs.
+	Max int
+	// Langs overrides Max for the values in a language, keyed by language.
+	Langs map[string]int
+}
+
+// forLang returns the limit for values in the language lang, which is empty
+// for values without one.
+func (l RuneLimit) forLang(lang string) int {
+	if max, ok := l.Langs[lang]; ok && len(lang) > 0 {
+		return max
+	}
+	return l.Max
+}
+
+// Validate checks the Set and Del NQuads of the mutation against the options,
+// returning an error naming the first NQuad which fails a check.
+func (m Mutation) Validate(opts ValidateOptions) error {
+	check := func(nquads []*protos.NQuad, name string) error {
+		for i, nq := range nquads {
+			err := opts.validate(NQuad{nq})
+			if err == nil && name == "delete" {
+				err = NQuad{nq}.checkDeleteObject()
+			}
+			if err != nil {
+				return x.Wrapf(err, "while validating %s nquad at index %d", name, i)
+			}
+		}
+		return nil
+	}
+	if err := check(m.Set, "set"); err != nil {
+		return err
+	}
+	if err := opts.checkCardinality(m.Set); err != nil {
+		return err
+	}
+	return check(m.Del, "delete")
+}
+
+// checkCardinality returns an error if the Set NQuads add more objects to a
+// subject than allowed for the predicate by MaxCardinality.
+func (opts ValidateOptions) checkCardinality(set []*protos.NQuad) error {
+	if len(opts.MaxCardinality) == 0 {
+		return nil
+	}
+	type key struct {
+		subject, subjectVar, pred string
+	}
+	counts := make(map[key]int)
+	for i, nq := range set {
+		max, ok := opts.MaxCardinality[nq.Predicate]
+		if !ok {
+			continue
+		}
+		k := key{nq.Subject, nq.SubjectVar, nq.Predicate}
+		if len(nq.ObjectIds) > 0 {
+			counts[k] += len(nq.ObjectIds)
+		} else {
+			counts[k]++
+		}
+		if counts[k] > max {
+			return x.Errorf("Predicate %s can have at most %d objects per subject, but set "+
+				"nquad at index %d adds another for subject %s", nq.Predicate, max, i,
+				nq.Subject+nq.SubjectVar)
+		}
+	}
+	return nil
+}
+
+func (opts ValidateOptions) validate(nq NQuad) error {
+	if err := nq.checkLang(); err != nil {
+		return err
+	}
+	if opts.MaxValueBytes > 0 && nq.ObjectValue != nil {
+		b, _, err := byteVal(nq)
+		if err != nil {
+			return err
+		}
+		if len(b) > opts.MaxValueBytes {
+			return x.Errorf("Value for predicate %s has %d bytes, more than the limit of %d",
+				nq.Predicate, len(b), opts.MaxValueBytes)
+		}
+	}
+	if opts.RejectControlChars {
+		if str, ok := stringValue(nq.ObjectValue); ok {
+			if i := indexControlChar(str); i >= 0 {
+				return x.Errorf("Value for predicate %s has control character %q at byte %d",
+					nq.Predicate, str[i], i)
+			}
+		}
+	}
+	if limit, ok := opts.MaxRunes[nq.Predicate]; ok {
+		str, _ := stringValue(nq.ObjectValue)
+		max := limit.forLang(nq.Lang)
+		if n := utf8.RuneCountInString(str); max > 0 && n > max {
+			lang := ""
+			if len(nq.Lang) > 0 {
+				lang = " in language " + nq.Lang
+			}
+			return x.Errorf("Value for predicate %s%s has %d runes, more than the limit of %d",
+				nq.Predicate, lang, n, max)
+		}
+	}
+	if opts.Ratios[nq.Predicate] && nq.ObjectValue != nil &&
+		nq.ObjectValue.GetDefaultVal() != x.Star {
+		return checkRatio(nq)
+	}
+	return nil
+}
+
+// stringValue returns the string of a string or default value.
+func stringValue(v *protos.Value) (string, bool) {
+	switch v := v.GetVal().(type) {
+	case *protos.Value_StrVal:
+		return v.StrVal, true
+	case *protos.Value_DefaultVal:
+		return v.DefaultVal, true
+	}
+	return "", false
+}
+
+// isDisallowedControl returns true for the control characters which aren't
+// allowed in string values, which are all but tab, newline and carriage return.
+func isDisallowedControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}
+
+// indexControlChar returns the index of the first disallowed control
+// character in s, or -1 if there is none.
+func indexControlChar(s string) int {
+	return strings.IndexFunc(s, isDisallowedControl)
+}
+
+// checkRatio returns an error unless the value of the NQuad is a number
+// between 0 and 1.
+func checkRatio(nq NQuad) error {
+	var f float64
+	switch v := nq.ObjectValue.Val.(type) {
+	case *protos.Value_DoubleVal:
+		f = v.DoubleVal
+	case *protos.Value_IntVal:
+		f = float64(v.IntVal)
+	case *protos.Value_StrVal, *protos.Value_DefaultVal:
+		str := typeValFrom(nq.ObjectValue).Value.(string)
+		dst, err := types.Convert(types.Val{Tid: types.StringID, Value: []byte(str)},
+			types.FloatID)
+		if err != nil {
+			return x.Wrapf(err, "Value for predicate %s should be a ratio", nq.Predicate)
+		}
+		f = dst.Value.(float64)
+	default:
+		return x.Errorf("Value for predicate %s should be a ratio, got type %s", nq.Predicate,
+			typeValFrom(nq.ObjectValue).Tid.Name())
+	}
+	if !(f >= 0 && f <= 1) {
+		return x.Errorf("Value %v for predicate %s should be a ratio between 0 and 1", f,
+			nq.Predicate)
+	}
+	return nil
+}
+
+// checkDeleteObject returns an error for a delete without an object, which
+// could mean deleting all the objects of the predicate. That has to be asked
+// for explicitly with a * object.
+func (nq NQuad) checkDeleteObject() error {
+	if nq.valueType() == x.ValueEmpty && len(nq.ObjectVar) == 0 && len(nq.ObjectIds) == 0 {
+		return x.Errorf("Delete for predicate %s has no object, which is ambiguous. Use * "+
+			"as the object to delete all of them", nq.Predicate)
+	}
+	return nil
+}
+
+// FacetOrder is the order of the facets on the edges converted from NQuads.
+type FacetOrder int
+
+const (
+	// FacetsAsGiven keeps the facets in the order the client gave them in.
+	FacetsAsGiven FacetOrder = iota
+	// FacetsSorted sorts the facets by key.
+	FacetsSorted
+)
+
+// FanOut returns the number of outgoing edges of every subject in nquads, keyed
+// by the uid GetUid returns for it, so callers can detect subjects with too
+// many edges before loading them. NQuads whose subject is a variable or doesn't
+// have a valid uid aren't counted.
+func FanOut(nquads []NQuad) map[uint64]int {
+	counts := make(map[uint64]int)
+	for _, nq := range nquads {
+		if len(nq.SubjectVar) > 0 || nq.Subject == x.Star {
+			continue
+		}
+		uid, err := GetUid(nq.Subject)
+		if err != nil {
+			continue
+		}
+		counts[uid]++
+	}
+	return counts
+}
+
+// AssignContentUids returns uids for the blank nodes in nquads which only
+// depend on the input, so that loading the same input again yields the same
+// uids. The uid of a blank node is the fingerprint of the namespace, its name
+// and the predicate and other end of the first NQuad it appears in. The map can
+// be passed to ToEdgeUsing or ToEdges.
+//
+// As the uids are 64 bit fingerprints, different blank nodes can collide,
+// though it's unlikely, and they may collide with uids leased from Dgraph Zero.
+// Use a namespace per data set to keep blank nodes of different loads apart.
+func AssignContentUids(namespace string, nquads []NQuad) map[string]uint64 {
+	newToUid := make(map[string]uint64)
+	assign := func(blank, pred, other string) {
+		if !strings.HasPrefix(blank, "_:") {
+			return
+		}
+		if _, ok := newToUid[blank]; ok {
+			return
+		}
+		id := strings.Join([]string{namespace, blank, pred, other}, "\x00")
+		uid := farm.Fingerprint64([]byte(id))
+		if uid == 0 {
+			// Zero isn't a valid uid.
+			uid = 1
+		}
+		newToUid[blank] = uid
+	}
+	for _, nq := range nquads {
+		object := nq.ObjectId
+		if nq.ObjectValue != nil {
+			object = contentOf(nq)
+		}
+		assign(nq.Subject, nq.Predicate, object)
+		assign(nq.ObjectId, nq.Predicate, nq.Subject)
+	}
+	return newToUid
+}
+
+// contentOf returns the type of the object value of the NQuad with the bytes it
+// is stored as, and its language. Unlike the text form of the value, these
+// don't change with the version of the protobuf library. Values which can't be
+// marshalled, and so can't be converted to an edge, are only told apart by
+// their type.
+func contentOf(nq NQuad) string {
+	b, tid, err := byteVal(nq)
+	if err != nil {
+		b = nil
+	}
+	return strconv.Itoa(int(tid)) + "\x00" + string(b) + "@" + nq.Lang
+}
+
+// ConvertOptions holds the options used while converting a Mutation to edges.
+type ConvertOptions struct {
+	// ACL is checked for the predicate of every NQuad, which gives an error if
+	// it isn't allowed.
+	ACL PredicateACL
+	// DropEmptyValues skips the Set NQuads with an empty string or default
+	// value, which some clients send to mean that there is no value.
+	DropEmptyValues bool
+	// FacetOrder is the order of the facets on the edges.
+	FacetOrder FacetOrder
+	// DetectTypePrefix converts string values starting with geo: or dt: to
+	// geo and datetime values. Other string values are left as they are.
+	DetectTypePrefix bool
+	// Tombstone, if set, marks the edges for Del NQuads as tombstones, with
+	// facets recording who deleted them and when. The server keeps tombstoned
+	// edges, adding these facets to them, instead of removing them.
+	Tombstone *Tombstone
+	// AddCreatedAt adds the CreatedAtFacet facet, set to the time of the
+	// conversion, to the edges for Set NQuads which don't have it already.
+	AddCreatedAt bool
+	// Clock returns the time of the conversion. It defaults to time.Now.
+	Clock func() time.Time
+	// TruncateStrings maps predicates to the maximum size in bytes of their
+	// string values. Longer values in Set NQuads are cut at the last whole
+	// rune which fits, and a warning is passed to Warn. A negative size is
+	// taken as 0.
+	TruncateStrings map[string]int
+	// Warn, if set, is called with the warnings raised during conversion.
+	Warn func(Warning)
+	// NameRules, if set, are used to infer the type of untyped values from the
+	// name of their predicate, e.g. with DefaultNameRules. Values of predicates
+	// matching no rule are left untyped.
+	NameRules []NameRule
+	// MaxTTL, if set, is the longest time to live allowed in the TTLFacet of
+	// Set NQuads. Longer ones are clamped to it, and a warning is passed to
+	// Warn. Negative ones are an error.
+	MaxTTL time.Duration
+	// RelativeTimes lets the values converted to datetime by DetectTypePrefix
+	// or NameRules be relative to the time of the conversion, as now, now-7d
+	// or now+1h. The units are s, m, h, d and w.
+	RelativeTimes bool
+	// FoldXidCase looks the xids up in newToUid with FoldXid, so the map is
+	// expected to hold lower case xids. Blank nodes are looked up as they are.
+	// Enabling it changes which node xids with upper case letters map to.
+	FoldXidCase bool
+	// Values, if set, interns the string values of the edges, so that edges
+	// with the same value share its bytes.
+	Values *ValueInterner
+	// InternalFacets allows facet keys with the ReservedFacetPrefix, for
+	// facets set by Dgraph itself rather than by users.
+	InternalFacets bool
+	// StripControlChars removes the control characters rejected by
+	// ValidateOptions.RejectControlChars from the string values of Set
+	// NQuads, and passes a warning to Warn for each value changed.
+	StripControlChars bool
+}
+
+// ValueInterner keeps one copy of each string value seen, for loads with many
+// repeated values such as category labels. It isn't safe for concurrent use.
+type ValueInterner struct {
+	values map[string][]byte
+}
+
+// NewValueInterner returns an empty interner.
+func NewValueInterner() *ValueInterner {
+	return &ValueInterner{values: make(map[string][]byte)}
+}
+
+// Intern returns the bytes kept for a value equal to b, keeping b if there is
+// none yet.
+func (in *ValueInterner) Intern(b []byte) []byte {
+	// The conversion in the lookup doesn't allocate.
+	if v, ok := in.values[string(b)]; ok {
+		return v
+	}
+	in.values[string(b)] = b
+	return b
+}
+
+// Len returns the number of distinct values kept.
+func (in *ValueInterner) Len() int {
+	return len(in.values)
+}
+
+// truncateUTF8 returns the longest prefix of s of at most max bytes which
+// doesn't end in the middle of a rune. A negative max is taken as 0.
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	if max < 0 {
+		max = 0
+	}
+	i := max
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return s[:i]
+}
+
+// truncateString returns the NQuad at the given index of the Set NQuads with
+// its string value truncated to the limit for its predicate, if it's over.
+func (opts ConvertOptions) truncateString(nq NQuad, idx int) NQuad {
+	max, ok := opts.TruncateStrings[nq.Predicate]
+	if !ok {
+		return nq
+	}
+	var str string
+	switch v := nq.ObjectValue.GetVal().(type) {
+	case *protos.Value_StrVal:
+		str = v.StrVal
+	case *protos.Value_DefaultVal:
+		str = v.DefaultVal
+	default:
+		return nq
+	}
+	if len(str) <= max {
+		return nq
+	}
+	short := truncateUTF8(str, max)
+	val := proto.Clone(nq.ObjectValue).(*protos.Value)
+	switch v := val.Val.(type) {
+	case *protos.Value_StrVal:
+		v.StrVal = short
+	case *protos.Value_DefaultVal:
+		v.DefaultVal = short
+	}
+	cp := *nq.NQuad
+	cp.ObjectValue = val
+	if opts.Warn != nil {
+		opts.Warn(Warning{
+			Index:     idx,
+			Predicate: nq.Predicate,
+			Message: fmt.Sprintf("string value of %d bytes truncated to %d bytes, the limit is %d",
+				len(str), len(short), max),
+		})
+	}
+	return NQuad{&cp}
+}
+
+// stripControlChars returns the NQuad with the disallowed control characters
+// removed from its string value.
+func (opts ConvertOptions) stripControlChars(nq NQuad, idx int) NQuad {
+	str, ok := stringValue(nq.ObjectValue)
+	if !ok || indexControlChar(str) < 0 {
+		return nq
+	}
+	clean := strings.Map(func(r rune) rune {
+		if isDisallowedControl(r) {
+			return -1
+		}
+		return r
+	}, str)
+	val := proto.Clone(nq.ObjectValue).(*protos.Value)
+	switch v := val.Val.(type) {
+	case *protos.Value_StrVal:
+		v.StrVal = clean
+	case *protos.Value_DefaultVal:
+		v.DefaultVal = clean
+	}
+	cp := *nq.NQuad
+	cp.ObjectValue = val
+	if opts.Warn != nil {
+		opts.Warn(Warning{
+			Index:     idx,
+			Predicate: nq.Predicate,
+			Message: fmt.Sprintf("%d control characters removed from string value",
+				utf8.RuneCountInString(str)-utf8.RuneCountInString(clean)),
+		})
+	}
+	return NQuad{&cp}
+}
+
+// TTLFacet is the int facet holding the number of seconds an edge expires
+// after, as checked by ConvertOptions.MaxTTL.
+const TTLFacet = "ttl"
+
+// clampTTL returns the NQuad with its TTLFacet clamped to opts.MaxTTL.
+func (opts ConvertOptions) clampTTL(nq NQuad, idx int) (NQuad, error) {
+	for i, f := range nq.Facets {
+		if f.Key != TTLFacet {
+			continue
+		}
+		if f.ValType != protos.Facet_INT {
+			return nq, x.Errorf("Facet %s should be an int number of seconds", TTLFacet)
+		}
+		ttl := facets.ValFor(f).Value.(int64)
+		if ttl < 0 {
+			return nq, x.Errorf("Facet %s can't be negative, got %d", TTLFacet, ttl)
+		}
+		max := int64(opts.MaxTTL / time.Second)
+		if ttl <= max {
+			return nq, nil
+		}
+		clamped, err := facets.FacetFor(TTLFacet, strconv.FormatInt(max, 10))
+		if err != nil {
+			return nq, err
+		}
+		cp := *nq.NQuad
+		cp.Facets = append([]*protos.Facet{}, nq.Facets...)
+		cp.Facets[i] = clamped
+		if opts.Warn != nil {
+			opts.Warn(Warning{
+				Index:     idx,
+				Predicate: nq.Predicate,
+				Message:   fmt.Sprintf("ttl of %ds clamped to the limit of %ds", ttl, max),
+			})
+		}
+		return NQuad{&cp}, nil
+	}
+	return nq, nil
+}
+
+// Names of the facets reserved for tombstone edges.
+const (
+	DeletedAtFacet = "deletedAt"
+	DeletedByFacet = "deletedBy"
+)
+
+// CreatedAtFacet is the facet holding the time an edge was created at, as set
+// by ConvertOptions.AddCreatedAt.
+const CreatedAtFacet = "createdAt"
+
+// dateTimeFacet returns a datetime facet for the given key and time.
+func dateTimeFacet(key string, t time.Time) (*protos.Facet, error) {
+	return facets.FacetFor(key, t.UTC().Format(time.RFC3339Nano))
+}
+
+func (opts ConvertOptions) now() time.Time {
+	if opts.Clock != nil {
+		return opts.Clock()
+	}
+	return time.Now()
+}
+
+// addCreatedAt adds the CreatedAtFacet facet to the edge, unless it has one.
+func (opts ConvertOptions) addCreatedAt(edge *protos.DirectedEdge) error {
+	for _, f := range edge.Facets {
+		if f.Key == CreatedAtFacet {
+			return nil
+		}
+	}
+	f, err := dateTimeFacet(CreatedAtFacet, opts.now())
+	if err != nil {
+		return err
+	}
+	fcs := make([]*protos.Facet, 0, len(edge.Facets)+1)
+	fcs = append(fcs, edge.Facets...)
+	edge.Facets = append(fcs, f)
+	return nil
+}
+
+// Tombstone holds who deleted the edges and when, for audit.
+type Tombstone struct {
+	DeletedBy string
+	DeletedAt time.Time
+}
+
+// markTombstone marks the edge as a tombstone and adds the facets recording
+// the delete.
+func (t *Tombstone) markTombstone(edge *protos.DirectedEdge) error {
+	if edge.Attr == x.Star || string(edge.Value) == x.Star {
+		return x.Errorf("Tombstone can't be used to delete all the edges: %+v", edge)
+	}
+	if len(edge.FacetCondOp) > 0 {
+		return x.Errorf("Tombstone can't be used with a facet condition: %+v", edge)
+	}
+	for _, f := range edge.Facets {
+		if f.Key == DeletedAtFacet || f.Key == DeletedByFacet {
+			return x.Errorf("Facet %s is reserved for tombstones", f.Key)
+		}
+	}
+	by, err := facets.FacetFor(DeletedByFacet, strconv.Quote(t.DeletedBy))
+	if err != nil {
+		return err
+	}
+	at, err := dateTimeFacet(DeletedAtFacet, t.DeletedAt)
+	if err != nil {
+		return err
+	}
+	fcs := make([]*protos.Facet, 0, len(edge.Facets)+2)
+	fcs = append(fcs, edge.Facets...)
+	edge.Facets = append(fcs, by, at)
+	edge.Tombstone = true
+	return nil
+}
+
+// ReservedFacetPrefix starts the facet keys reserved for Dgraph itself.
+const ReservedFacetPrefix = "dgraph."
+
+// MaxFacetKeyLength is the maximum size of a facet key in bytes.
+const MaxFacetKeyLength = 256
+
+// ValidateFacetKey returns an error if the facet key given by a user isn't
+// valid. Keys start with a letter or an underscore, followed by letters,
+// digits, underscores, dots and dashes, and can't be reserved.
+func ValidateFacetKey(key string) error {
+	return checkFacetKey(key, false)
+}
+
+func checkFacetKey(key string, allowReserved bool) error {
+	if len(key) == 0 {
+		return x.Errorf("Facet key can't be empty")
+	}
+	if len(key) > MaxFacetKeyLength {
+		return x.Errorf("Facet key %.32q... is longer than %d bytes", key, MaxFacetKeyLength)
+	}
+	for i, r := range key {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '.' || r == '-' || unicode.IsDigit(r)):
+		default:
+			return x.Errorf("Invalid character %q in facet key %q", r, key)
+		}
+	}
+	if !allowReserved && strings.HasPrefix(key, ReservedFacetPrefix) {
+		return x.Errorf("Facet key %q is reserved, as it starts with %q", key,
+			ReservedFacetPrefix)
+	}
+	return nil
+}
+
+// orderFacets gives the edge its own copy of the facets, so that sorting the
+// facets of the NQuad afterwards doesn't change their order on the edge. The
+// facet keys are validated on the way.
+func (opts ConvertOptions) orderFacets(edge *protos.DirectedEdge) error {
+	if len(edge.Facets) == 0 {
+		return nil
+	}
+	for _, f := range edge.Facets {
+		if err := checkFacetKey(f.Key, opts.InternalFacets); err != nil {
+			return err
+		}
+	}
+	fs := make([]*protos.Facet, len(edge.Facets))
+	copy(fs, edge.Facets)
+	edge.Facets = fs
+	if opts.FacetOrder == FacetsSorted {
+		return facets.SortAndValidate(fs)
+	}
+	return nil
+}
+
+// hasEmptyValue returns true if the NQuad has an empty string or default
+// value. The RDF parser stores empty literals as _nil_.
+func (nq NQuad) hasEmptyValue() bool {
+	switch v := nq.ObjectValue.GetVal().(type) {
+	case *protos.Value_StrVal:
+		return v.StrVal == "" || v.StrVal == "_nil_"
+	case *protos.Value_DefaultVal:
+		return v.DefaultVal == "" || v.DefaultVal == "_nil_"
+	}
+	return false
+}
+
+// typePrefixes maps the prefixes which some loaders put in front of string
+// values to the type of the rest of the value, e.g. dt:2017-01-01.
+var typePrefixes = map[string]types.TypeID{
+	"geo:": types.GeoID,
+	"dt:":  types.DateTimeID,
+}
+
+// withValueOfType returns a copy of the NQuad with the value str converted to
+// the type tid.
+func (nq NQuad) withValueOfType(str string, tid types.TypeID,
+	now func() time.Time) (NQuad, error) {
+	var dst types.Val
+	var err error
+	if tid == types.DateTimeID && now != nil && strings.HasPrefix(str, "now") {
+		dst.Value, err = parseRelativeTime(str, now())
+	} else {
+		dst, err = types.Convert(types.Val{Tid: types.StringID, Value: []byte(str)}, tid)
+	}
+	if err != nil {
+		return nq, err
+	}
+	val, err := types.ObjectValue(tid, dst.Value)
+	if err != nil {
+		return nq, err
+	}
+	cp := *nq.NQuad
+	cp.ObjectValue = val
+	return NQuad{&cp}, nil
+}
+
+var relativeUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// parseRelativeTime returns the time given by s relative to now, which is
+// either now, or now followed by a sign, a number and a unit, e.g. now-7d.
+func parseRelativeTime(s string, now time.Time) (time.Time, error) {
+	rel := strings.TrimPrefix(s, "now")
+	if len(rel) == 0 {
+		return now, nil
+	}
+	if len(rel) < 3 || (rel[0] != '+' && rel[0] != '-') {
+		return time.Time{}, x.Errorf("Invalid relative time: %q", s)
+	}
+	unit, ok := relativeUnits[rel[len(rel)-1]]
+	if !ok {
+		return time.Time{}, x.Errorf("Invalid unit in relative time: %q", s)
+	}
+	n, err := strconv.ParseUint(rel[1:len(rel)-1], 10, 32)
+	if err != nil {
+		return time.Time{}, x.Errorf("Invalid number in relative time: %q", s)
+	}
+	d := time.Duration(n) * unit
+	if rel[0] == '-' {
+		d = -d
+	}
+	return now.Add(d), nil
+}
+
+// NameRule gives the type of the values of the predicates with names matching
+// Pattern. A * in the pattern stands for any text, e.g. *_at or is_*.
+type NameRule struct {
+	Pattern string
+	Type    types.TypeID
+}
+
+// DefaultNameRules are the common naming conventions for predicates.
+var DefaultNameRules = []NameRule{
+	{Pattern: "*_at", Type: types.DateTimeID},
+	{Pattern: "*_count", Type: types.IntID},
+	{Pattern: "is_*", Type: types.BoolID},
+}
+
+func (r NameRule) matches(pred string) bool {
+	i := strings.IndexByte(r.Pattern, '*')
+	if i < 0 {
+		return pred == r.Pattern
+	}
+	prefix, suffix := r.Pattern[:i], r.Pattern[i+1:]
+	return len(pred) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(pred, prefix) && strings.HasSuffix(pred, suffix)
+}
+
+// withTypeFromName returns a copy of the NQuad with its untyped value converted
+// to the type of the first rule matching its predicate. NQuads with typed values
+// or matching no rule are returned as is.
+func withTypeFromName(nq NQuad, rules []NameRule, now func() time.Time) (NQuad, error) {
+	v, ok := nq.ObjectValue.GetVal().(*protos.Value_DefaultVal)
+	if !ok || v.DefaultVal == x.Star {
+		return nq, nil
+	}
+	for _, r := range rules {
+		if !r.matches(nq.Predicate) {
+			continue
+		}
+		cp, err := nq.withValueOfType(v.DefaultVal, r.Type, now)
+		if err != nil {
+			return nq, x.Wrapf(err, "while converting value for predicate %s to %s",
+				nq.Predicate, r.Type.Name())
+		}
+		return cp, nil
+	}
+	return nq, nil
+}
+
+// withTypeFromPrefix returns a copy of the NQuad with the value converted to the
+// type given by its prefix. NQuads without a known prefix are returned as is.
+func withTypeFromPrefix(nq NQuad, now func() time.Time) (NQuad, error) {
+	var str string
+	switch v := nq.ObjectValue.GetVal().(type) {
+	case *protos.Value_StrVal:
+		str = v.StrVal
+	case *protos.Value_DefaultVal:
+		str = v.DefaultVal
+	default:
+		return nq, nil
+	}
+	for prefix, tid := range typePrefixes {
+		if !strings.HasPrefix(str, prefix) {
+			continue
+		}
+		cp, err := nq.withValueOfType(str[len(prefix):], tid, now)
+		if err != nil {
+			return nq, x.Wrapf(err, "while parsing value with prefix %s", prefix)
+		}
+		return cp, nil
+	}
+	return nq, nil
+}
+
+// UidResolver maps XIDs to UIDs, for XIDs which are kept in a persisted table
+// instead of being fingerprinted.
+type UidResolver interface {
+	// Resolve returns the UIDs for the given XIDs. It may leave out XIDs it has
+	// no UID for, in which case converting the NQuads using them fails.
+	Resolve(ctx context.Context, xids []string) (map[string]uint64, error)
+}
+
+// ToEdgesResolving converts the NQuads to edges, calling the resolver once with
+// all the XIDs used by them which aren't UIDs already.
+func ToEdgesResolving(ctx context.Context, nquads []NQuad,
+	resolver UidResolver) ([]*protos.DirectedEdge, error) {
+	var xids []string
+	seen := make(map[string]bool)
+	addXid := func(xid string) {
+		if len(xid) == 0 || xid == x.Star || seen[xid] {
+			return
+		}
+		if _, err := ParseUid(xid); err == nil || err == ErrInvalidUID {
+			return
+		}
+		seen[xid] = true
+		xids = append(xids, xid)
+	}
+	for _, nq := range nquads {
+		addXid(nq.Subject)
+		addXid(nq.ObjectId)
+	}
+
+	newToUid := make(map[string]uint64)
+	if len(xids) > 0 {
+		var err error
+		if newToUid, err = resolver.Resolve(ctx, xids); err != nil {
+			return nil, x.Wrapf(err, "while resolving %d xids", len(xids))
+		}
+	}
+	edges := make([]*protos.DirectedEdge, 0, len(nquads))
+	for i, nq := range nquads {
+		edge, err := nq.ToEdgeUsing(newToUid)
+		if err != nil {
+			return nil, x.Wrapf(err, "while converting nquad at index %d", i)
+		}
+		edges = append(edges, edge)
+	}
+	return edges, nil
+}
+
+// ManifestEntry records the uid an xid was converted to.
+type ManifestEntry struct {
+	Xid string
+	Uid uint64
+}
+
+// ToEdgesWithManifest converts the NQuads to edges with ToEdgeUsing, and also
+// returns the uid of every distinct subject and object, in the order they
+// first appear in, for audit logs. Uids given as such are listed as written.
+func ToEdgesWithManifest(nquads []NQuad,
+	newToUid map[string]uint64) ([]*protos.DirectedEdge, []ManifestEntry, error) {
+	var manifest []ManifestEntry
+	seen := make(map[string]bool)
+	edges := make([]*protos.DirectedEdge, 0, len(nquads))
+	for i, nq := range nquads {
+		edge, err := nq.toEdge(func(xid string) (uint64, error) {
+			uid, err := toUid(xid, newToUid)
+			if err == nil && !seen[xid] {
+				seen[xid] = true
+				manifest = append(manifest, ManifestEntry{Xid: xid, Uid: uid})
+			}
+			return uid, err
+		})
+		if err != nil {
+			return nil, nil, x.Wrapf(err, "while converting nquad at index %d", i)
+		}
+		edges = append(edges, edge)
+	}
+	return edges, manifest, nil
+}
+
+// Allocator leases new uids.
+type Allocator interface {
+	// Allocate returns the first of num consecutive uids leased to the caller.
+	Allocate(num uint64) (uint64, error)
+}
+
+// ToEdgesTwoPass converts the NQuads to edges, assigning new uids to the blank
+// nodes in them. All the blank nodes are assigned uids first, in the order they
+// first appear in, with a single call to the allocator. So an edge can refer to
+// a blank node which only appears in later NQuads. The uids assigned to the
+// blank nodes are returned along with the edges.
+func ToEdgesTwoPass(nquads []NQuad, allocator Allocator) ([]*protos.DirectedEdge,
+	map[string]uint64, error) {
+	var blanks []string
+	newToUid := make(map[string]uint64)
+	addBlank := func(xid string) {
+		if !strings.HasPrefix(xid, "_:") {
+			return
+		}
+		if _, ok := newToUid[xid]; !ok {
+			newToUid[xid] = 0
+			blanks = append(blanks, xid)
+		}
+	}
+	for _, nq := range nquads {
+		addBlank(nq.Subject)
+		addBlank(nq.ObjectId)
+	}
+
+	if len(blanks) > 0 {
+		start, err := allocator.Allocate(uint64(len(blanks)))
+		if err != nil {
+			return nil, nil, x.Wrapf(err, "while allocating uids for %d blank nodes", len(blanks))
+		}
+		if start == 0 {
+			return nil, nil, x.Errorf("Allocator returned an invalid uid: 0")
+		}
+		for i, blank := range blanks {
+			newToUid[blank] = start + uint64(i)
+		}
+	}
+
+	edges := make([]*protos.DirectedEdge, 0, len(nquads))
+	for i, nq := range nquads {
+		edge, err := nq.ToEdgeUsing(newToUid)
+		if err != nil {
+			return nil, nil, x.Wrapf(err, "while converting nquad at index %d", i)
+		}
+		edges = append(edges, edge)
+	}
+	return edges, newToUid, nil
+}
+
+// ToEdges converts the Set and Del NQuads of the mutation to edges, using the
+// newToUid map to determine the UIDs for the XIDs.
+func (m Mutation) ToEdges(newToUid map[string]uint64,
+	opts ConvertOptions) ([]*protos.DirectedEdge, error) {
+	edges := make([]*protos.DirectedEdge, 0, len(m.Set)+len(m.Del))
+	convert := func(nquads []*protos.NQuad, op protos.DirectedEdge_Op, name string) error {
+		for i, nq := range nquads {
+			nq := NQuad{nq}
+			if op == protos.DirectedEdge_SET && opts.StripControlChars {
+				nq = opts.stripControlChars(nq, i)
+			}
+			if op == protos.DirectedEdge_SET && len(opts.TruncateStrings) > 0 {
+				nq = opts.truncateString(nq, i)
+			}
+			if op == protos.DirectedEdge_SET && opts.MaxTTL > 0 {
+				var err error
+				if nq, err = opts.clampTTL(nq, i); err != nil {
+					return x.Wrapf(err, "while converting %s nquad at index %d", name, i)
+				}
+			}
+			expanded, err := nq.ExpandObjectIds()
+			if err != nil {
+				return x.Wrapf(err, "while converting %s nquad at index %d", name, i)
+			}
+			for _, nq := range expanded {
+				edge, err := opts.convert(nq, newToUid, op)
+				if err != nil {
+					return x.Wrapf(err, "while converting %s nquad at index %d", name, i)
+				}
+				if edge != nil {
+					edges = append(edges, edge)
+				}
+			}
+		}
+		return nil
+	}
+	if err := convert(m.Set, protos.DirectedEdge_SET, "set"); err != nil {
+		return nil, err
+	}
+	if err := convert(m.Del, protos.DirectedEdge_DEL, "delete"); err != nil {
+		return nil, err
+	}
+	return edges, nil
+}
+
+// convert returns the edge with the given op for the NQuad, or nil if the
+// options drop the NQuad.
+func (opts ConvertOptions) convert(nq NQuad, newToUid map[string]uint64,
+	op protos.DirectedEdge_Op) (*protos.DirectedEdge, error) {
+	if op == protos.DirectedEdge_SET {
+		if opts.DropEmptyValues && nq.hasEmptyValue() {
+			return nil, nil
+		}
+		if nq.IsLabelDelete() {
+			return nil, x.Errorf("NQuad has no object, only a label")
+		}
+	} else if nq.Increment {
+		return nil, x.Errorf("Increment is only allowed in set mutations")
+	} else if nq.CreateOnly {
+		return nil, x.Errorf("Create only is only allowed in set mutations")
+	}
+	if nq.CreateOnly && nq.Increment {
+		return nil, x.Errorf("Create only can't be used with increment")
+	}
+	var now func() time.Time
+	if opts.RelativeTimes {
+		now = opts.now
+	}
+	if opts.DetectTypePrefix {
+		var err error
+		if nq, err = withTypeFromPrefix(nq, now); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.NameRules) > 0 {
+		var err error
+		if nq, err = withTypeFromName(nq, opts.NameRules, now); err != nil {
+			return nil, err
+		}
+	}
+	var edge *protos.DirectedEdge
+	var err error
+	resolve := func(xid string) (uint64, error) {
+		if opts.FoldXidCase {
+			xid = FoldXid(xid)
+		}
+		return toUid(xid, newToUid)
+	}
+	edge, err = nq.toEdgeFor(op, opts.ACL, resolve, resolve)
+	if err != nil {
+		return nil, err
+	}
+	if edge.Op != protos.DirectedEdge_INC {
+		edge.Op = op
+	}
+	if op == protos.DirectedEdge_DEL && opts.Tombstone != nil && !edge.Tombstone {
+		if err := opts.Tombstone.markTombstone(edge); err != nil {
+			return nil, err
+		}
+	}
+	if op == protos.DirectedEdge_SET && opts.AddCreatedAt {
+		if err := opts.addCreatedAt(edge); err != nil {
+			return nil, err
+		}
+	}
+	if err := opts.orderFacets(edge); err != nil {
+		return nil, err
+	}
+	if opts.Values != nil && (edge.ValueType == protos.Posting_STRING ||
+		edge.ValueType == protos.Posting_DEFAULT) && len(edge.Value) > 0 {
+		edge.Value = opts.Values.Intern(edge.Value)
+	}
+	return edge, nil
+}
+
+// GroupByPredicate groups the edges by predicate, keeping the order of the
+// edges within each group.
+func GroupByPredicate(edges []*protos.DirectedEdge) map[string][]*protos.DirectedEdge {
+	groups := make(map[string][]*protos.DirectedEdge)
+	for _, edge := range edges {
+		groups[edge.Attr] = append(groups[edge.Attr], edge)
+	}
+	return groups
+}
+
+// SortByPredicate returns the edges with all the edges of a predicate next to
+// each other, which improves the locality of index writes. Predicates are in
+// the order they were first seen in and the edges of a predicate keep their
+// order.
+func SortByPredicate(edges []*protos.DirectedEdge) []*protos.DirectedEdge {
+	groups := GroupByPredicate(edges)
+	sorted := make([]*protos.DirectedEdge, 0, len(edges))
+	for _, edge := range edges {
+		if group, ok := groups[edge.Attr]; ok {
+			sorted = append(sorted, group...)
+			delete(groups, edge.Attr)
+		}
+	}
+	return sorted
+}
+
+// AddBucketFacet precomputes the bucket of the numeric facet with given key and
+// attaches it to the NQuad as the <key>_bucket facet, so that it's carried onto
+// the edge during conversion and can be used for range filtering.
+func (nq NQuad) AddBucketFacet(key string, width float64) error {
+	for _, f := range nq.Facets {
+		if f.Key != key {
+			continue
+		}
+		bf, err := facets.BucketFor(f, width)
+		if err != nil {
+			return err
+		}
+		return nq.addFacet(bf)
+	}
+	return x.Errorf("Facet %s not found in NQuad", key)
+}
+
+// addFacet adds f to the facets of the NQuad, keeping them sorted. The facets
+// are left as they are if f is invalid or its key is taken.
+func (nq NQuad) addFacet(f *protos.Facet) error {
+	fcs := make([]*protos.Facet, 0, len(nq.Facets)+1)
+	fcs = append(fcs, nq.Facets...)
+	fcs = append(fcs, f)
+	if err := facets.SortAndValidate(fcs); err != nil {
+		return err
+	}
+	nq.Facets = fcs
+	return nil
+}
+
+// AddDistanceFacet attaches a float facet with given key to an NQuad holding a
+// geo point, set to the great-circle distance in meters from the point to ref.
+func (nq NQuad) AddDistanceFacet(key string, ref *geom.Point) error {
+	if ref == nil {
+		return x.Errorf("Reference point is required for distance facet %s", key)
+	}
+	val := nq.ObjectValue.GetGeoVal()
+	if val == nil {
+		return x.Errorf("NQuad for predicate %s doesn't have a geo value", nq.Predicate)
+	}
+	g, err := types.Convert(types.Val{Tid: types.BinaryID, Value: val}, types.GeoID)
+	if err != nil {
+		return x.Wrapf(err, "while decoding geo value for predicate %s", nq.Predicate)
+	}
+	p, ok := g.Value.(*geom.Point)
+	if !ok {
+		return x.Errorf("Distance can only be computed for points. Got: %T", g.Value)
+	}
+	f, err := facets.FloatFacet(key, float64(types.PointDistance(p, ref)))
+	if err != nil {
+		return err
+	}
+	return nq.addFacet(f)
+}
+
+func copyValue(out *protos.DirectedEdge, nq NQuad) error {
+	if nq.Increment {
+		return copyIncrement(out, nq)
+	}
+	// NaN and infinite values can't be indexed or sorted.
+	if f, ok := nq.ObjectValue.GetVal().(*protos.Value_DoubleVal); ok &&
+		(math.IsNaN(f.DoubleVal) || math.IsInf(f.DoubleVal, 0)) {
+		return x.Errorf("Got invalid value: %v for predicate %s", f.DoubleVal, nq.Predicate)
+	}
+	var err error
+	var t types.TypeID
+	if out.Value, t, err = byteVal(nq); err != nil {
+		return err
+	}
+	out.ValueType = t.Enum()
+	return nil
+}
+
+// copyIncrement sets the delta of an increment NQuad as the int value of the edge.
+func copyIncrement(out *protos.DirectedEdge, nq NQuad) error {
+	if nq.ObjectValue == nil || len(nq.ObjectId) > 0 {
+		return x.Errorf("Increment for predicate %s needs an int value and no uid object",
+			nq.Predicate)
+	}
+	var delta int64
+	switch p := typeValFrom(nq.ObjectValue); p.Tid {
+	case types.IntID:
+		delta = p.Value.(int64)
+	case types.DefaultID:
+		// Untyped RDF literals, like "5", are parsed as default values.
+		src := types.Val{Tid: types.DefaultID, Value: []byte(p.Value.(string))}
+		v, err := types.Convert(src, types.IntID)
+		if err != nil {
+			return x.Wrapf(err, "while converting increment for predicate %s", nq.Predicate)
+		}
+		delta = v.Value.(int64)
+	default:
+		return x.Errorf("Increment for predicate %s needs an int value. Got type: %s",
+			nq.Predicate, p.Tid.Name())
+	}
+	b := types.ValueForType(types.BinaryID)
+	if err := types.Marshal(types.Val{Tid: types.IntID, Value: delta}, &b); err != nil {
+		return err
+	}
+	out.Value = b.Value.([]byte)
+	out.ValueType = types.IntID.Enum()
+	out.Op = protos.DirectedEdge_INC
+	return nil
+}
+
+// checkLang returns an error if the NQuad has a language tag but points to a
+// node, as only values can have a language.
+func (nq NQuad) checkLang() error {
+	if len(nq.Lang) > 0 && len(nq.ObjectId) > 0 {
+		return x.Errorf("NQuad for predicate %s has language %s, so its object must be "+
+			"a value. Got object: %s", nq.Predicate, nq.Lang, nq.ObjectId)
+	}
+	if nq.DefaultLang && len(nq.Lang) > 0 {
+		return x.Errorf("NQuad for predicate %s can't have language %s and be untagged",
+			nq.Predicate, nq.Lang)
+	}
+	if nq.DefaultLang && nq.ObjectValue == nil {
+		return x.Errorf("NQuad for predicate %s is untagged, so its object must be a value",
+			nq.Predicate)
+	}
+	return nil
+}
+
+func (nq NQuad) valueType() x.ValueTypeInfo {
+	if nq.Increment {
+		if nq.ObjectValue == nil || len(nq.ObjectId) > 0 {
+			// Only int values can be incremented.
+			return x.ValueUnknown
+		}
+		return x.ValueIncrement
+	}
+	if len(nq.Lang) > 0 && len(nq.ObjectId) > 0 {
+		// Never resolve the object of a language tagged NQuad as a uid.
+		return x.ValueUnknown
+	}
+	if nq.DefaultLang && (len(nq.Lang) > 0 || nq.ObjectValue == nil) {
+		// Only values can be explicitly untagged, and they have no language.
+		return x.ValueUnknown
+	}
+	hasValue := nq.ObjectValue != nil
+	hasLang := len(nq.Lang) > 0
+	hasSpecialId := len(nq.ObjectId) == 0
+	vt := x.ValueType(hasValue, hasLang, hasSpecialId)
+	if vt == x.ValueEmpty && len(nq.Label) > 0 {
+		return x.ValueLabel
+	}
+	return vt
+}
+
+// IsLabelDelete returns true if the NQuad only has a subject, a predicate and
+// a label. As a delete, it removes the edges of the predicate with the label.
+func (nq NQuad) IsLabelDelete() bool {
+	return nq.valueType() == x.ValueLabel
 }
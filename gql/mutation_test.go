@@ -0,0 +1,2831 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	geom "github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/wkb"
+	"golang.org/x/net/context"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/types/facets"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+func TestAddBucketFacet(t *testing.T) {
+	tests := []struct {
+		val    string
+		width  float64
+		bucket int64
+	}{
+		{"17", 10, 1},
+		{"20", 10, 2},
+		{"-3", 10, -1},
+		{"2.5", 0.5, 5},
+		{"0", 5, 0},
+	}
+	for _, tc := range tests {
+		f, err := facets.FacetFor("price", tc.val)
+		require.NoError(t, err)
+		nq := NQuad{&protos.NQuad{
+			Subject:     "0x1",
+			Predicate:   "item",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "book"}},
+			Facets:      []*protos.Facet{f},
+		}}
+		require.NoError(t, nq.AddBucketFacet("price", tc.width))
+		require.Equal(t, 2, len(nq.Facets))
+
+		edge, err := nq.ToEdgeUsing(nil)
+		require.NoError(t, err)
+		bf := edge.Facets[1]
+		require.Equal(t, "price_bucket", bf.Key)
+		require.Equal(t, tc.bucket, facets.ValFor(bf).Value.(int64), "value %s", tc.val)
+	}
+}
+
+func TestAddBucketFacetErrors(t *testing.T) {
+	f, err := facets.FacetFor("price", "17")
+	require.NoError(t, err)
+	nq := NQuad{&protos.NQuad{Facets: []*protos.Facet{f}}}
+	require.Error(t, nq.AddBucketFacet("price", 0))
+	require.Error(t, nq.AddBucketFacet("price", -2))
+	require.Error(t, nq.AddBucketFacet("missing", 10))
+
+	f, err = facets.FacetFor("name", `"alice"`)
+	require.NoError(t, err)
+	nq.Facets = []*protos.Facet{f}
+	require.Error(t, nq.AddBucketFacet("name", 10))
+
+	// Adding the bucket a second time fails and leaves the facets as they are.
+	f, err = facets.FacetFor("price", "17")
+	require.NoError(t, err)
+	nq.Facets = []*protos.Facet{f}
+	require.NoError(t, nq.AddBucketFacet("price", 10))
+	require.Error(t, nq.AddBucketFacet("price", 10))
+	require.Equal(t, 2, len(nq.Facets))
+}
+
+func TestAddBucketFacetSorted(t *testing.T) {
+	price, err := facets.FacetFor("price", "17")
+	require.NoError(t, err)
+	zone, err := facets.FacetFor("zone", "3")
+	require.NoError(t, err)
+	nq := NQuad{&protos.NQuad{Subject: "0x1", Predicate: "item",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "book"}},
+		Facets:      []*protos.Facet{price, zone}}}
+	require.NoError(t, nq.AddBucketFacet("price", 10))
+	require.Equal(t, 3, len(nq.Facets))
+	require.Equal(t, "price_bucket", nq.Facets[1].Key)
+	require.Equal(t, "zone", nq.Facets[2].Key)
+
+	// Adding the bucket again would give two facets with the same key.
+	require.Error(t, nq.AddBucketFacet("price", 10))
+}
+
+func parallelTestNQuads(n int) []NQuad {
+	var nqs []NQuad
+	for i := 0; i < n; i++ {
+		nq := &protos.NQuad{
+			Subject:   fmt.Sprintf("_:s%d", i%7),
+			Predicate: "friend",
+		}
+		if i%2 == 0 {
+			nq.ObjectId = fmt.Sprintf("_:s%d", (i+1)%7)
+		} else {
+			nq.ObjectValue = &protos.Value{Val: &protos.Value_IntVal{IntVal: int64(i)}}
+		}
+		nqs = append(nqs, NQuad{nq})
+	}
+	return nqs
+}
+
+func TestToEdgesParallelMatchesSerial(t *testing.T) {
+	newToUid := make(map[string]uint64)
+	for i := 0; i < 7; i++ {
+		newToUid[fmt.Sprintf("_:s%d", i)] = uint64(100 + i)
+	}
+	nqs := parallelTestNQuads(100)
+
+	var serial []*protos.DirectedEdge
+	for _, nq := range nqs {
+		edge, err := nq.ToEdgeUsing(newToUid)
+		require.NoError(t, err)
+		serial = append(serial, edge)
+	}
+
+	resolve := func(xid string) (uint64, error) {
+		return toUid(xid, newToUid)
+	}
+	for _, workers := range []int{0, 1, 3, 16} {
+		edges, err := ToEdgesParallel(nqs, resolve, workers)
+		require.NoError(t, err)
+		require.Equal(t, serial, edges)
+	}
+}
+
+func TestToEdgesParallelError(t *testing.T) {
+	nqs := parallelTestNQuads(20)
+	resolve := func(xid string) (uint64, error) {
+		if xid == "_:s3" {
+			return 0, errors.New("unknown xid")
+		}
+		return 1, nil
+	}
+	_, err := ToEdgesParallel(nqs, resolve, 4)
+	require.Error(t, err)
+	// _:s3 is first used as the object of the NQuad at index 2.
+	require.Contains(t, err.Error(), "index 2")
+}
+
+// TestToEdgesParallelRace is meant to be run with -race, to check that
+// conversion doesn't write to any state shared between goroutines.
+func TestToEdgesParallelRace(t *testing.T) {
+	newToUid := make(map[string]uint64)
+	for i := 0; i < 7; i++ {
+		newToUid[fmt.Sprintf("_:s%d", i)] = uint64(100 + i)
+	}
+	nqs := parallelTestNQuads(1000)
+
+	// require can't be used outside of the test goroutine.
+	errs := make([]error, 4)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = ToEdgesParallel(nqs, func(xid string) (uint64, error) {
+				return toUid(xid, newToUid)
+			}, 8)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func TestMutationMap(t *testing.T) {
+	m := Mutation{
+		Set: []*protos.NQuad{
+			{Subject: "_:a", Predicate: "Name"},
+			{Subject: "_:a", Predicate: "FRIEND", ObjectId: "_:b"},
+		},
+		Del: []*protos.NQuad{{Subject: "0x1", Predicate: "Age"}},
+	}
+	err := m.Map(func(nq NQuad) (NQuad, error) {
+		nq.Predicate = strings.ToLower(nq.Predicate)
+		return nq, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "name", m.Set[0].Predicate)
+	require.Equal(t, "friend", m.Set[1].Predicate)
+	require.Equal(t, "_:b", m.Set[1].ObjectId)
+	require.Equal(t, "age", m.Del[0].Predicate)
+}
+
+func TestMutationMapError(t *testing.T) {
+	var m Mutation
+	for i := 0; i < 4; i++ {
+		m.Set = append(m.Set, &protos.NQuad{Subject: "_:a", Predicate: "p"})
+	}
+	calls := 0
+	err := m.Map(func(nq NQuad) (NQuad, error) {
+		if calls == 2 {
+			return nq, errors.New("bad nquad")
+		}
+		calls++
+		return NQuad{&protos.NQuad{Subject: "_:a", Predicate: "q"}}, nil
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "index 2")
+	require.Contains(t, err.Error(), "bad nquad")
+	require.Equal(t, "q", m.Set[1].Predicate)
+	require.Equal(t, "p", m.Set[2].Predicate)
+	require.Equal(t, "p", m.Set[3].Predicate)
+}
+
+func TestAddDistanceFacet(t *testing.T) {
+	london := geom.NewPoint(geom.XY).MustSetCoords(geom.Coord{-0.1278, 51.5074})
+	paris := geom.NewPoint(geom.XY).MustSetCoords(geom.Coord{2.3522, 48.8566})
+	val, err := types.ObjectValue(types.GeoID, paris)
+	require.NoError(t, err)
+
+	nq := NQuad{&protos.NQuad{Subject: "_:paris", Predicate: "loc", ObjectValue: val}}
+	require.NoError(t, nq.AddDistanceFacet("from_london", london))
+	require.Equal(t, 1, len(nq.Facets))
+	f := nq.Facets[0]
+	require.Equal(t, "from_london", f.Key)
+	require.Equal(t, protos.Facet_FLOAT, f.ValType)
+	d := facets.ValFor(f).Value.(float64)
+	require.True(t, math.Abs(d-343.5e3) < 1e3, "Got distance %v", d)
+
+	// A point at the reference has a distance of zero, which is still a float.
+	nq.Facets = nil
+	require.NoError(t, nq.AddDistanceFacet("from_paris", paris))
+	require.Equal(t, protos.Facet_FLOAT, nq.Facets[0].ValType)
+	require.Equal(t, 0.0, facets.ValFor(nq.Facets[0]).Value.(float64))
+
+	// The facets stay sorted by key.
+	require.NoError(t, nq.AddDistanceFacet("from_london", london))
+	require.Equal(t, "from_london", nq.Facets[0].Key)
+	require.Equal(t, "from_paris", nq.Facets[1].Key)
+	require.Error(t, nq.AddDistanceFacet("from_paris", paris))
+}
+
+func TestAddDistanceFacetErrors(t *testing.T) {
+	ref := geom.NewPoint(geom.XY).MustSetCoords(geom.Coord{0, 0})
+	nq := NQuad{&protos.NQuad{
+		Subject:     "_:a",
+		Predicate:   "loc",
+		ObjectValue: &protos.Value{Val: &protos.Value_GeoVal{GeoVal: []byte("garbage")}},
+	}}
+	require.Error(t, nq.AddDistanceFacet("dist", ref))
+
+	nq.ObjectValue = &protos.Value{Val: &protos.Value_StrVal{StrVal: "Paris"}}
+	require.Error(t, nq.AddDistanceFacet("dist", ref))
+	require.Empty(t, nq.Facets)
+
+	val, err := types.ObjectValue(types.GeoID, ref)
+	require.NoError(t, err)
+	nq.ObjectValue = val
+	require.Error(t, nq.AddDistanceFacet("dist", nil))
+	require.Empty(t, nq.Facets)
+}
+
+func TestIncrementEdge(t *testing.T) {
+	nq := NQuad{&protos.NQuad{
+		Subject:     "0x1",
+		Predicate:   "views",
+		ObjectValue: &protos.Value{Val: &protos.Value_IntVal{IntVal: 5}},
+		Increment:   true,
+	}}
+	edge, err := nq.ToEdgeUsing(nil)
+	require.NoError(t, err)
+	require.Equal(t, protos.DirectedEdge_INC, edge.Op)
+	require.Equal(t, types.IntID.Enum(), edge.ValueType)
+	delta, err := types.Convert(types.Val{Tid: types.BinaryID, Value: edge.Value}, types.IntID)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), delta.Value.(int64))
+
+	// Untyped RDF literals are converted to int.
+	nq.ObjectValue = &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: "-3"}}
+	edge, err = nq.ToEdgeUsing(nil)
+	require.NoError(t, err)
+	delta, err = types.Convert(types.Val{Tid: types.BinaryID, Value: edge.Value}, types.IntID)
+	require.NoError(t, err)
+	require.Equal(t, int64(-3), delta.Value.(int64))
+}
+
+func TestIncrementEdgeErrors(t *testing.T) {
+	nq := NQuad{&protos.NQuad{
+		Subject:     "0x1",
+		Predicate:   "views",
+		ObjectValue: &protos.Value{Val: &protos.Value_DoubleVal{DoubleVal: 1.5}},
+		Increment:   true,
+	}}
+	_, err := nq.ToEdgeUsing(nil)
+	require.Error(t, err)
+
+	nq.ObjectValue = &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: "one"}}
+	_, err = nq.ToEdgeUsing(nil)
+	require.Error(t, err)
+}
+
+func TestIncrementEdgeWithoutValue(t *testing.T) {
+	nq := NQuad{&protos.NQuad{Subject: "0x1", Predicate: "views", Increment: true}}
+	_, err := nq.ToEdgeUsing(nil)
+	require.Error(t, err)
+	_, err = nq.createEdge(1, nil)
+	require.Error(t, err)
+	require.Error(t, copyIncrement(&protos.DirectedEdge{}, nq))
+}
+
+func TestIncrementEdgeWithObjectId(t *testing.T) {
+	nq := NQuad{&protos.NQuad{Subject: "0x1", Predicate: "views", ObjectId: "0x2",
+		Increment: true}}
+	_, err := nq.ToEdgeUsing(nil)
+	require.Error(t, err)
+	_, err = nq.createEdge(1, nil)
+	require.Error(t, err)
+
+	nq.ObjectValue = &protos.Value{Val: &protos.Value_IntVal{IntVal: 1}}
+	_, err = nq.ToEdgeUsing(nil)
+	require.Error(t, err)
+	require.Error(t, copyIncrement(&protos.DirectedEdge{}, nq))
+}
+
+func floatNQuad(v float64) NQuad {
+	return NQuad{&protos.NQuad{
+		Subject:     "_:a",
+		Predicate:   "weight",
+		ObjectValue: &protos.Value{Val: &protos.Value_DoubleVal{DoubleVal: v}},
+	}}
+}
+
+func TestEqualsWithin(t *testing.T) {
+	// Computed at runtime, so that it isn't folded into exactly 0.3.
+	tenth := 0.1
+	a := floatNQuad(0.3)
+	b := floatNQuad(tenth + 0.2)
+	require.False(t, a.Equals(b))
+	require.False(t, a.EqualsWithin(b, 0))
+	require.True(t, a.EqualsWithin(b, 1e-9))
+	require.True(t, a.EqualsWithin(floatNQuad(0.3), 0))
+
+	// Values outside of epsilon aren't equal.
+	require.False(t, a.EqualsWithin(floatNQuad(0.31), 1e-9))
+
+	// Other fields still have to match exactly.
+	c := floatNQuad(tenth + 0.2)
+	c.Predicate = "height"
+	require.False(t, a.EqualsWithin(c, 1e-9))
+
+	// Epsilon doesn't apply to values which aren't floats.
+	s1 := NQuad{&protos.NQuad{Subject: "_:a", Predicate: "name",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "x"}}}}
+	s2 := NQuad{&protos.NQuad{Subject: "_:a", Predicate: "name",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "y"}}}}
+	require.False(t, s1.EqualsWithin(s2, 1))
+	require.True(t, s1.Equals(s1))
+}
+
+func TestToEdgesDropEmptyValues(t *testing.T) {
+	m := Mutation{
+		Set: []*protos.NQuad{
+			{Subject: "0x1", Predicate: "name",
+				ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: ""}}},
+			{Subject: "0x1", Predicate: "nick",
+				ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: "_nil_"}}},
+			{Subject: "0x1", Predicate: "age",
+				ObjectValue: &protos.Value{Val: &protos.Value_IntVal{IntVal: 0}}},
+			{Subject: "0x1", Predicate: "city",
+				ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Sydney"}}},
+		},
+		Del: []*protos.NQuad{
+			{Subject: "0x1", Predicate: "name",
+				ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: ""}}},
+		},
+	}
+
+	edges, err := m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 5, len(edges))
+
+	edges, err = m.ToEdges(nil, ConvertOptions{DropEmptyValues: true})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(edges))
+	require.Equal(t, "age", edges[0].Attr)
+	require.Equal(t, protos.DirectedEdge_SET, edges[0].Op)
+	require.Equal(t, "city", edges[1].Attr)
+	// Explicit deletes are kept.
+	require.Equal(t, "name", edges[2].Attr)
+	require.Equal(t, protos.DirectedEdge_DEL, edges[2].Op)
+}
+
+func TestInferSchema(t *testing.T) {
+	val := func(v interface{}) *protos.Value {
+		switch v := v.(type) {
+		case int:
+			return &protos.Value{Val: &protos.Value_IntVal{IntVal: int64(v)}}
+		case float64:
+			return &protos.Value{Val: &protos.Value_DoubleVal{DoubleVal: v}}
+		case bool:
+			return &protos.Value{Val: &protos.Value_BoolVal{BoolVal: v}}
+		}
+		return nil
+	}
+	nqs := []NQuad{
+		{&protos.NQuad{Subject: "_:a", Predicate: "age", ObjectValue: val(26)}},
+		{&protos.NQuad{Subject: "_:b", Predicate: "age", ObjectValue: val(31)}},
+		{&protos.NQuad{Subject: "_:a", Predicate: "score", ObjectValue: val(3)}},
+		{&protos.NQuad{Subject: "_:b", Predicate: "score", ObjectValue: val(4.5)}},
+		{&protos.NQuad{Subject: "_:a", Predicate: "friend", ObjectId: "_:b"}},
+		{&protos.NQuad{Subject: "_:b", Predicate: "friend", ObjectId: "_:a"}},
+		{&protos.NQuad{Subject: "_:a", Predicate: "misc", ObjectValue: val(true)}},
+		{&protos.NQuad{Subject: "_:b", Predicate: "misc", ObjectValue: val(2)}},
+	}
+	require.Equal(t, map[string]types.TypeID{
+		"age":    types.IntID,
+		"score":  types.FloatID,
+		"friend": types.UidID,
+		"misc":   types.StringID,
+	}, InferSchema(nqs))
+}
+
+func TestLabelDeleteEdge(t *testing.T) {
+	m := Mutation{Del: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "friend", Label: "import-2017"},
+	}}
+	require.True(t, NQuad{m.Del[0]}.IsLabelDelete())
+	edges, err := m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(edges))
+	e := edges[0]
+	require.Equal(t, uint64(1), e.Entity)
+	require.Equal(t, "friend", e.Attr)
+	require.Equal(t, "import-2017", e.Label)
+	require.Equal(t, protos.DirectedEdge_DEL, e.Op)
+	require.Empty(t, e.Value)
+	require.Zero(t, e.ValueId)
+
+	// Only a label isn't enough to set an edge.
+	_, err = Mutation{Set: m.Del}.ToEdges(nil, ConvertOptions{})
+	require.Error(t, err)
+
+	// Without a label, there is nothing to delete.
+	require.False(t, NQuad{&protos.NQuad{Subject: "0x1", Predicate: "friend"}}.IsLabelDelete())
+}
+
+func TestRejectNaNAndInf(t *testing.T) {
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := floatNQuad(v).ToEdgeUsing(map[string]uint64{"_:a": 1})
+		require.Error(t, err, "Expected error for %v", v)
+	}
+
+	edge, err := floatNQuad(2.5).ToEdgeUsing(map[string]uint64{"_:a": 1})
+	require.NoError(t, err)
+	require.Equal(t, types.FloatID.Enum(), edge.ValueType)
+}
+
+func TestToEdgesFacetOrder(t *testing.T) {
+	var fs []*protos.Facet
+	for _, key := range []string{"since", "close", "weight"} {
+		f, err := facets.FacetFor(key, "1")
+		require.NoError(t, err)
+		fs = append(fs, f)
+	}
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x2", Facets: fs},
+	}}
+	keys := func(edge *protos.DirectedEdge) []string {
+		var out []string
+		for _, f := range edge.Facets {
+			out = append(out, f.Key)
+		}
+		return out
+	}
+
+	edges, err := m.ToEdges(nil, ConvertOptions{FacetOrder: FacetsAsGiven})
+	require.NoError(t, err)
+	require.Equal(t, []string{"since", "close", "weight"}, keys(edges[0]))
+	// Sorting the facets of the NQuad later doesn't reorder the edge.
+	require.NoError(t, facets.SortAndValidate(m.Set[0].Facets))
+	require.Equal(t, []string{"since", "close", "weight"}, keys(edges[0]))
+
+	m.Set[0].Facets = []*protos.Facet{fs[2], fs[0], fs[1]}
+	edges, err = m.ToEdges(nil, ConvertOptions{FacetOrder: FacetsSorted})
+	require.NoError(t, err)
+	require.Equal(t, []string{"close", "since", "weight"}, keys(edges[0]))
+	require.Equal(t, "weight", m.Set[0].Facets[0].Key)
+
+	m.Set[0].Facets = []*protos.Facet{fs[0], fs[0]}
+	_, err = m.ToEdges(nil, ConvertOptions{FacetOrder: FacetsSorted})
+	require.Error(t, err)
+}
+
+func TestValidateFacetKey(t *testing.T) {
+	for _, key := range []string{"since", "_line", "créé", "last-seen.at2", CreatedAtFacet} {
+		require.NoError(t, ValidateFacetKey(key), key)
+	}
+	for _, key := range []string{"", "2nd", ".since", "has space", "a=b", "a(b)",
+		strings.Repeat("k", MaxFacetKeyLength+1)} {
+		err := ValidateFacetKey(key)
+		require.Error(t, err, key)
+	}
+	err := ValidateFacetKey("dgraph.source")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "reserved")
+}
+
+func TestToEdgesFacetKeys(t *testing.T) {
+	f, err := facets.FacetFor("dgraph.source", `"import"`)
+	require.NoError(t, err)
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x2", Facets: []*protos.Facet{f}},
+	}}
+	_, err = m.ToEdges(nil, ConvertOptions{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "reserved")
+
+	edges, err := m.ToEdges(nil, ConvertOptions{InternalFacets: true})
+	require.NoError(t, err)
+	require.Equal(t, "dgraph.source", edges[0].Facets[0].Key)
+
+	m.Set[0].Facets[0].Key = "bad key"
+	_, err = m.ToEdges(nil, ConvertOptions{InternalFacets: true})
+	require.Error(t, err)
+}
+
+func TestGetUid(t *testing.T) {
+	uid, err := GetUid("0x1f")
+	require.NoError(t, err)
+	require.Equal(t, uint64(31), uid)
+
+	uid, err = GetUid("alice")
+	require.NoError(t, err)
+	again, err := GetUid("alice")
+	require.NoError(t, err)
+	require.Equal(t, uid, again)
+	other, err := GetUid("_:alice")
+	require.NoError(t, err)
+	require.NotEqual(t, uid, other)
+
+	_, err = GetUid("0x0")
+	require.Equal(t, ErrInvalidUID, err)
+	_, err = GetUid("")
+	require.Error(t, err)
+}
+
+func TestGetUidOutside(t *testing.T) {
+	_, err := GetUidOutside("0x63", 100)
+	require.Equal(t, ErrReservedUID, err)
+
+	uid, err := GetUidOutside("100", 100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), uid)
+
+	uid, err = GetUidOutside("0x100", 100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(256), uid)
+
+	// Other xids map to their fingerprint, as with GetUid.
+	uid, err = GetUidOutside("_:alice", 100)
+	require.NoError(t, err)
+	require.Equal(t, FingerprintXid("_:alice"), uid)
+
+	_, err = GetUidOutside("0x0", 100)
+	require.Equal(t, ErrInvalidUID, err)
+}
+
+func TestGetUidFolded(t *testing.T) {
+	upper, err := GetUid("Alice@dgraph.io")
+	require.NoError(t, err)
+	lower, err := GetUid("alice@dgraph.io")
+	require.NoError(t, err)
+	require.NotEqual(t, upper, lower)
+
+	upper, err = GetUidFolded("Alice@dgraph.io")
+	require.NoError(t, err)
+	require.Equal(t, lower, upper)
+
+	uid, err := GetUidFolded("0X1F")
+	require.NoError(t, err)
+	require.Equal(t, uint64(31), uid)
+}
+
+func TestToEdgesFoldXidCase(t *testing.T) {
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "Alice@dgraph.io", Predicate: "friend", ObjectId: "bob@dgraph.io"},
+		{Subject: "alice@dgraph.io", Predicate: "friend", ObjectId: "BOB@dgraph.io"},
+		{Subject: "0x1", Predicate: "friend", ObjectId: "Bob@Dgraph.io"},
+		{Subject: "_:Carol", Predicate: "friend", ObjectId: "bob@dgraph.io"},
+	}}
+	newToUid := map[string]uint64{"alice@dgraph.io": 10, "bob@dgraph.io": 11, "_:Carol": 12}
+	edges, err := m.ToEdges(newToUid, ConvertOptions{FoldXidCase: true})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), edges[0].Entity)
+	require.Equal(t, uint64(10), edges[1].Entity)
+	require.Equal(t, uint64(1), edges[2].Entity)
+	// Blank nodes aren't folded.
+	require.Equal(t, uint64(12), edges[3].Entity)
+	for _, edge := range edges {
+		require.Equal(t, uint64(11), edge.ValueId)
+	}
+
+	// Without the option, the mixed case xids are other nodes.
+	_, err = m.ToEdges(newToUid, ConvertOptions{})
+	require.Error(t, err)
+}
+
+func TestFingerprintXid(t *testing.T) {
+	// These pin the algorithm. If they change, uids computed by clients stop
+	// matching the ones in existing data.
+	tests := []struct {
+		xid string
+		uid uint64
+	}{
+		{"alice", 10125359828081617157},
+		{"_:alice", 15022922162993593006},
+		{"http://dgraph.io/bob", 10326563659694549870},
+		{"名前", 2175420799749915521},
+	}
+	for _, tc := range tests {
+		require.Equal(t, tc.uid, FingerprintXid(tc.xid), "for xid %q", tc.xid)
+		uid, err := GetUid(tc.xid)
+		require.NoError(t, err)
+		require.Equal(t, tc.uid, uid)
+	}
+	// Numeric xids are fingerprinted too, while GetUid parses them.
+	require.NotEqual(t, uint64(31), FingerprintXid("0x1f"))
+}
+
+func TestFanOut(t *testing.T) {
+	var nqs []NQuad
+	add := func(subject string, n int) {
+		for i := 0; i < n; i++ {
+			nqs = append(nqs, NQuad{&protos.NQuad{
+				Subject:   subject,
+				Predicate: "friend",
+				ObjectId:  fmt.Sprintf("_:f%d", i),
+			}})
+		}
+	}
+	add("_:alice", 3)
+	add("0x2", 1)
+	add("bob", 5)
+	add("_:alice", 2)
+	nqs = append(nqs, NQuad{&protos.NQuad{SubjectVar: "a", Predicate: "friend", ObjectId: "0x2"}})
+
+	expected := make(map[uint64]int)
+	for subject, n := range map[string]int{"_:alice": 5, "0x2": 1, "bob": 5} {
+		uid, err := GetUid(subject)
+		require.NoError(t, err)
+		expected[uid] = n
+	}
+	require.Equal(t, expected, FanOut(nqs))
+	require.Equal(t, 1, FanOut(nqs)[2])
+}
+
+func TestToEdgesDetectTypePrefix(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "loc", ObjectValue: str(`geo:{"type":"Point","coordinates":[1.5,2.5]}`)},
+		{Subject: "0x1", Predicate: "born", ObjectValue: str("dt:2017-03-04")},
+		{Subject: "0x1", Predicate: "url", ObjectValue: str("http://dgraph.io")},
+	}}
+
+	edges, err := m.ToEdges(nil, ConvertOptions{DetectTypePrefix: true})
+	require.NoError(t, err)
+	require.Equal(t, types.GeoID.Enum(), edges[0].ValueType)
+	g, err := types.Convert(types.Val{Tid: types.GeoID, Value: edges[0].Value}, types.GeoID)
+	require.NoError(t, err)
+	require.Equal(t, geom.Coord{1.5, 2.5}, g.Value.(*geom.Point).Coords())
+
+	require.Equal(t, types.DateTimeID.Enum(), edges[1].ValueType)
+	require.Equal(t, types.StringID.Enum(), edges[2].ValueType)
+	require.Equal(t, "http://dgraph.io", string(edges[2].Value))
+	// The NQuads of the mutation are left as they are.
+	require.Equal(t, "dt:2017-03-04", m.Set[1].ObjectValue.GetStrVal())
+
+	// Without the option, the prefix is kept.
+	edges, err = m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, types.StringID.Enum(), edges[1].ValueType)
+
+	m.Set = []*protos.NQuad{{Subject: "0x1", Predicate: "born", ObjectValue: str("dt:yesterday")}}
+	_, err = m.ToEdges(nil, ConvertOptions{DetectTypePrefix: true})
+	require.Error(t, err)
+}
+
+func contentTestNQuads() []NQuad {
+	name := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	return []NQuad{
+		{&protos.NQuad{Subject: "_:alice", Predicate: "name", ObjectValue: name("Alice")}},
+		{&protos.NQuad{Subject: "_:bob", Predicate: "name", ObjectValue: name("Bob")}},
+		{&protos.NQuad{Subject: "_:alice", Predicate: "friend", ObjectId: "_:bob"}},
+		{&protos.NQuad{Subject: "0x5", Predicate: "friend", ObjectId: "_:carol"}},
+		{&protos.NQuad{Subject: "_:bob2", Predicate: "name", ObjectValue: name("Bob")}},
+	}
+}
+
+func TestAssignContentUids(t *testing.T) {
+	first := AssignContentUids("test", contentTestNQuads())
+	second := AssignContentUids("test", contentTestNQuads())
+	require.Equal(t, first, second)
+	require.Equal(t, 4, len(first))
+	require.NotContains(t, first, "0x5")
+
+	seen := make(map[uint64]bool)
+	for _, uid := range first {
+		require.NotZero(t, uid)
+		require.False(t, seen[uid], "Duplicate uid %d", uid)
+		seen[uid] = true
+	}
+
+	// A different namespace gives different uids.
+	other := AssignContentUids("other", contentTestNQuads())
+	require.NotEqual(t, first["_:alice"], other["_:alice"])
+
+	edges, err := Mutation{Set: []*protos.NQuad{contentTestNQuads()[2].NQuad}}.
+		ToEdges(first, ConvertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, first["_:alice"], edges[0].Entity)
+	require.Equal(t, first["_:bob"], edges[0].ValueId)
+}
+
+func TestAssignContentUidsValueType(t *testing.T) {
+	value := func(v *protos.Value) []NQuad {
+		return []NQuad{{&protos.NQuad{Subject: "_:a", Predicate: "code", ObjectValue: v}}}
+	}
+	str := AssignContentUids("test", value(&protos.Value{Val: &protos.Value_StrVal{StrVal: "7"}}))
+	def := AssignContentUids("test",
+		value(&protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: "7"}}))
+	num := AssignContentUids("test", value(&protos.Value{Val: &protos.Value_IntVal{IntVal: 7}}))
+	require.NotEqual(t, str["_:a"], def["_:a"])
+	require.NotEqual(t, str["_:a"], num["_:a"])
+
+	// The uid depends on the stored bytes, not on the text form of the value.
+	require.Equal(t, "2\x00\x07\x00\x00\x00\x00\x00\x00\x00@",
+		contentOf(value(&protos.Value{Val: &protos.Value_IntVal{IntVal: 7}})[0]))
+}
+
+func TestGroupByPredicate(t *testing.T) {
+	var edges []*protos.DirectedEdge
+	for i, attr := range []string{"name", "friend", "name", "age", "friend", "name"} {
+		edges = append(edges, &protos.DirectedEdge{Entity: uint64(i + 1), Attr: attr})
+	}
+	entities := func(edges []*protos.DirectedEdge) []uint64 {
+		var out []uint64
+		for _, e := range edges {
+			out = append(out, e.Entity)
+		}
+		return out
+	}
+
+	groups := GroupByPredicate(edges)
+	require.Equal(t, 3, len(groups))
+	require.Equal(t, []uint64{1, 3, 6}, entities(groups["name"]))
+	require.Equal(t, []uint64{2, 5}, entities(groups["friend"]))
+	require.Equal(t, []uint64{4}, entities(groups["age"]))
+
+	require.Equal(t, []uint64{1, 3, 6, 2, 5, 4}, entities(SortByPredicate(edges)))
+	require.Empty(t, SortByPredicate(nil))
+}
+
+func TestValidateMaxValueBytes(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	opts := ValidateOptions{MaxValueBytes: 5}
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "name", ObjectValue: str("Alice")},
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"},
+	}}
+	require.NoError(t, m.Validate(opts))
+
+	m.Set = append(m.Set, &protos.NQuad{Subject: "0x1", Predicate: "bio", ObjectValue: str("Hello!")})
+	err := m.Validate(opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "index 2")
+	require.Contains(t, err.Error(), "bio")
+	require.Contains(t, err.Error(), "6 bytes")
+
+	// Zero means unlimited.
+	require.NoError(t, m.Validate(ValidateOptions{}))
+}
+
+func TestValidateDeleteWithoutObject(t *testing.T) {
+	m := Mutation{Del: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"},
+		{Subject: "0x1", Predicate: "friend"},
+	}}
+	err := m.Validate(ValidateOptions{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "delete nquad at index 1")
+	require.Contains(t, err.Error(), "ambiguous")
+
+	// Deleting all the objects has to be asked for with a star.
+	m.Del[1].ObjectValue = &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: x.Star}}
+	require.NoError(t, m.Validate(ValidateOptions{}))
+
+	// Label deletes and deletes of variables name what they delete.
+	m.Del = append(m.Del,
+		&protos.NQuad{Subject: "0x1", Predicate: "friend", Label: "import"},
+		&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectVar: "f"})
+	require.NoError(t, m.Validate(ValidateOptions{}))
+}
+
+func TestValidateRatios(t *testing.T) {
+	opts := ValidateOptions{Ratios: map[string]bool{"share": true}}
+	double := func(f float64) *protos.Value {
+		return &protos.Value{Val: &protos.Value_DoubleVal{DoubleVal: f}}
+	}
+	for _, val := range []*protos.Value{
+		double(0), double(1), double(0.5),
+		{Val: &protos.Value_IntVal{IntVal: 1}},
+		{Val: &protos.Value_DefaultVal{DefaultVal: "0.25"}},
+	} {
+		m := Mutation{Set: []*protos.NQuad{{Subject: "0x1", Predicate: "share", ObjectValue: val}}}
+		require.NoError(t, m.Validate(opts), "%v", val)
+	}
+	for _, val := range []*protos.Value{
+		double(-0.1), double(1.1), double(math.NaN()),
+		{Val: &protos.Value_StrVal{StrVal: "half"}},
+		{Val: &protos.Value_BoolVal{BoolVal: true}},
+	} {
+		m := Mutation{Set: []*protos.NQuad{{Subject: "0x1", Predicate: "share", ObjectValue: val}}}
+		err := m.Validate(opts)
+		require.Error(t, err, "%v", val)
+		require.Contains(t, err.Error(), "share")
+	}
+
+	// Other predicates and deletes of all the values aren't checked.
+	m := Mutation{
+		Set: []*protos.NQuad{{Subject: "0x1", Predicate: "score", ObjectValue: double(1.1)}},
+		Del: []*protos.NQuad{{Subject: "0x1", Predicate: "share",
+			ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: x.Star}}}},
+	}
+	require.NoError(t, m.Validate(opts))
+}
+
+func TestPredicateACL(t *testing.T) {
+	opts := ConvertOptions{ACL: PredicateACL{Deny: []string{"password"}}}
+	nq := &protos.NQuad{
+		Subject:     "0x1",
+		Predicate:   "password",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "secret"}},
+	}
+	m := Mutation{Set: []*protos.NQuad{nq}}
+	_, err := m.ToEdges(nil, opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "denied")
+
+	nq.Predicate = "name"
+	edges, err := m.ToEdges(nil, opts)
+	require.NoError(t, err)
+	require.Equal(t, "name", edges[0].Attr)
+
+	nq.Predicate = "password"
+	_, err = m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+
+	// Single NQuads are checked the same way.
+	_, err = opts.ACL.ToEdgeUsing(NQuad{nq}, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "denied")
+	_, err = opts.ACL.ToDeleteEdgeUsing(NQuad{nq}, nil)
+	require.Error(t, err)
+	_, err = NQuad{nq}.ToEdgeUsing(nil)
+	require.NoError(t, err)
+}
+
+func TestPredicateACLAllow(t *testing.T) {
+	opts := ConvertOptions{ACL: PredicateACL{Allow: []string{"name"}}}
+	nq := &protos.NQuad{
+		Subject:     "0x1",
+		Predicate:   "name",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}},
+	}
+	m := Mutation{Set: []*protos.NQuad{nq}}
+	_, err := m.ToEdges(nil, opts)
+	require.NoError(t, err)
+
+	nq.Predicate = "age"
+	_, err = m.ToEdges(nil, opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "isn't allowed")
+
+	m = Mutation{Del: []*protos.NQuad{{Subject: "0x1", Predicate: x.Star,
+		ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: x.Star}}}}}
+	_, err = m.ToEdges(nil, opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "isn't allowed")
+}
+
+func TestPredicateACLReverse(t *testing.T) {
+	acl := PredicateACL{Deny: []string{"owner"}}
+	err := acl.Check("~owner")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "~owner")
+
+	m := Mutation{Set: []*protos.NQuad{{Subject: "0x2", Predicate: "~owner", ObjectId: "0x1"}}}
+	_, err = m.ToEdges(nil, ConvertOptions{ACL: acl})
+	require.Error(t, err)
+}
+
+func TestLangWithObjectId(t *testing.T) {
+	nq := NQuad{&protos.NQuad{
+		Subject:   "0x1",
+		Predicate: "name",
+		Lang:      "en",
+		ObjectId:  "_:bob",
+	}}
+	resolved := false
+	_, err := nq.toEdge(func(xid string) (uint64, error) {
+		resolved = true
+		return 1, nil
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "language en")
+	require.False(t, resolved)
+	require.Error(t, Mutation{Set: []*protos.NQuad{nq.NQuad}}.Validate(ValidateOptions{}))
+
+	nq.ObjectId = ""
+	nq.ObjectValue = &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}
+	edge, err := nq.ToEdgeUsing(nil)
+	require.NoError(t, err)
+	require.Equal(t, "en", edge.Lang)
+	require.NoError(t, Mutation{Set: []*protos.NQuad{nq.NQuad}}.Validate(ValidateOptions{}))
+}
+
+func TestJsonValue(t *testing.T) {
+	jsonVal := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_JsonVal{JsonVal: []byte(s)}}
+	}
+	for _, in := range []string{`{"tags": ["a", "b"]}`, `[{"x": 1}, 2]`} {
+		nq := NQuad{&protos.NQuad{Subject: "0x1", Predicate: "meta", ObjectValue: jsonVal(in)}}
+		edge, err := nq.ToEdgeUsing(nil)
+		require.NoError(t, err)
+		require.Equal(t, in, string(edge.Value))
+		require.Equal(t, types.JsonID.Enum(), edge.ValueType)
+	}
+
+	nq := NQuad{&protos.NQuad{Subject: "0x1", Predicate: "meta", ObjectValue: jsonVal(`{"tags": [`)}}
+	_, err := nq.ToEdgeUsing(nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "meta")
+}
+
+func TestMutationClone(t *testing.T) {
+	since, err := facets.FacetFor("since", "2006")
+	require.NoError(t, err)
+	// Leave spare capacity, so that appending to a shared slice wouldn't reallocate.
+	fs := make([]*protos.Facet, 1, 4)
+	fs[0] = since
+	m := Mutation{
+		Set: []*protos.NQuad{{
+			Subject:     "0x1",
+			Predicate:   "name",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}},
+			Facets:      fs,
+		}},
+		Del:    []*protos.NQuad{{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"}},
+		Schema: "name: string .",
+	}
+
+	c := m.Clone()
+	require.True(t, NQuad{m.Set[0]}.Equals(NQuad{c.Set[0]}))
+	require.True(t, NQuad{m.Del[0]}.Equals(NQuad{c.Del[0]}))
+	require.Equal(t, m.Schema, c.Schema)
+
+	extra, err := facets.FacetFor("close", "true")
+	require.NoError(t, err)
+	c.Set[0].Facets = append(c.Set[0].Facets, extra)
+	c.Set[0].Facets[0].Key = "until"
+	c.Set[0].ObjectValue.Val = &protos.Value_StrVal{StrVal: "Bob"}
+	c.Del[0].ObjectId = "0x3"
+	c.Set = append(c.Set, &protos.NQuad{Subject: "0x4"})
+
+	require.Equal(t, 1, len(m.Set))
+	require.Equal(t, 1, len(m.Set[0].Facets))
+	require.Equal(t, "since", fs[0].Key)
+	require.Nil(t, fs[:2][1])
+	require.Equal(t, "Alice", m.Set[0].ObjectValue.GetStrVal())
+	require.Equal(t, "0x2", m.Del[0].ObjectId)
+}
+
+func TestSuggestIndexes(t *testing.T) {
+	m := Mutation{Schema: `
+		name: string @index(term) .
+		email: string .
+		age: int .
+		born: dateTime @index(day) .
+		score: float .
+		bio: string .
+	`}
+	updates, err := m.SuggestIndexes(map[string][]string{
+		"name":  {"eq", "allofterms"},
+		"email": {"eq"},
+		"age":   {"lt", "eq"},
+		"born":  {"ge"},
+		"score": {"gt"},
+		"bio":   {"anyoftext", "regexp", "has"},
+		"alias": {"eq", "le"},
+	})
+	require.NoError(t, err)
+	got := make(map[string][]string)
+	for _, u := range updates {
+		require.Equal(t, protos.SchemaUpdate_INDEX, u.Directive)
+		got[u.Predicate] = u.Tokenizer
+	}
+	require.Equal(t, map[string][]string{
+		// The term index is kept.
+		"name":  {"hash", "term"},
+		"email": {"hash"},
+		"age":   {"int"},
+		"score": {"float"},
+		"bio":   {"fulltext", "trigram"},
+		// Untyped values compared with an inequality need exact, which does eq too.
+		"alias": {"exact"},
+	}, got)
+	require.Equal(t, "age", updates[0].Predicate)
+	require.Equal(t, types.IntID.Enum(), updates[0].ValueType)
+
+	_, err = Mutation{Schema: "name: string @index(nope) ."}.SuggestIndexes(nil)
+	require.Error(t, err)
+}
+
+func TestSplitByShard(t *testing.T) {
+	str := &protos.Value{Val: &protos.Value_StrVal{StrVal: "x"}}
+	var m Mutation
+	for i := 0; i < 50; i++ {
+		pred := fmt.Sprintf("pred%d", i)
+		m.Set = append(m.Set, &protos.NQuad{Subject: "_:a", Predicate: pred, ObjectValue: str})
+		m.Del = append(m.Del, &protos.NQuad{Subject: "0x1", Predicate: pred, ObjectValue: str})
+		m.Schema += pred + ": string .\n"
+	}
+	m.Set = append(m.Set, &protos.NQuad{Subject: "0x2", Predicate: "~pred3", ObjectId: "0x1"})
+
+	shards := m.SplitByShard(4)
+	require.True(t, len(shards) > 1)
+	seen := make(map[*protos.NQuad]int)
+	schemaLines := 0
+	for i, sm := range shards {
+		for _, nq := range append(sm.Set, sm.Del...) {
+			seen[nq]++
+			require.Equal(t, i, ShardFor(nq.Predicate, 4))
+		}
+		for _, line := range strings.Split(sm.Schema, "\n") {
+			pred := strings.TrimSuffix(line, ": string .")
+			require.Equal(t, i, ShardFor(pred, 4), line)
+			schemaLines++
+		}
+	}
+	require.Equal(t, 101, len(seen))
+	for _, n := range seen {
+		require.Equal(t, 1, n)
+	}
+	require.Equal(t, 50, schemaLines)
+	require.Equal(t, ShardFor("pred3", 4), ShardFor("~pred3", 4))
+
+	// Shard assignment only depends on the predicate and the number of shards.
+	again := m.SplitByShard(4)
+	require.Equal(t, len(shards), len(again))
+	for i, sm := range shards {
+		require.Equal(t, sm.Set, again[i].Set)
+		require.Equal(t, sm.Del, again[i].Del)
+		require.Equal(t, sm.Schema, again[i].Schema)
+	}
+
+	one := m.SplitByShard(1)
+	require.Equal(t, 1, len(one))
+	require.Equal(t, len(m.Set), len(one[0].Set))
+}
+
+func TestSplitByShardStar(t *testing.T) {
+	m := Mutation{
+		Del: []*protos.NQuad{{Subject: "0x1", Predicate: x.Star,
+			ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: x.Star}}}},
+		DropAll: true,
+	}
+	shards := m.SplitByShard(3)
+	require.Equal(t, 3, len(shards))
+	for _, sm := range shards {
+		require.Equal(t, m.Del, sm.Del)
+		require.True(t, sm.DropAll)
+	}
+}
+
+func TestReverseEdgePreview(t *testing.T) {
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"},
+		{Subject: "0x1", Predicate: "boss", ObjectId: "0x3"},
+		{Subject: "0x1", Predicate: "friend", ObjectValue: &protos.Value{
+			Val: &protos.Value_StrVal{StrVal: "nobody"}}},
+		{Subject: "0x4", Predicate: "friend", ObjectIds: []string{"0x5", "0x6"}},
+		{SubjectVar: "a", Predicate: "friend", ObjectId: "0x7"},
+	}, Del: []*protos.NQuad{
+		{Subject: "0x8", Predicate: "friend", ObjectId: "0x9"},
+	}}
+	edges := m.ReverseEdgePreview(map[string]bool{"friend": true})
+	require.Equal(t, 3, len(edges))
+	var got []string
+	for _, e := range edges {
+		require.Equal(t, "~friend", e.Attr)
+		require.Equal(t, protos.DirectedEdge_SET, e.Op)
+		got = append(got, fmt.Sprintf("%#x -> %#x", e.Entity, e.ValueId))
+	}
+	require.Equal(t, []string{"0x2 -> 0x1", "0x5 -> 0x4", "0x6 -> 0x4"}, got)
+
+	require.Empty(t, m.ReverseEdgePreview(nil))
+	require.Empty(t, m.ReverseEdgePreview(map[string]bool{"name": true}))
+}
+
+func TestAssertSingleSubject(t *testing.T) {
+	str := &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}
+	m := Mutation{
+		Set: []*protos.NQuad{
+			{Subject: "_:alice", Predicate: "name", ObjectValue: str},
+			{Subject: "_:alice", Predicate: "friend", ObjectId: "_:bob"},
+		},
+		Del: []*protos.NQuad{{Subject: "_:alice", Predicate: "age", ObjectValue: str}},
+	}
+	uid, err := m.AssertSingleSubject()
+	require.NoError(t, err)
+	want, err := GetUid("_:alice")
+	require.NoError(t, err)
+	require.Equal(t, want, uid)
+
+	m.Del = append(m.Del, &protos.NQuad{Subject: "_:bob", Predicate: "age", ObjectValue: str})
+	_, err = m.AssertSingleSubject()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "delete nquad at index 1")
+
+	_, err = Mutation{Set: []*protos.NQuad{{SubjectVar: "a", Predicate: "name",
+		ObjectValue: str}}}.AssertSingleSubject()
+	require.Error(t, err)
+	_, err = Mutation{}.AssertSingleSubject()
+	require.Error(t, err)
+}
+
+func TestFacetsToMap(t *testing.T) {
+	var fcs []*protos.Facet
+	for _, kv := range [][2]string{{"since", "2006-01-02T15:04:05Z"}, {"count", "12"},
+		{"weight", "1.5"}, {"close", "true"}, {"note", `"old friend"`}} {
+		f, err := facets.FacetFor(kv[0], kv[1])
+		require.NoError(t, err)
+		fcs = append(fcs, f)
+	}
+	m, err := FacetsToMap(fcs)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"since":  time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		"count":  int64(12),
+		"weight": 1.5,
+		"close":  true,
+		"note":   "old friend",
+	}, m)
+
+	m, err = FacetsToMap(nil)
+	require.NoError(t, err)
+	require.Empty(t, m)
+
+	_, err = FacetsToMap(append(fcs, fcs[1]))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "count")
+	_, err = FacetsToMap([]*protos.Facet{{Key: "count", ValType: protos.Facet_INT,
+		Value: []byte{1}}})
+	require.Error(t, err)
+}
+
+func TestValidateMaxRunes(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	opts := ValidateOptions{MaxRunes: map[string]RuneLimit{
+		"title": {Max: 5, Langs: map[string]int{"de": 8}},
+	}}
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "title", ObjectValue: str("héllo")},
+		{Subject: "_:a", Predicate: "title", Lang: "de", ObjectValue: str("Grüße an")},
+		{Subject: "_:a", Predicate: "title", Lang: "fr", ObjectValue: str("salut")},
+		{Subject: "_:a", Predicate: "body", ObjectValue: str("no limit at all")},
+	}}
+	require.NoError(t, m.Validate(opts))
+
+	// The override for de fires.
+	m.Set[1].ObjectValue = str("Grüße an alle")
+	err := m.Validate(opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "index 1")
+	require.Contains(t, err.Error(), "language de")
+	require.Contains(t, err.Error(), "limit of 8")
+	m.Set[1].ObjectValue = str("Grüße")
+
+	// The global limit applies to untagged values and languages without one.
+	m.Set[0].ObjectValue = str("hello!")
+	err = m.Validate(opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "index 0")
+	m.Set[0].ObjectValue = str("hello")
+	m.Set[2].ObjectValue = str("bonjour")
+	err = m.Validate(opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "language fr")
+}
+
+func TestValidateControlChars(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "bio", ObjectValue: str("line one\n\tline two\r\n")},
+		{Subject: "_:a", Predicate: "name", ObjectValue: str("Ali\x00ce")},
+	}}
+	require.NoError(t, m.Validate(ValidateOptions{}))
+	err := m.Validate(ValidateOptions{RejectControlChars: true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "set nquad at index 1")
+	require.Contains(t, err.Error(), "byte 3")
+
+	m.Set = m.Set[:1]
+	require.NoError(t, m.Validate(ValidateOptions{RejectControlChars: true}))
+}
+
+func TestToEdgesStripControlChars(t *testing.T) {
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "name", ObjectValue: &protos.Value{
+			Val: &protos.Value_StrVal{StrVal: "Ali\x00ce\x1b"}}},
+		{Subject: "0x1", Predicate: "bio", ObjectValue: &protos.Value{
+			Val: &protos.Value_DefaultVal{DefaultVal: "clean\tvalue"}}},
+	}}
+	var warnings []Warning
+	edges, err := m.ToEdges(nil, ConvertOptions{
+		StripControlChars: true,
+		Warn:              func(w Warning) { warnings = append(warnings, w) },
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Alice", string(edges[0].Value))
+	require.Equal(t, "clean\tvalue", string(edges[1].Value))
+	require.Equal(t, 1, len(warnings))
+	require.Equal(t, 0, warnings[0].Index)
+	require.Contains(t, warnings[0].Message, "2 control characters")
+	// The NQuad is left as it is.
+	require.Equal(t, "Ali\x00ce\x1b", m.Set[0].ObjectValue.GetStrVal())
+}
+
+func TestCheckSingleValued(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	preds := map[string]bool{"email": true}
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "email", ObjectValue: str("a@dgraph.io")},
+		{Subject: "_:b", Predicate: "email", ObjectValue: str("b@dgraph.io")},
+		{Subject: "_:a", Predicate: "alias", ObjectValue: str("A")},
+		{Subject: "_:a", Predicate: "alias", ObjectValue: str("AA")},
+		{Subject: "_:a", Predicate: "email", Lang: "en", ObjectValue: str("a@dgraph.io")},
+	}}
+	require.NoError(t, CheckSingleValued(m, preds))
+
+	m.Set = append(m.Set, &protos.NQuad{Subject: "_:a", Predicate: "email",
+		ObjectValue: str("other@dgraph.io")})
+	err := CheckSingleValued(m, preds)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "email")
+	require.Contains(t, err.Error(), "index 5")
+}
+
+func TestCheckSingleValuedLang(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	preds := map[string]bool{"name": true}
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "name", Lang: "en", ObjectValue: str("Alice")},
+		{Subject: "_:a", Predicate: "name", Lang: "fr", ObjectValue: str("Alix")},
+	}}
+	require.NoError(t, CheckSingleValued(m, preds))
+
+	m.Set = append(m.Set, &protos.NQuad{Subject: "_:a", Predicate: "name", Lang: "en",
+		ObjectValue: str("Alicia")})
+	err := CheckSingleValued(m, preds)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "language en")
+	require.Contains(t, err.Error(), "index 2")
+}
+
+func TestEncodeSets(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "tags", ObjectValue: str("go")},
+		{Subject: "_:a", Predicate: "name", ObjectValue: str("A")},
+		{Subject: "_:b", Predicate: "tags", ObjectValue: str("rust")},
+		{Subject: "_:a", Predicate: "tags", ObjectValue: str("db")},
+		{Subject: "_:a", Predicate: "tags", ObjectValue: &protos.Value{
+			Val: &protos.Value_DefaultVal{DefaultVal: "go"}}},
+		{Subject: "_:a", Predicate: "topics", ObjectValue: str("x")},
+	}}
+	require.NoError(t, m.EncodeSets(map[string]SetEncoding{
+		"tags":   SetAsSortedValue,
+		"topics": SetAsEdges,
+	}))
+	require.Equal(t, 4, len(m.Set))
+	require.Equal(t, "name", m.Set[1].Predicate)
+	require.Equal(t, "topics", m.Set[3].Predicate)
+
+	a, err := DecodeSortedSet(m.Set[0].ObjectValue.GetBytesVal())
+	require.NoError(t, err)
+	require.Equal(t, []string{"db", "go"}, a)
+	b, err := DecodeSortedSet(m.Set[2].ObjectValue.GetBytesVal())
+	require.NoError(t, err)
+	require.Equal(t, []string{"rust"}, b)
+
+	// The encoding doesn't depend on the order of the values.
+	require.Equal(t, EncodeSortedSet([]string{"go", "db", "go"}),
+		EncodeSortedSet([]string{"db", "go"}))
+	values, err := DecodeSortedSet(EncodeSortedSet(nil))
+	require.NoError(t, err)
+	require.Empty(t, values)
+}
+
+func TestEncodeSetsErrors(t *testing.T) {
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "tags", ObjectValue: &protos.Value{
+			Val: &protos.Value_StrVal{StrVal: "go"}}},
+		{Subject: "_:a", Predicate: "tags", ObjectValue: &protos.Value{
+			Val: &protos.Value_IntVal{IntVal: 1}}},
+	}}
+	err := m.EncodeSets(map[string]SetEncoding{"tags": SetAsSortedValue})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "index 1")
+	require.Equal(t, "go", m.Set[0].ObjectValue.GetStrVal())
+
+	_, err = DecodeSortedSet([]byte{2, 1, 'a'})
+	require.Error(t, err)
+	_, err = DecodeSortedSet([]byte{1, 1, 'a', 'b'})
+	require.Error(t, err)
+}
+
+func TestCoalesceSingleValued(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "email", ObjectValue: str("old@dgraph.io")},
+		{Subject: "_:a", Predicate: "alias", ObjectValue: str("A")},
+		{Subject: "_:b", Predicate: "email", ObjectValue: str("b@dgraph.io")},
+		{Subject: "_:a", Predicate: "email", ObjectValue: str("new@dgraph.io")},
+		{Subject: "_:a", Predicate: "alias", ObjectValue: str("AA")},
+	}}
+	m.CoalesceSingleValued(map[string]bool{"email": true})
+	require.NoError(t, CheckSingleValued(m, map[string]bool{"email": true}))
+
+	var got []string
+	for _, nq := range m.Set {
+		got = append(got, nq.Subject+" "+nq.Predicate+" "+nq.ObjectValue.GetStrVal())
+	}
+	require.Equal(t, []string{
+		"_:a alias A",
+		"_:b email b@dgraph.io",
+		"_:a email new@dgraph.io",
+		"_:a alias AA",
+	}, got)
+}
+
+func TestCoalesceSingleValuedLang(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "name", Lang: "en", ObjectValue: str("Alice")},
+		{Subject: "_:a", Predicate: "name", Lang: "fr", ObjectValue: str("Alix")},
+		{Subject: "_:a", Predicate: "name", ObjectValue: str("A")},
+		{Subject: "_:a", Predicate: "name", Lang: "en", ObjectValue: str("Alicia")},
+	}}
+	m.CoalesceSingleValued(map[string]bool{"name": true})
+	require.NoError(t, CheckSingleValued(m, map[string]bool{"name": true}))
+
+	var got []string
+	for _, nq := range m.Set {
+		got = append(got, nq.Lang+" "+nq.ObjectValue.GetStrVal())
+	}
+	require.Equal(t, []string{"fr Alix", " A", "en Alicia"}, got)
+}
+
+func TestGroupLangValues(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "name", Lang: "en", ObjectValue: str("Alice")},
+		{Subject: "_:a", Predicate: "friend", ObjectId: "_:b"},
+		{Subject: "_:a", Predicate: "name", Lang: "fr", ObjectValue: str("Alix")},
+		{Subject: "_:b", Predicate: "name", ObjectValue: str("Bob")},
+		{Subject: "_:a", Predicate: "name", ObjectValue: str("A")},
+		{Subject: "_:a", Predicate: "name", Lang: "ru", ObjectValue: str("Алиса")},
+	}}
+	groups := GroupLangValues(m)
+	require.Equal(t, 2, len(groups))
+	require.Equal(t, map[string]*protos.Value{
+		"en": str("Alice"),
+		"fr": str("Alix"),
+		"ru": str("Алиса"),
+		"":   str("A"),
+	}, groups[SubjectPredicate{Subject: "_:a", Predicate: "name"}])
+	require.Equal(t, map[string]*protos.Value{"": str("Bob")},
+		groups[SubjectPredicate{Subject: "_:b", Predicate: "name"}])
+}
+
+func TestDiffAgainst(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	age := func(i int64) *protos.Value {
+		return &protos.Value{Val: &protos.Value_IntVal{IntVal: i}}
+	}
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "name", ObjectValue: str("Alice")},
+		{Subject: "0x1", Predicate: "age", ObjectValue: age(31)},
+		{Subject: "0x1", Predicate: "name", Lang: "fr", ObjectValue: str("Alice")},
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"},
+		{Subject: "0x2", Predicate: "name", ObjectValue: str("Bob")},
+	}}
+	m.DiffAgainst(map[string]map[string]*protos.Value{
+		"0x1": {"name": str("Alice"), "age": age(30), "name@fr": str("Alice")},
+		"0x2": {"name": str("Robert")},
+	})
+
+	var got []string
+	for _, nq := range m.Set {
+		got = append(got, nq.Subject+" "+nq.Predicate)
+	}
+	// The redundant sets of name are dropped, the changed age and name kept.
+	require.Equal(t, []string{"0x1 age", "0x1 friend", "0x2 name"}, got)
+}
+
+func TestDiffAgainstTypes(t *testing.T) {
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "age",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "31"}}},
+	}}
+	// A value of another type is not the same value.
+	m.DiffAgainst(map[string]map[string]*protos.Value{
+		"0x1": {"age": {Val: &protos.Value_IntVal{IntVal: 31}}},
+	})
+	require.Equal(t, 1, len(m.Set))
+}
+
+func TestDiffAgainstIncrement(t *testing.T) {
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "views", Increment: true,
+			ObjectValue: &protos.Value{Val: &protos.Value_IntVal{IntVal: 3}}},
+	}}
+	// The delta happens to equal the current value, which it still adds to.
+	m.DiffAgainst(map[string]map[string]*protos.Value{
+		"0x1": {"views": {Val: &protos.Value_IntVal{IntVal: 3}}},
+	})
+	require.Equal(t, 1, len(m.Set))
+}
+
+func TestByteValChecksEncodedValues(t *testing.T) {
+	point, err := wkb.Marshal(geom.NewPoint(geom.XY).MustSetCoords(geom.Coord{1, 2}),
+		binary.LittleEndian)
+	require.NoError(t, err)
+	when, err := time.Date(2017, 11, 2, 0, 0, 0, 0, time.UTC).MarshalBinary()
+	require.NoError(t, err)
+	nquad := func(val *protos.Value) NQuad {
+		return NQuad{&protos.NQuad{Subject: "_:a", Predicate: "p", ObjectValue: val}}
+	}
+
+	valid := []*protos.Value{
+		{Val: &protos.Value_GeoVal{GeoVal: point}},
+		{Val: &protos.Value_DatetimeVal{DatetimeVal: when}},
+		{Val: &protos.Value_DateVal{DateVal: when}},
+	}
+	for _, val := range valid {
+		b, tid, err := byteVal(nquad(val))
+		require.NoError(t, err, "%v", val)
+		require.NotEmpty(t, b)
+		require.Contains(t, []types.TypeID{types.GeoID, types.DateTimeID}, tid)
+	}
+
+	corrupt := []*protos.Value{
+		{Val: &protos.Value_GeoVal{GeoVal: point[:len(point)-3]}},
+		{Val: &protos.Value_GeoVal{GeoVal: []byte("POINT(1 2)")}},
+		{Val: &protos.Value_DatetimeVal{DatetimeVal: when[:4]}},
+		{Val: &protos.Value_DateVal{DateVal: []byte{0xff}}},
+	}
+	for _, val := range corrupt {
+		_, _, err := byteVal(nquad(val))
+		require.Error(t, err, "%v", val)
+		_, err = nquad(val).ToEdgeUsing(map[string]uint64{"_:a": 1})
+		require.Error(t, err, "%v", val)
+	}
+}
+
+func TestMarshalCheck(t *testing.T) {
+	point, err := wkb.Marshal(geom.NewPoint(geom.XY).MustSetCoords(geom.Coord{1, 2}),
+		binary.LittleEndian)
+	require.NoError(t, err)
+	geo := func(b []byte) *protos.Value {
+		return &protos.Value{Val: &protos.Value_GeoVal{GeoVal: b}}
+	}
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "name",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "a"}}},
+		{Subject: "_:a", Predicate: "loc", ObjectValue: geo(point)},
+		{Subject: "_:a", Predicate: "friend", ObjectId: "_:b"},
+		{Subject: "_:b", Predicate: "loc", ObjectValue: geo([]byte{1, 2, 3})},
+		{Subject: "_:b", Predicate: "age",
+			ObjectValue: &protos.Value{Val: &protos.Value_IntVal{IntVal: 3}}},
+	}}
+	err = m.MarshalCheck()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Values of 1 nquads")
+	require.Contains(t, err.Error(), "set nquad at index 3")
+
+	m.Set = append(m.Set[:3], m.Set[4])
+	require.NoError(t, m.MarshalCheck())
+
+	m.Del = []*protos.NQuad{{Subject: "_:a", Predicate: "score",
+		ObjectValue: &protos.Value{Val: &protos.Value_DoubleVal{DoubleVal: math.NaN()}}}}
+	err = m.MarshalCheck()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "delete nquad at index 0")
+	m.Del[0].ObjectValue = &protos.Value{Val: &protos.Value_DoubleVal{DoubleVal: math.Inf(-1)}}
+	require.Error(t, m.MarshalCheck())
+}
+
+func TestCreateWeightedEdge(t *testing.T) {
+	since, err := facets.FacetFor("since", "2006")
+	require.NoError(t, err)
+	nq := NQuad{&protos.NQuad{
+		Subject:   "_:a",
+		Predicate: "road",
+		ObjectId:  "_:b",
+		Facets:    []*protos.Facet{since},
+	}}
+	newToUid := map[string]uint64{"_:a": 1, "_:b": 2}
+	edge, err := nq.CreateWeightedEdge(1, newToUid, 2.5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), edge.ValueId)
+	require.Equal(t, 2, len(edge.Facets))
+	require.Equal(t, "since", edge.Facets[0].Key)
+	require.Equal(t, WeightFacet, edge.Facets[1].Key)
+	require.Equal(t, protos.Facet_FLOAT, edge.Facets[1].ValType)
+	require.Equal(t, 2.5, facets.ValFor(edge.Facets[1]).Value)
+	require.Equal(t, 1, len(nq.Facets))
+
+	_, err = nq.CreateWeightedEdge(1, newToUid, math.NaN())
+	require.Error(t, err)
+	_, err = nq.CreateWeightedEdge(1, newToUid, math.Inf(-1))
+	require.Error(t, err)
+
+	w, err := facets.FloatFacet(WeightFacet, 1)
+	require.NoError(t, err)
+	nq.Facets = append(nq.Facets, w)
+	_, err = nq.CreateWeightedEdge(1, newToUid, 2.5)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "reserved")
+}
+
+func TestExpandObjectIds(t *testing.T) {
+	nq := NQuad{&protos.NQuad{
+		Subject:   "_:a",
+		Predicate: "member",
+		ObjectIds: []string{"_:b", "0x3", "_:c"},
+	}}
+	newToUid := map[string]uint64{"_:a": 1, "_:b": 2, "_:c": 4}
+	nquads, err := nq.ExpandObjectIds()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(nquads))
+	for i, uid := range []uint64{2, 3, 4} {
+		edge, err := nquads[i].ToEdgeUsing(newToUid)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), edge.Entity)
+		require.Equal(t, "member", edge.Attr)
+		require.Equal(t, uid, edge.ValueId)
+	}
+	require.Equal(t, 3, len(nq.ObjectIds))
+
+	_, err = nq.ToEdgeUsing(newToUid)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "ExpandObjectIds")
+
+	m := Mutation{Set: []*protos.NQuad{nq.NQuad}}
+	edges, err := m.ToEdges(newToUid, ConvertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(edges))
+	require.Equal(t, uint64(3), edges[1].ValueId)
+
+	nq.ObjectId = "_:d"
+	_, err = nq.ExpandObjectIds()
+	require.Error(t, err)
+}
+
+func TestCreateEdgesEmptyObjectIds(t *testing.T) {
+	nq := NQuad{&protos.NQuad{
+		Subject:   "_:a",
+		Predicate: "member",
+		ObjectIds: []string{},
+	}}
+	nquads, err := nq.ExpandObjectIds()
+	require.NoError(t, err)
+	require.Empty(t, nquads)
+
+	m := Mutation{Set: []*protos.NQuad{nq.NQuad}}
+	edges, err := m.ToEdges(map[string]uint64{"_:a": 1}, ConvertOptions{})
+	require.NoError(t, err)
+	require.Empty(t, edges)
+}
+
+func listNQuad(n int) NQuad {
+	nq := NQuad{&protos.NQuad{Subject: "0x1", Predicate: "member"}}
+	for i := 0; i < n; i++ {
+		nq.ObjectIds = append(nq.ObjectIds, fmt.Sprintf("%#x", i+2))
+	}
+	return nq
+}
+
+func TestExpandSubjectVar(t *testing.T) {
+	nq := NQuad{&protos.NQuad{SubjectVar: "a", Predicate: "friend", ObjectId: "0x9"}}
+	uids := []uint64{5, 2, 7, 3}
+	subjects := func(edges []*protos.DirectedEdge) []uint64 {
+		var out []uint64
+		for _, e := range edges {
+			require.Equal(t, uint64(9), e.ValueId)
+			out = append(out, e.Entity)
+		}
+		return out
+	}
+
+	edges, err := ExpandSubjectVar(nq, uids, false)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5, 2, 7, 3}, subjects(edges))
+
+	edges, err = ExpandSubjectVar(nq, uids, true)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2, 3, 5, 7}, subjects(edges))
+	// The uids given are left as they are.
+	require.Equal(t, []uint64{5, 2, 7, 3}, uids)
+
+	shuffled, err := ExpandSubjectVar(nq, []uint64{7, 3, 2, 5}, true)
+	require.NoError(t, err)
+	require.Equal(t, edges, shuffled)
+
+	nq.ObjectVar, nq.ObjectId = "b", ""
+	_, err = ExpandSubjectVar(nq, uids, true)
+	require.Error(t, err)
+}
+
+func TestExpandListStream(t *testing.T) {
+	out := make(chan *protos.DirectedEdge)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ExpandListStream(context.Background(), listNQuad(1000), 1, out)
+		close(out)
+	}()
+	count := 0
+	for edge := range out {
+		count++
+		require.Equal(t, uint64(1), edge.Entity)
+		require.Equal(t, uint64(count+1), edge.ValueId)
+	}
+	require.NoError(t, <-errCh)
+	require.Equal(t, 1000, count)
+}
+
+func TestExpandListStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan *protos.DirectedEdge)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ExpandListStream(ctx, listNQuad(1000), 1, out)
+	}()
+	for i := 0; i < 10; i++ {
+		<-out
+	}
+	// The sender stops once nobody reads and the context is done.
+	cancel()
+	require.Equal(t, context.Canceled, <-errCh)
+}
+
+func TestCreateEdgeDefaultLang(t *testing.T) {
+	nq := NQuad{&protos.NQuad{
+		Subject:     "_:a",
+		Predicate:   "name",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}},
+	}}
+	newToUid := map[string]uint64{"_:a": 1}
+	missing, err := nq.createEdge(1, newToUid)
+	require.NoError(t, err)
+	require.False(t, missing.DefaultLang)
+
+	nq.DefaultLang = true
+	untagged, err := nq.createEdge(1, newToUid)
+	require.NoError(t, err)
+	require.True(t, untagged.DefaultLang)
+	require.Empty(t, untagged.Lang)
+	require.Equal(t, missing.Value, untagged.Value)
+	require.NotEqual(t, missing, untagged)
+
+	edge, err := nq.ToEdgeUsing(newToUid)
+	require.NoError(t, err)
+	require.True(t, edge.DefaultLang)
+
+	nq.Lang = "en"
+	_, err = nq.ToEdgeUsing(newToUid)
+	require.Error(t, err)
+	_, err = nq.createEdge(1, newToUid)
+	require.Error(t, err)
+}
+
+func TestToEdgesCreateOnly(t *testing.T) {
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "name", CreateOnly: true,
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}},
+		{Subject: "0x1", Predicate: "friend", ObjectId: "0x2", CreateOnly: true},
+		{Subject: "0x1", Predicate: "nick",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Al"}}},
+	}}
+	edges, err := m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+	require.True(t, edges[0].CreateOnly)
+	require.True(t, edges[1].CreateOnly)
+	require.False(t, edges[2].CreateOnly)
+
+	_, err = Mutation{Del: m.Set[:1]}.ToEdges(nil, ConvertOptions{})
+	require.Error(t, err)
+	m.Set[0].Increment = true
+	m.Set[0].ObjectValue = &protos.Value{Val: &protos.Value_IntVal{IntVal: 1}}
+	_, err = m.ToEdges(nil, ConvertOptions{})
+	require.Error(t, err)
+}
+
+func TestValidateMaxCardinality(t *testing.T) {
+	friends := func(subject string, n int) []*protos.NQuad {
+		var nquads []*protos.NQuad
+		for i := 0; i < n; i++ {
+			nquads = append(nquads, &protos.NQuad{
+				Subject:   subject,
+				Predicate: "friend",
+				ObjectId:  fmt.Sprintf("_:f%d", i),
+			})
+		}
+		return nquads
+	}
+	opts := ValidateOptions{MaxCardinality: map[string]int{"friend": 3}}
+	m := Mutation{Set: append(friends("_:a", 3), friends("_:b", 3)...)}
+	require.NoError(t, m.Validate(opts))
+
+	m.Set = append(m.Set, friends("_:a", 1)...)
+	err := m.Validate(opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "index 6")
+	require.Contains(t, err.Error(), "_:a")
+
+	// Predicates which aren't listed have no limit.
+	opts.MaxCardinality = map[string]int{"follows": 1}
+	require.NoError(t, m.Validate(opts))
+
+	// Each uid in a list counts as an object.
+	opts.MaxCardinality = map[string]int{"friend": 3}
+	m.Set = []*protos.NQuad{{Subject: "_:a", Predicate: "friend",
+		ObjectIds: []string{"_:f0", "_:f1", "_:f2", "_:f3"}}}
+	require.Error(t, m.Validate(opts))
+	m.Set[0].ObjectIds = m.Set[0].ObjectIds[:3]
+	require.NoError(t, m.Validate(opts))
+}
+
+type fakeResolver struct {
+	uids  map[string]uint64
+	err   error
+	calls [][]string
+}
+
+func (r *fakeResolver) Resolve(ctx context.Context, xids []string) (map[string]uint64, error) {
+	r.calls = append(r.calls, xids)
+	if r.err != nil {
+		return nil, r.err
+	}
+	out := make(map[string]uint64)
+	for _, xid := range xids {
+		if uid, ok := r.uids[xid]; ok {
+			out[xid] = uid
+		}
+	}
+	return out, nil
+}
+
+func TestToEdgesWithManifest(t *testing.T) {
+	str := &protos.Value{Val: &protos.Value_StrVal{StrVal: "a"}}
+	nquads := []NQuad{
+		{&protos.NQuad{Subject: "_:b", Predicate: "friend", ObjectId: "_:a"}},
+		{&protos.NQuad{Subject: "_:a", Predicate: "name", ObjectValue: str}},
+		{&protos.NQuad{Subject: "_:a", Predicate: "friend", ObjectId: "0x2a"}},
+		{&protos.NQuad{Subject: "alice", Predicate: "friend", ObjectId: "_:b"}},
+	}
+	newToUid := map[string]uint64{"_:a": 5, "_:b": 6, "alice": 7}
+	for i := 0; i < 2; i++ {
+		edges, manifest, err := ToEdgesWithManifest(nquads, newToUid)
+		require.NoError(t, err)
+		require.Equal(t, 4, len(edges))
+		require.Equal(t, []ManifestEntry{
+			{Xid: "_:b", Uid: 6},
+			{Xid: "_:a", Uid: 5},
+			{Xid: "0x2a", Uid: 42},
+			{Xid: "alice", Uid: 7},
+		}, manifest)
+	}
+
+	_, _, err := ToEdgesWithManifest(nquads, map[string]uint64{"_:a": 5})
+	require.Error(t, err)
+}
+
+func TestToEdgesResolving(t *testing.T) {
+	nqs := []NQuad{
+		{&protos.NQuad{Subject: "alice", Predicate: "friend", ObjectId: "bob"}},
+		{&protos.NQuad{Subject: "bob", Predicate: "friend", ObjectId: "0x5"}},
+		{&protos.NQuad{Subject: "alice", Predicate: "name",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}}},
+	}
+	r := &fakeResolver{uids: map[string]uint64{"alice": 10, "bob": 11}}
+	edges, err := ToEdgesResolving(context.Background(), nqs, r)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"alice", "bob"}}, r.calls)
+	require.Equal(t, 3, len(edges))
+	require.Equal(t, uint64(10), edges[0].Entity)
+	require.Equal(t, uint64(11), edges[0].ValueId)
+	require.Equal(t, uint64(5), edges[1].ValueId)
+	require.Equal(t, uint64(10), edges[2].Entity)
+
+	// XIDs the resolver has no UID for fail conversion.
+	delete(r.uids, "bob")
+	_, err = ToEdgesResolving(context.Background(), nqs, r)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "index 0")
+
+	r.err = errors.New("table unavailable")
+	_, err = ToEdgesResolving(context.Background(), nqs, r)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "table unavailable")
+}
+
+func TestToEdgesTombstone(t *testing.T) {
+	deletedAt := time.Date(2017, 11, 2, 10, 30, 0, 0, time.UTC)
+	opts := ConvertOptions{Tombstone: &Tombstone{DeletedBy: "auditor", DeletedAt: deletedAt}}
+	m := Mutation{
+		Set: []*protos.NQuad{{Subject: "0x1", Predicate: "friend", ObjectId: "0x3"}},
+		Del: []*protos.NQuad{{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"}},
+	}
+	edges, err := m.ToEdges(nil, opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(edges))
+
+	require.False(t, edges[0].Tombstone)
+	require.Equal(t, 0, len(edges[0].Facets))
+
+	del := edges[1]
+	require.Equal(t, protos.DirectedEdge_DEL, del.Op)
+	require.True(t, del.Tombstone)
+	require.Equal(t, 2, len(del.Facets))
+	require.Equal(t, DeletedByFacet, del.Facets[0].Key)
+	require.Equal(t, "auditor", facets.ValFor(del.Facets[0]).Value)
+	require.Equal(t, DeletedAtFacet, del.Facets[1].Key)
+	require.Equal(t, protos.Facet_DATETIME, del.Facets[1].ValType)
+	require.True(t, deletedAt.Equal(facets.ValFor(del.Facets[1]).Value.(time.Time)))
+	require.Equal(t, 0, len(m.Del[0].Facets))
+
+	// Without the option, deletes are plain.
+	edges, err = m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+	require.False(t, edges[1].Tombstone)
+	require.Equal(t, 0, len(edges[1].Facets))
+
+	// Deleting all the edges can't leave tombstones.
+	m = Mutation{Del: []*protos.NQuad{{Subject: "0x1", Predicate: "friend",
+		ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: x.Star}}}}}
+	_, err = m.ToEdges(nil, opts)
+	require.Error(t, err)
+}
+
+func TestMutationParseSchemaDuplicates(t *testing.T) {
+	m := Mutation{Schema: `
+		name: string @index(exact) .
+		age: int .
+		name: string @index(exact) .
+	`}
+	updates, err := m.ParseSchema(SchemaOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(updates))
+	require.Equal(t, "name", updates[0].Predicate)
+	require.Equal(t, "age", updates[1].Predicate)
+
+	m.Schema = `
+		name: string @index(exact) .
+		age: int .
+		name: string @index(term) .
+	`
+	_, err = m.ParseSchema(SchemaOptions{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "name")
+
+	updates, err = m.ParseSchema(SchemaOptions{LastDuplicateWins: true})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(updates))
+	require.Equal(t, "name", updates[0].Predicate)
+	require.Equal(t, []string{"term"}, updates[0].Tokenizer)
+	require.Equal(t, "age", updates[1].Predicate)
+}
+
+func TestMutationToProto(t *testing.T) {
+	m := Mutation{
+		Set: []*protos.NQuad{
+			{Subject: "_:a", Predicate: "name",
+				ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}},
+			{Subject: "_:a", Predicate: "friend", ObjectId: "_:b"},
+			{Subject: "_:b", Predicate: "name",
+				ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Bob"}}},
+		},
+		Del:    []*protos.NQuad{{Subject: "_:b", Predicate: "friend", ObjectId: "_:a"}},
+		Schema: "name: string @index(exact) .",
+	}
+	newToUid := map[string]uint64{"_:a": 1, "_:b": 2}
+	mu, err := m.ToProto(newToUid)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(mu.Edges))
+	require.Equal(t, protos.DirectedEdge_SET, mu.Edges[2].Op)
+	require.Equal(t, protos.DirectedEdge_DEL, mu.Edges[3].Op)
+	require.Equal(t, 1, len(mu.Schema))
+	require.Equal(t, "name", mu.Schema[0].Predicate)
+	require.False(t, mu.DropAll)
+
+	m.Set = append(m.Set, &protos.NQuad{Subject: "_:a", Predicate: "friend", ObjectVar: "f"})
+	_, err = m.ToProto(newToUid)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "[f]")
+}
+
+func TestToEdgesCreatedAt(t *testing.T) {
+	now := time.Date(2017, 12, 1, 8, 0, 0, 0, time.UTC)
+	opts := ConvertOptions{
+		AddCreatedAt: true,
+		Clock:        func() time.Time { return now },
+	}
+	earlier, err := facets.FacetFor(CreatedAtFacet, "2016-05-04T00:00:00Z")
+	require.NoError(t, err)
+	m := Mutation{
+		Set: []*protos.NQuad{
+			{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"},
+			{Subject: "0x1", Predicate: "friend", ObjectId: "0x3",
+				Facets: []*protos.Facet{earlier}},
+		},
+		Del: []*protos.NQuad{{Subject: "0x1", Predicate: "friend", ObjectId: "0x4"}},
+	}
+	edges, err := m.ToEdges(nil, opts)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(edges))
+
+	require.Equal(t, 1, len(edges[0].Facets))
+	require.Equal(t, CreatedAtFacet, edges[0].Facets[0].Key)
+	require.True(t, now.Equal(facets.ValFor(edges[0].Facets[0]).Value.(time.Time)))
+	require.Equal(t, 0, len(m.Set[0].Facets))
+
+	// Facets the client gave are kept as they are.
+	require.Equal(t, []*protos.Facet{earlier}, edges[1].Facets)
+	// Deletes don't get the facet.
+	require.Equal(t, 0, len(edges[2].Facets))
+}
+
+type fakeAllocator struct {
+	next  uint64
+	calls []uint64
+	err   error
+}
+
+func (a *fakeAllocator) Allocate(num uint64) (uint64, error) {
+	a.calls = append(a.calls, num)
+	if a.err != nil {
+		return 0, a.err
+	}
+	start := a.next
+	a.next += num
+	return start, nil
+}
+
+func TestToEdgesTwoPass(t *testing.T) {
+	edge := func(s, o string) NQuad {
+		return NQuad{&protos.NQuad{Subject: s, Predicate: "edge", ObjectId: o}}
+	}
+	nqs := []NQuad{
+		// _:b and _:c are used before they appear as subjects.
+		edge("_:a", "_:b"),
+		edge("_:b", "_:c"),
+		edge("_:c", "_:a"),
+		edge("_:a", "0x5"),
+		edge("_:c", "_:b"),
+	}
+	alloc := &fakeAllocator{next: 100}
+	edges, newToUid, err := ToEdgesTwoPass(nqs, alloc)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3}, alloc.calls)
+	require.Equal(t, map[string]uint64{"_:a": 100, "_:b": 101, "_:c": 102}, newToUid)
+
+	pairs := make([][2]uint64, 0, len(edges))
+	for _, e := range edges {
+		pairs = append(pairs, [2]uint64{e.Entity, e.ValueId})
+	}
+	require.Equal(t, [][2]uint64{{100, 101}, {101, 102}, {102, 100}, {100, 5}, {102, 101}}, pairs)
+
+	alloc.err = errors.New("zero unreachable")
+	_, _, err = ToEdgesTwoPass(nqs, alloc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "zero unreachable")
+}
+
+func TestToEdgesTruncateStrings(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	var warnings []Warning
+	opts := ConvertOptions{
+		TruncateStrings: map[string]int{"name": 4, "bio": 20},
+		Warn:            func(w Warning) { warnings = append(warnings, w) },
+	}
+	m := Mutation{Set: []*protos.NQuad{
+		// "é" takes two bytes, so the limit of 4 falls in the middle of it.
+		{Subject: "0x1", Predicate: "name", ObjectValue: str("Renée Smith")},
+		{Subject: "0x1", Predicate: "bio", ObjectValue: str("Short bio.")},
+		{Subject: "0x1", Predicate: "note", ObjectValue: str("Not limited at all.")},
+	}}
+	edges, err := m.ToEdges(nil, opts)
+	require.NoError(t, err)
+	require.Equal(t, "Ren", string(edges[0].Value))
+	require.Equal(t, "Short bio.", string(edges[1].Value))
+	require.Equal(t, "Not limited at all.", string(edges[2].Value))
+	// The NQuad is left as it was.
+	require.Equal(t, "Renée Smith", m.Set[0].ObjectValue.GetStrVal())
+
+	require.Equal(t, 1, len(warnings))
+	require.Equal(t, 0, warnings[0].Index)
+	require.Equal(t, "name", warnings[0].Predicate)
+	require.Equal(t, "string value of 12 bytes truncated to 3 bytes, the limit is 4",
+		warnings[0].Message)
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	require.Equal(t, "abc", truncateUTF8("abc", 5))
+	require.Equal(t, "ab", truncateUTF8("abc", 2))
+	require.Equal(t, "", truncateUTF8("日本", 2))
+	require.Equal(t, "日", truncateUTF8("日本", 5))
+	require.Equal(t, "日本", truncateUTF8("日本", 6))
+	require.Equal(t, "", truncateUTF8("abc", -1))
+}
+
+func TestMutationCheckVars(t *testing.T) {
+	m := Mutation{
+		Set: []*protos.NQuad{
+			{SubjectVar: "friends", Predicate: "likes", ObjectVar: "films"},
+			{SubjectVar: "friends", Predicate: "name",
+				ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}},
+		},
+		Del: []*protos.NQuad{{SubjectVar: "old", Predicate: "likes", ObjectId: "0x1"}},
+	}
+	require.Equal(t, []string{"films", "friends", "old"}, m.NeededVars())
+	require.NoError(t, m.CheckVars(map[string]bool{"films": true, "friends": true, "old": true,
+		"unused": true}))
+
+	err := m.CheckVars(map[string]bool{"friends": true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "[films old]")
+}
+
+func TestToEdgesNameRules(t *testing.T) {
+	def := func(pred, val string) *protos.NQuad {
+		return &protos.NQuad{Subject: "0x1", Predicate: pred,
+			ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: val}}}
+	}
+	m := Mutation{Set: []*protos.NQuad{
+		def("created_at", "2017-11-02T10:30:00Z"),
+		def("view_count", "42"),
+		def("is_admin", "true"),
+		def("name", "Alice"),
+		// Typed values are left as they are.
+		{Subject: "0x1", Predicate: "login_count",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "many"}}},
+	}}
+	opts := ConvertOptions{NameRules: DefaultNameRules}
+	edges, err := m.ToEdges(nil, opts)
+	require.NoError(t, err)
+	typeOf := func(e *protos.DirectedEdge) types.TypeID { return types.TypeID(e.ValueType) }
+	require.Equal(t, types.DateTimeID, typeOf(edges[0]))
+	require.Equal(t, types.IntID, typeOf(edges[1]))
+	require.Equal(t, types.BoolID, typeOf(edges[2]))
+	require.Equal(t, types.DefaultID, typeOf(edges[3]))
+	require.Equal(t, types.StringID, typeOf(edges[4]))
+
+	// Without rules, nothing is inferred.
+	edges, err = m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, types.DefaultID, typeOf(edges[1]))
+
+	m.Set = []*protos.NQuad{def("view_count", "lots")}
+	_, err = m.ToEdges(nil, opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "view_count")
+}
+
+func TestToEdgesRelativeTimes(t *testing.T) {
+	def := func(pred, val string) *protos.NQuad {
+		return &protos.NQuad{Subject: "0x1", Predicate: pred,
+			ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: val}}}
+	}
+	now := time.Date(2017, 11, 2, 10, 30, 0, 0, time.UTC)
+	opts := ConvertOptions{
+		NameRules:     DefaultNameRules,
+		RelativeTimes: true,
+		Clock:         func() time.Time { return now },
+	}
+	m := Mutation{Set: []*protos.NQuad{
+		def("seen_at", "now"),
+		def("created_at", "now-7d"),
+		def("expires_at", "now+1h"),
+		def("name", "now"),
+	}}
+	edges, err := m.ToEdges(nil, opts)
+	require.NoError(t, err)
+	timeOf := func(e *protos.DirectedEdge) time.Time {
+		require.Equal(t, types.DateTimeID, types.TypeID(e.ValueType))
+		v, err := types.Convert(types.Val{Tid: types.BinaryID, Value: e.Value}, types.DateTimeID)
+		require.NoError(t, err)
+		return v.Value.(time.Time)
+	}
+	require.True(t, now.Equal(timeOf(edges[0])))
+	require.True(t, now.AddDate(0, 0, -7).Equal(timeOf(edges[1])))
+	require.True(t, now.Add(time.Hour).Equal(timeOf(edges[2])))
+	// Values which aren't converted to datetime are left as they are.
+	require.Equal(t, types.DefaultID, types.TypeID(edges[3].ValueType))
+
+	for _, val := range []string{"now-7", "now*7d", "now-7y", "now-d", "nowish"} {
+		m.Set = []*protos.NQuad{def("created_at", val)}
+		_, err = m.ToEdges(nil, opts)
+		require.Error(t, err, val)
+	}
+
+	// Without the option, relative times aren't valid datetimes.
+	m.Set = []*protos.NQuad{def("created_at", "now")}
+	_, err = m.ToEdges(nil, ConvertOptions{NameRules: DefaultNameRules})
+	require.Error(t, err)
+}
+
+func TestToEdgesInternValues(t *testing.T) {
+	str := func(s string) *protos.Value {
+		return &protos.Value{Val: &protos.Value_StrVal{StrVal: s}}
+	}
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "0x1", Predicate: "category", ObjectValue: str("book")},
+		{Subject: "0x2", Predicate: "category", ObjectValue: str("film")},
+		{Subject: "0x3", Predicate: "category", ObjectValue: str("book")},
+		{Subject: "0x3", Predicate: "friend", ObjectId: "0x1"},
+	}}
+	in := NewValueInterner()
+	edges, err := m.ToEdges(nil, ConvertOptions{Values: in})
+	require.NoError(t, err)
+	require.Equal(t, 2, in.Len())
+	require.Equal(t, []byte("book"), edges[0].Value)
+	require.Equal(t, []byte("film"), edges[1].Value)
+	require.Equal(t, []byte("book"), edges[2].Value)
+	// Both edges for book share the same bytes.
+	require.True(t, &edges[0].Value[0] == &edges[2].Value[0])
+	require.Empty(t, edges[3].Value)
+
+	edges, err = m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+	require.False(t, &edges[0].Value[0] == &edges[2].Value[0])
+}
+
+func TestNameRuleMatches(t *testing.T) {
+	require.True(t, NameRule{Pattern: "*_at"}.matches("created_at"))
+	require.False(t, NameRule{Pattern: "*_at"}.matches("at"))
+	require.True(t, NameRule{Pattern: "is_*"}.matches("is_"))
+	require.False(t, NameRule{Pattern: "is_*"}.matches("this_is"))
+	require.True(t, NameRule{Pattern: "age"}.matches("age"))
+	require.False(t, NameRule{Pattern: "age"}.matches("page"))
+}
+
+func TestAppendScalarMatchesMarshal(t *testing.T) {
+	vals := []*protos.Value{
+		{Val: &protos.Value_IntVal{IntVal: 0}},
+		{Val: &protos.Value_IntVal{IntVal: 42}},
+		{Val: &protos.Value_IntVal{IntVal: -1}},
+		{Val: &protos.Value_IntVal{IntVal: math.MaxInt64}},
+		{Val: &protos.Value_IntVal{IntVal: math.MinInt64}},
+		{Val: &protos.Value_DoubleVal{DoubleVal: 0}},
+		{Val: &protos.Value_DoubleVal{DoubleVal: -2.5e-3}},
+		{Val: &protos.Value_DoubleVal{DoubleVal: math.MaxFloat64}},
+		{Val: &protos.Value_DoubleVal{DoubleVal: math.Inf(-1)}},
+		{Val: &protos.Value_BoolVal{BoolVal: true}},
+		{Val: &protos.Value_BoolVal{BoolVal: false}},
+	}
+	buf := []byte("prefix")
+	for _, v := range vals {
+		p := typeValFrom(v)
+		want := types.ValueForType(types.BinaryID)
+		require.NoError(t, types.Marshal(p, &want))
+
+		got, tid, ok := appendScalar(buf, v)
+		require.True(t, ok)
+		require.Equal(t, p.Tid, tid)
+		require.Equal(t, "prefix", string(got[:len(buf)]))
+		require.Equal(t, want.Value.([]byte), got[len(buf):], "for %v", v)
+
+		b, tid, err := byteVal(NQuad{&protos.NQuad{ObjectValue: v}})
+		require.NoError(t, err)
+		require.Equal(t, p.Tid, tid)
+		require.Equal(t, want.Value.([]byte), b)
+	}
+
+	_, _, ok := appendScalar(nil, &protos.Value{Val: &protos.Value_StrVal{StrVal: "1"}})
+	require.False(t, ok)
+}
+
+func TestMergeEdgeFacets(t *testing.T) {
+	since, err := facets.FacetFor("since", "2006")
+	require.NoError(t, err)
+	closeF, err := facets.FacetFor("close", "true")
+	require.NoError(t, err)
+	m := Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "friend", ObjectId: "_:b",
+			Facets: []*protos.Facet{since}},
+		{Subject: "_:a", Predicate: "name",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}},
+		{Subject: "_:a", Predicate: "friend", ObjectId: "_:b",
+			Facets: []*protos.Facet{closeF, since}},
+		{Subject: "_:a", Predicate: "friend", ObjectId: "_:c"},
+	}}
+	require.NoError(t, m.MergeEdgeFacets())
+	require.Equal(t, 3, len(m.Set))
+	require.Equal(t, "_:b", m.Set[0].ObjectId)
+	require.Equal(t, []*protos.Facet{closeF, since}, m.Set[0].Facets)
+	require.Equal(t, "name", m.Set[1].Predicate)
+	require.Equal(t, "_:c", m.Set[2].ObjectId)
+
+	// NQuads with different lists of uids set different edges.
+	m = Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "friend", ObjectIds: []string{"0x2"}},
+		{Subject: "_:a", Predicate: "friend", ObjectIds: []string{"0x3"}},
+		{Subject: "_:a", Predicate: "friend", ObjectIds: []string{"0x3"},
+			Facets: []*protos.Facet{since}},
+	}}
+	require.NoError(t, m.MergeEdgeFacets())
+	require.Equal(t, 2, len(m.Set))
+	require.Equal(t, []string{"0x2"}, m.Set[0].ObjectIds)
+	require.Equal(t, []string{"0x3"}, m.Set[1].ObjectIds)
+	require.Equal(t, []*protos.Facet{since}, m.Set[1].Facets)
+}
+
+func TestMergeEdgeFacetsIncrement(t *testing.T) {
+	inc := func() *protos.NQuad {
+		return &protos.NQuad{Subject: "0x1", Predicate: "views", Increment: true,
+			ObjectValue: &protos.Value{Val: &protos.Value_IntVal{IntVal: 1}}}
+	}
+	m := Mutation{Set: []*protos.NQuad{inc(), inc()}}
+	require.NoError(t, m.MergeEdgeFacets())
+	require.Equal(t, 2, len(m.Set))
+}
+
+func TestMergeEdgeFacetsConflict(t *testing.T) {
+	since1, err := facets.FacetFor("since", "2006")
+	require.NoError(t, err)
+	since2, err := facets.FacetFor("since", "2007")
+	require.NoError(t, err)
+	nq := &protos.NQuad{Subject: "_:a", Predicate: "friend", ObjectId: "_:b",
+		Facets: []*protos.Facet{since1}}
+	m := Mutation{Set: []*protos.NQuad{nq,
+		{Subject: "_:a", Predicate: "friend", ObjectId: "_:b",
+			Facets: []*protos.Facet{since2}},
+	}}
+	err = m.MergeEdgeFacets()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "since")
+	require.Contains(t, err.Error(), "index 1")
+	// The NQuads given aren't modified.
+	require.Equal(t, []*protos.Facet{since1}, nq.Facets)
+}
+
+func TestCheckPredicateConsistency(t *testing.T) {
+	name := func(v string) *protos.NQuad {
+		return &protos.NQuad{Subject: "_:a", Predicate: "name",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: v}}}
+	}
+	friend := func(o string) *protos.NQuad {
+		return &protos.NQuad{Subject: "_:a", Predicate: "friend", ObjectId: o}
+	}
+	star := &protos.NQuad{Subject: "_:a", Predicate: "friend",
+		ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: x.Star}}}
+
+	m := &Mutation{Set: []*protos.NQuad{name("Alice"), name("Ali")},
+		Del: []*protos.NQuad{name("Al")}}
+	require.NoError(t, CheckPredicateConsistency(m))
+
+	m = &Mutation{Set: []*protos.NQuad{friend("_:b"), friend("_:c")},
+		Del: []*protos.NQuad{star, friend("0x1")}}
+	require.NoError(t, CheckPredicateConsistency(m))
+
+	m = &Mutation{Set: []*protos.NQuad{friend("_:b"), name("Alice")},
+		Del: []*protos.NQuad{{Subject: "_:a", Predicate: "friend",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Bob"}}}}}
+	err := CheckPredicateConsistency(m)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "friend")
+}
+
+func TestFacetConditionApply(t *testing.T) {
+	tests := []struct {
+		op, fn string
+	}{
+		{"<", "lt"},
+		{">", "gt"},
+		{"=", "eq"},
+	}
+	for _, tc := range tests {
+		c, err := NewFacetCondition(tc.op, "weight", "0.1")
+		require.NoError(t, err)
+		edge := &protos.DirectedEdge{Entity: 1, Attr: "friend", ValueId: 2,
+			Op: protos.DirectedEdge_DEL}
+		require.NoError(t, c.Apply(edge))
+		require.Equal(t, tc.fn, edge.FacetCondOp)
+		require.Equal(t, 1, len(edge.Facets))
+		require.Equal(t, "weight", edge.Facets[0].Key)
+		require.Equal(t, protos.Facet_FLOAT, edge.Facets[0].ValType)
+
+		// The condition survives the trip to the server.
+		data, err := edge.Marshal()
+		require.NoError(t, err)
+		var out protos.DirectedEdge
+		require.NoError(t, out.Unmarshal(data))
+		require.Equal(t, tc.fn, out.FacetCondOp)
+		require.Equal(t, edge.Facets, out.Facets)
+
+		// Sets can't be conditional.
+		edge = &protos.DirectedEdge{Entity: 1, Attr: "friend", ValueId: 2}
+		require.Error(t, c.Apply(edge))
+	}
+
+	_, err := NewFacetCondition("~", "weight", "0.1")
+	require.Error(t, err)
+}
+
+func TestToEdgeModes(t *testing.T) {
+	weight, err := facets.FacetFor("weight", "0.1")
+	require.NoError(t, err)
+	nq := NQuad{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2",
+		FacetCondOp: "<", Facets: []*protos.Facet{weight}}}
+	edge, err := nq.ToDeleteEdgeUsing(nil)
+	require.NoError(t, err)
+	require.Equal(t, "lt", edge.FacetCondOp)
+	require.Equal(t, []*protos.Facet{weight}, edge.Facets)
+
+	nq = NQuad{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2",
+		DelFacets: []string{"since"}}}
+	edge, err = nq.ToEdgeUsing(nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"since"}, edge.DelFacets)
+
+	nq = NQuad{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2",
+		Tombstone: true, DeletedBy: "auditor"}}
+	edge, err = nq.ToDeleteEdgeUsing(nil)
+	require.NoError(t, err)
+	require.True(t, edge.Tombstone)
+	require.Equal(t, DeletedByFacet, edge.Facets[0].Key)
+	require.Equal(t, DeletedAtFacet, edge.Facets[1].Key)
+
+	bad := []struct {
+		nq  *protos.NQuad
+		del bool
+	}{
+		// Facet conditions need a known operator, a single facet and a delete.
+		{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2",
+			FacetCondOp: "!=", Facets: []*protos.Facet{weight}}, true},
+		{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2",
+			FacetCondOp: "<"}, true},
+		{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2",
+			FacetCondOp: "<", Facets: []*protos.Facet{weight}}, false},
+		// Facets are only deleted by sets.
+		{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2",
+			DelFacets: []string{"since"}}, true},
+		// Tombstones are only left by deletes.
+		{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2",
+			Tombstone: true}, false},
+		{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2",
+			DeletedBy: "auditor"}, true},
+	}
+	for _, tc := range bad {
+		if tc.del {
+			_, err = NQuad{tc.nq}.ToDeleteEdgeUsing(nil)
+		} else {
+			_, err = NQuad{tc.nq}.ToEdgeUsing(nil)
+		}
+		require.Error(t, err, "%+v", tc.nq)
+	}
+}
+
+func TestDeleteFacets(t *testing.T) {
+	nq := NQuad{&protos.NQuad{Subject: "0x1", Predicate: "friend", ObjectId: "0x2"}}
+	edge, err := nq.ToEdgeUsing(nil)
+	require.NoError(t, err)
+	require.NoError(t, DeleteFacets(edge, "since", "close"))
+	require.Equal(t, []string{"since", "close"}, edge.DelFacets)
+	// The edge itself is kept.
+	require.Equal(t, protos.DirectedEdge_SET, edge.Op)
+	require.Equal(t, uint64(2), edge.ValueId)
+	require.Empty(t, edge.Facets)
+
+	// The keys survive the trip to the server.
+	data, err := edge.Marshal()
+	require.NoError(t, err)
+	var out protos.DirectedEdge
+	require.NoError(t, out.Unmarshal(data))
+	require.Equal(t, edge.DelFacets, out.DelFacets)
+
+	require.Error(t, DeleteFacets(&protos.DirectedEdge{Attr: "friend"}))
+	require.Error(t, DeleteFacets(&protos.DirectedEdge{Attr: "friend",
+		Op: protos.DirectedEdge_DEL}, "since"))
+}
+
+func TestToScanDeleteEdges(t *testing.T) {
+	nq := NQuad{&protos.NQuad{Subject: x.Star, Predicate: "status",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "spam"}}}}
+	edges, err := nq.ToScanDeleteEdges([]uint64{3, 5})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(edges))
+	for i, uid := range []uint64{3, 5} {
+		require.Equal(t, protos.DirectedEdge_DEL, edges[i].Op)
+		require.Equal(t, uid, edges[i].Entity)
+		require.Equal(t, "status", edges[i].Attr)
+		require.Equal(t, []byte("spam"), edges[i].Value)
+	}
+
+	_, err = nq.ToScanDeleteEdges([]uint64{3, 0})
+	require.Error(t, err)
+
+	bad := []*protos.NQuad{
+		{Subject: x.Star, Predicate: "status"},
+		{Subject: x.Star, Predicate: "status",
+			ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: x.Star}}},
+		{Subject: x.Star, Predicate: x.Star,
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "spam"}}},
+		{Subject: "0x1", Predicate: "status",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "spam"}}},
+	}
+	for _, nq := range bad {
+		_, err := NQuad{nq}.ToScanDeleteEdges([]uint64{3})
+		require.Error(t, err, "%+v", nq)
+	}
+}
+
+func TestNormalizePredicatesTrim(t *testing.T) {
+	nq := &protos.NQuad{Subject: "_:a", Predicate: " name ",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}}
+	m := &Mutation{
+		Set: []*protos.NQuad{nq},
+		Del: []*protos.NQuad{{Subject: "_:a", Predicate: "Friend\t", ObjectId: "_:b"}},
+	}
+	m.NormalizePredicates(PredicatePolicy{TrimSpace: true})
+	require.Equal(t, "name", m.Set[0].Predicate)
+	require.Equal(t, "Friend", m.Del[0].Predicate)
+	// The NQuads given aren't modified.
+	require.Equal(t, " name ", nq.Predicate)
+}
+
+func TestNormalizePredicatesLowercase(t *testing.T) {
+	m := &Mutation{Set: []*protos.NQuad{
+		{Subject: "_:a", Predicate: "Name",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}},
+		{Subject: "_:a", Predicate: "name",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Ali"}}},
+	}}
+	m.NormalizePredicates(PredicatePolicy{Lowercase: true})
+	require.Equal(t, "name", m.Set[0].Predicate)
+	require.Equal(t, "name", m.Set[1].Predicate)
+
+	m = &Mutation{Set: []*protos.NQuad{{Subject: "_:a", Predicate: " Name ", ObjectId: "_:b"}}}
+	m.NormalizePredicates(PredicatePolicy{Lowercase: true})
+	require.Equal(t, " name ", m.Set[0].Predicate)
+}
+
+func TestNormalizePredicatesSchema(t *testing.T) {
+	m := &Mutation{
+		Set: []*protos.NQuad{
+			{Subject: "_:a", Predicate: " Name",
+				ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}},
+			{Subject: "_:a", Predicate: "http://Schema.org/Friend", ObjectId: "_:b"},
+			{Subject: "_:a", Predicate: "Age",
+				ObjectValue: &protos.Value{Val: &protos.Value_IntVal{IntVal: 26}}},
+		},
+		Schema: "Name: string @index(exact) .\n" +
+			"  <http://Schema.org/Friend>: uid @reverse .\n" +
+			"Age : int .\n",
+	}
+	m.NormalizePredicates(PredicatePolicy{TrimSpace: true, Lowercase: true})
+	require.Equal(t, "name: string @index(exact) .\n"+
+		"  <http://schema.org/friend>: uid @reverse .\n"+
+		"age : int .\n", m.Schema)
+
+	updates, err := m.ParseSchema(SchemaOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(updates))
+	require.Equal(t, "name", updates[0].Predicate)
+	require.Equal(t, m.Set[0].Predicate, updates[0].Predicate)
+	require.Equal(t, "http://schema.org/friend", updates[1].Predicate)
+	require.Equal(t, m.Set[1].Predicate, updates[1].Predicate)
+	require.Equal(t, "age", updates[2].Predicate)
+	require.Equal(t, m.Set[2].Predicate, updates[2].Predicate)
+}
+
+func TestRenamePredicate(t *testing.T) {
+	nq := &protos.NQuad{Subject: "_:a", Predicate: "friend", ObjectId: "_:b"}
+	m := &Mutation{
+		Set: []*protos.NQuad{
+			nq,
+			{Subject: "_:a", Predicate: "name",
+				ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}},
+			{Subject: "_:a", Predicate: "friends", ObjectId: "_:c"},
+		},
+		Del: []*protos.NQuad{{Subject: "_:c", Predicate: "friend", ObjectId: "_:a"}},
+	}
+	require.Equal(t, 2, m.RenamePredicate("friend", "knows"))
+	require.Equal(t, "knows", m.Set[0].Predicate)
+	require.Equal(t, "name", m.Set[1].Predicate)
+	require.Equal(t, "friends", m.Set[2].Predicate)
+	require.Equal(t, "knows", m.Del[0].Predicate)
+	// The NQuads given aren't modified.
+	require.Equal(t, "friend", nq.Predicate)
+
+	require.Equal(t, 0, m.RenamePredicate("friend", "knows"))
+}
+
+func TestRenamePredicateSchema(t *testing.T) {
+	m := &Mutation{
+		Set: []*protos.NQuad{{Subject: "_:a", Predicate: "friend", ObjectId: "_:b"}},
+		Schema: "friend: uid @reverse .\n" +
+			"  <friend> : uid .\n" +
+			"friends: uid .\n",
+	}
+	require.Equal(t, 3, m.RenamePredicate("friend", "knows"))
+	require.Equal(t, "knows: uid @reverse .\n"+
+		"  <knows> : uid .\n"+
+		"friends: uid .\n", m.Schema)
+}
+
+func TestRenamePredicateReverse(t *testing.T) {
+	m := &Mutation{Del: []*protos.NQuad{
+		{Subject: "_:b", Predicate: "~friend", ObjectId: "_:a"},
+		{Subject: "_:b", Predicate: "~friends", ObjectId: "_:a"},
+	}}
+	require.Equal(t, 1, m.RenamePredicate("friend", "knows"))
+	require.Equal(t, "~knows", m.Del[0].Predicate)
+	require.Equal(t, "~friends", m.Del[1].Predicate)
+}
+
+func TestToEdgesStarPredicateValue(t *testing.T) {
+	m := Mutation{Del: []*protos.NQuad{{Subject: "0x1", Predicate: x.Star,
+		ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: "Alice"}}}}}
+	edges, err := m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(edges))
+	require.Equal(t, uint64(1), edges[0].Entity)
+	require.Equal(t, x.Star, edges[0].Attr)
+	require.Equal(t, "Alice", string(edges[0].Value))
+	require.Equal(t, protos.DirectedEdge_DEL, edges[0].Op)
+}
+
+func TestToEdgesStarPredicateUid(t *testing.T) {
+	m := Mutation{Del: []*protos.NQuad{{Subject: "0x1", Predicate: x.Star, ObjectId: "0x2"}}}
+	_, err := m.ToEdges(nil, ConvertOptions{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "uid object")
+
+	m = Mutation{Del: []*protos.NQuad{{Subject: "0x1", Predicate: x.Star, ObjectVar: "friends"}}}
+	_, err = m.ToEdges(nil, ConvertOptions{})
+	require.Error(t, err)
+}
+
+func TestToEdgesStarPredicateSet(t *testing.T) {
+	nq := NQuad{&protos.NQuad{Subject: "0x1", Predicate: x.Star,
+		ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: "Alice"}}}}
+	_, err := nq.ToEdgeUsing(nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only allowed in delete")
+
+	edge, err := nq.ToDeleteEdgeUsing(nil)
+	require.NoError(t, err)
+	require.Equal(t, x.Star, edge.Attr)
+	require.Equal(t, protos.DirectedEdge_DEL, edge.Op)
+
+	m := Mutation{Set: []*protos.NQuad{nq.NQuad}}
+	_, err = m.ToEdges(nil, ConvertOptions{})
+	require.Error(t, err)
+}
+
+func TestToEdgeUsingMaps(t *testing.T) {
+	subjects := map[string]uint64{"alice": 10, "_:bob": 20}
+	objects := map[string]uint64{"alice": 100, "_:bob": 200}
+
+	nq := NQuad{&protos.NQuad{Subject: "alice", Predicate: "friend", ObjectId: "_:bob"}}
+	edge, err := nq.ToEdgeUsingMaps(subjects, objects)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), edge.Entity)
+	require.Equal(t, uint64(200), edge.ValueId)
+
+	// The same xids resolve the other way round when they swap places.
+	nq = NQuad{&protos.NQuad{Subject: "_:bob", Predicate: "friend", ObjectId: "alice"}}
+	edge, err = nq.ToEdgeUsingMaps(subjects, objects)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), edge.Entity)
+	require.Equal(t, uint64(100), edge.ValueId)
+
+	// Misses fall back to GetUid.
+	nq = NQuad{&protos.NQuad{Subject: "0x5", Predicate: "friend", ObjectId: "carol"}}
+	edge, err = nq.ToEdgeUsingMaps(subjects, objects)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), edge.Entity)
+	require.Equal(t, FingerprintXid("carol"), edge.ValueId)
+
+	nq = NQuad{&protos.NQuad{Subject: "0x0", Predicate: "friend", ObjectId: "carol"}}
+	_, err = nq.ToEdgeUsingMaps(subjects, objects)
+	require.Error(t, err)
+}
+
+func TestEstimateBytes(t *testing.T) {
+	since, err := facets.FacetFor("since", "2006-01-02T15:04:05")
+	require.NoError(t, err)
+	closeF, err := facets.FacetFor("close", "true")
+	require.NoError(t, err)
+	weight, err := facets.FloatFacet("weight", 0.75)
+	require.NoError(t, err)
+	nquads := []*protos.NQuad{
+		{Subject: "_:alice", Predicate: "friend", ObjectId: "_:bob"},
+		{Subject: "0x1", Predicate: "name", Lang: "en", Label: "people.rdf",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice Liddell"}}},
+		{Subject: "_:alice", Predicate: "friend", ObjectId: "_:carol",
+			Facets: []*protos.Facet{closeF, since, weight}},
+		{SubjectVar: "adults", Predicate: "age", Increment: true,
+			ObjectValue: &protos.Value{Val: &protos.Value_IntVal{IntVal: 1}}},
+		{Subject: "_:doc", Predicate: "body", ObjectValue: &protos.Value{
+			Val: &protos.Value_DefaultVal{DefaultVal: strings.Repeat("lorem ipsum ", 100)}}},
+		{Subject: "_:alice", Predicate: "friend", ObjectIds: []string{"_:bob", "_:carol",
+			"_:dave", "_:eve", "_:frank", "_:grace", "0x1234"}},
+	}
+	for _, nq := range nquads {
+		est, size := EstimateBytes(NQuad{nq}), nq.Size()
+		// Within 25%, or a few bytes for the smallest NQuads.
+		tol := size / 4
+		if tol < 8 {
+			tol = 8
+		}
+		require.InDelta(t, size, est, float64(tol), "for %+v", nq)
+	}
+
+	// Facets, langs and labels all count.
+	plain := &protos.NQuad{Subject: "_:alice", Predicate: "friend", ObjectId: "_:carol"}
+	withFacets := *plain
+	withFacets.Facets = []*protos.Facet{closeF, since, weight}
+	require.True(t, EstimateBytes(NQuad{&withFacets}) > EstimateBytes(NQuad{plain}))
+	withLang := *nquads[1]
+	withLang.Lang, withLang.Label = "", ""
+	require.Equal(t, EstimateBytes(NQuad{nquads[1]})-len("en")-len("people.rdf")-4,
+		EstimateBytes(NQuad{&withLang}))
+}
+
+func TestUpsertByPredicate(t *testing.T) {
+	email := &protos.Value{Val: &protos.Value_StrVal{StrVal: "alice@dgraph.io"}}
+	name := NQuad{&protos.NQuad{Subject: "_:user", Predicate: "name",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}}}
+	m, err := UpsertByPredicate("email", email, []NQuad{name})
+	require.NoError(t, err)
+	require.Equal(t, &Condition{Subject: "_:user", Predicate: "email", Value: email}, m.Cond)
+	require.Equal(t, 2, len(m.Set))
+	require.Equal(t, name.NQuad, m.Set[0])
+	require.Equal(t, "_:user", m.Set[1].Subject)
+	require.Equal(t, "email", m.Set[1].Predicate)
+	require.Equal(t, email, m.Set[1].ObjectValue)
+
+	// The value isn't set twice.
+	set := NQuad{&protos.NQuad{Subject: "_:user", Predicate: "email",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "alice@dgraph.io"}}}}
+	m, err = UpsertByPredicate("email", email, []NQuad{set, name})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(m.Set))
+
+	// The condition survives cloning.
+	c := m.Clone()
+	require.Equal(t, m.Cond, c.Cond)
+	c.Cond.Value.Val = &protos.Value_StrVal{StrVal: "bob@dgraph.io"}
+	require.Equal(t, "alice@dgraph.io", m.Cond.Value.GetStrVal())
+}
+
+func TestUpsertByPredicateErrors(t *testing.T) {
+	email := &protos.Value{Val: &protos.Value_StrVal{StrVal: "alice@dgraph.io"}}
+	nq := func(subject string) NQuad {
+		return NQuad{&protos.NQuad{Subject: subject, Predicate: "name",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}}}
+	}
+	_, err := UpsertByPredicate("", email, []NQuad{nq("_:user")})
+	require.Error(t, err)
+	_, err = UpsertByPredicate("email", nil, []NQuad{nq("_:user")})
+	require.Error(t, err)
+	_, err = UpsertByPredicate("email", email, nil)
+	require.Error(t, err)
+	_, err = UpsertByPredicate("email", email, []NQuad{nq("0x1")})
+	require.Error(t, err)
+	_, err = UpsertByPredicate("email", email, []NQuad{nq("_:user"), nq("_:other")})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "index 1")
+}
+
+func TestMutationResolveCond(t *testing.T) {
+	email := &protos.Value{Val: &protos.Value_StrVal{StrVal: "alice@dgraph.io"}}
+	friend := NQuad{&protos.NQuad{Subject: "_:user", Predicate: "friend", ObjectId: "0x9"}}
+	m, err := UpsertByPredicate("email", email, []NQuad{friend})
+	require.NoError(t, err)
+	m.Del = []*protos.NQuad{{Subject: "0x9", Predicate: "friend", ObjectId: "_:user"},
+		{Subject: "0x8", Predicate: "friend", ObjectIds: []string{"0x7", "_:user"}}}
+
+	found := m.Clone()
+	found.ResolveCond("0x5")
+	require.Nil(t, found.Cond)
+	require.Equal(t, "0x5", found.Set[0].Subject)
+	require.Equal(t, "0x5", found.Set[1].Subject)
+	require.Equal(t, "0x5", found.Del[0].ObjectId)
+	require.Equal(t, []string{"0x7", "0x5"}, found.Del[1].ObjectIds)
+	require.Equal(t, []string{"0x7", "_:user"}, m.Del[1].ObjectIds)
+	// The NQuads given aren't modified.
+	require.Equal(t, "_:user", friend.Subject)
+
+	m.ResolveCond("")
+	require.Nil(t, m.Cond)
+	require.Equal(t, "_:user", m.Set[0].Subject)
+}
+
+func TestConditionFunction(t *testing.T) {
+	c := &Condition{Subject: "_:user", Predicate: "email",
+		Value: &protos.Value{Val: &protos.Value_StrVal{StrVal: "alice@dgraph.io"}}}
+	fn, err := c.Function()
+	require.NoError(t, err)
+	require.Equal(t, &Function{Name: "eq", Attr: "email",
+		Args: []Arg{{Value: "alice@dgraph.io"}}}, fn)
+
+	c = &Condition{Subject: "_:user", Predicate: "age",
+		Value: &protos.Value{Val: &protos.Value_IntVal{IntVal: 13}}}
+	fn, err = c.Function()
+	require.NoError(t, err)
+	require.Equal(t, []Arg{{Value: "13"}}, fn.Args)
+
+	_, err = (&Condition{Subject: "_:user", Predicate: "email"}).Function()
+	require.Error(t, err)
+}
+
+func TestToEdgesMaxTTL(t *testing.T) {
+	withTTL := func(ttl string) *protos.NQuad {
+		f, err := facets.FacetFor(TTLFacet, ttl)
+		require.NoError(t, err)
+		return &protos.NQuad{Subject: "0x1", Predicate: "session", ObjectId: "0x2",
+			Facets: []*protos.Facet{f}}
+	}
+	var warnings []Warning
+	opts := ConvertOptions{
+		MaxTTL: 24 * time.Hour,
+		Warn:   func(w Warning) { warnings = append(warnings, w) },
+	}
+	ttlOf := func(e *protos.DirectedEdge) int64 {
+		require.Equal(t, 1, len(e.Facets))
+		return facets.ValFor(e.Facets[0]).Value.(int64)
+	}
+
+	m := Mutation{Set: []*protos.NQuad{withTTL("3600"), withTTL("86400")}}
+	edges, err := m.ToEdges(nil, opts)
+	require.NoError(t, err)
+	require.Equal(t, int64(3600), ttlOf(edges[0]))
+	require.Equal(t, int64(86400), ttlOf(edges[1]))
+	require.Empty(t, warnings)
+
+	m = Mutation{Set: []*protos.NQuad{withTTL("60"), withTTL("31536000")}}
+	edges, err = m.ToEdges(nil, opts)
+	require.NoError(t, err)
+	require.Equal(t, int64(60), ttlOf(edges[0]))
+	require.Equal(t, int64(86400), ttlOf(edges[1]))
+	require.Equal(t, 1, len(warnings))
+	require.Equal(t, 1, warnings[0].Index)
+	require.Equal(t, "ttl of 31536000s clamped to the limit of 86400s", warnings[0].Message)
+	// The NQuad is left as it was.
+	require.Equal(t, "31536000", fmt.Sprint(facets.ValFor(m.Set[1].Facets[0]).Value))
+
+	m = Mutation{Set: []*protos.NQuad{withTTL("-5")}}
+	_, err = m.ToEdges(nil, opts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "negative")
+
+	m = Mutation{Set: []*protos.NQuad{withTTL(`"soon"`)}}
+	_, err = m.ToEdges(nil, opts)
+	require.Error(t, err)
+
+	// Without a limit, nothing is checked.
+	m = Mutation{Set: []*protos.NQuad{withTTL("-5")}}
+	_, err = m.ToEdges(nil, ConvertOptions{})
+	require.NoError(t, err)
+}
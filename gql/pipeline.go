@@ -0,0 +1,114 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// ConversionPipeline converts NQuads to edges in stages, one resolving the
+// uids of the subjects and objects with GetUid and one building the edges,
+// connected by bounded channels. So a consumer reading the edges slowly makes
+// Send block, which bounds the memory used by large loads. The edges come out
+// in the order the NQuads were sent in.
+type ConversionPipeline struct {
+	nquads   chan NQuad
+	resolved chan resolvedNQuad
+	edges    chan *protos.DirectedEdge
+	// err is only written by the last stage, before closing edges.
+	err error
+}
+
+type resolvedNQuad struct {
+	nq                    NQuad
+	subject, object       uint64
+	subjectErr, objectErr error
+}
+
+// NewConversionPipeline starts a pipeline with channels holding up to bufSize
+// items between each stage.
+func NewConversionPipeline(bufSize int) *ConversionPipeline {
+	if bufSize < 0 {
+		bufSize = 0
+	}
+	p := &ConversionPipeline{
+		nquads:   make(chan NQuad, bufSize),
+		resolved: make(chan resolvedNQuad, bufSize),
+		edges:    make(chan *protos.DirectedEdge, bufSize),
+	}
+	go p.resolve()
+	go p.emit()
+	return p
+}
+
+// Send adds the NQuad to the pipeline, blocking while it's full.
+func (p *ConversionPipeline) Send(nq NQuad) {
+	p.nquads <- nq
+}
+
+// Close tells the pipeline that all the NQuads have been sent. The channel
+// returned by Edges is closed once they have all been converted.
+func (p *ConversionPipeline) Close() {
+	close(p.nquads)
+}
+
+// Edges returns the channel the edges are delivered on.
+func (p *ConversionPipeline) Edges() <-chan *protos.DirectedEdge {
+	return p.edges
+}
+
+// Err returns the error for the first NQuad which failed to convert, once the
+// channel returned by Edges is closed. NQuads after it are not converted.
+func (p *ConversionPipeline) Err() error {
+	return p.err
+}
+
+func (p *ConversionPipeline) resolve() {
+	defer close(p.resolved)
+	for nq := range p.nquads {
+		r := resolvedNQuad{nq: nq}
+		r.subject, r.subjectErr = GetUid(nq.Subject)
+		if len(nq.ObjectId) > 0 {
+			r.object, r.objectErr = GetUid(nq.ObjectId)
+		}
+		p.resolved <- r
+	}
+}
+
+func (p *ConversionPipeline) emit() {
+	defer close(p.edges)
+	idx := 0
+	for r := range p.resolved {
+		// Keep draining after a failure, so that Send doesn't block forever.
+		if p.err != nil {
+			continue
+		}
+		edge, err := r.nq.toEdgeWith(func(string) (uint64, error) {
+			return r.subject, r.subjectErr
+		}, func(string) (uint64, error) {
+			return r.object, r.objectErr
+		})
+		if err != nil {
+			p.err = x.Wrapf(err, "while converting nquad at index %d", idx)
+			continue
+		}
+		p.edges <- edge
+		idx++
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dgraph-io/dgraph/protos"
+)
+
+func TestConversionPipelineBackpressure(t *testing.T) {
+	const n = 20
+	p := NewConversionPipeline(1)
+	var sent int32
+	go func() {
+		for i := 0; i < n; i++ {
+			p.Send(NQuad{&protos.NQuad{
+				Subject:   fmt.Sprintf("_:n%d", i),
+				Predicate: "next",
+				ObjectId:  fmt.Sprintf("_:n%d", i+1),
+			}})
+			atomic.AddInt32(&sent, 1)
+		}
+		p.Close()
+	}()
+
+	// Without a consumer, the producer blocks once the stages and the
+	// channels between them are full.
+	time.Sleep(50 * time.Millisecond)
+	blockedAt := atomic.LoadInt32(&sent)
+	require.True(t, blockedAt < n, "producer sent all %d nquads", blockedAt)
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, blockedAt, atomic.LoadInt32(&sent))
+
+	var edges []*protos.DirectedEdge
+	for edge := range p.Edges() {
+		edges = append(edges, edge)
+	}
+	require.NoError(t, p.Err())
+	require.Equal(t, n, len(edges))
+	require.Equal(t, int32(n), atomic.LoadInt32(&sent))
+	for i, edge := range edges {
+		sUid, err := GetUid(fmt.Sprintf("_:n%d", i))
+		require.NoError(t, err)
+		require.Equal(t, sUid, edge.Entity)
+	}
+}
+
+func TestConversionPipelineError(t *testing.T) {
+	p := NewConversionPipeline(2)
+	go func() {
+		p.Send(NQuad{&protos.NQuad{Subject: "_:a", Predicate: "next", ObjectId: "_:b"}})
+		p.Send(NQuad{&protos.NQuad{Subject: "_:a", Predicate: "next"}})
+		p.Send(NQuad{&protos.NQuad{Subject: "_:b", Predicate: "next", ObjectId: "_:c"}})
+		p.Close()
+	}()
+	var edges []*protos.DirectedEdge
+	for edge := range p.Edges() {
+		edges = append(edges, edge)
+	}
+	require.Equal(t, 1, len(edges))
+	require.Error(t, p.Err())
+	require.Contains(t, p.Err().Error(), "index 1")
+}
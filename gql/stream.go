@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// The NQuad stream is a sequence of marshalled protos.NQuad messages, each
+// prefixed with its size as a uvarint.
+
+// maxStreamNQuadSize bounds the size read from the stream, so that a corrupt
+// size doesn't make us allocate an arbitrary amount of memory.
+const maxStreamNQuadSize = 64 << 20
+
+// WriteNQuadStream writes the NQuads to w in the format read by
+// ReadNQuadStream.
+func WriteNQuadStream(w io.Writer, nquads []NQuad) error {
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	for i, nq := range nquads {
+		sz := nq.Size()
+		buf = x.ReserveCap(buf[:0], binary.MaxVarintLen64+sz)
+		buf = x.AppendUvarint(buf, uint64(sz))
+		n, err := nq.MarshalTo(buf[len(buf) : len(buf)+sz])
+		if err != nil {
+			return x.Wrapf(err, "while marshalling nquad at index %d", i)
+		}
+		if _, err := bw.Write(buf[:len(buf)+n]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadNQuadStream reads the NQuads written by WriteNQuadStream from r, until
+// its end. A stream which ends in the middle of an NQuad is an error.
+func ReadNQuadStream(r io.Reader) ([]NQuad, error) {
+	br := bufio.NewReader(r)
+	var nquads []NQuad
+	for {
+		sz, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nquads, nil
+		}
+		if err != nil {
+			return nil, x.Wrapf(err, "while reading size of nquad at index %d", len(nquads))
+		}
+		if sz > maxStreamNQuadSize {
+			return nil, x.Errorf("Size %d of nquad at index %d is over the limit of %d", sz,
+				len(nquads), maxStreamNQuadSize)
+		}
+		// Unmarshalled bytes fields may point into buf, so it can't be reused.
+		buf := make([]byte, sz)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, x.Wrapf(err, "while reading nquad at index %d", len(nquads))
+		}
+		nq := new(protos.NQuad)
+		if err := nq.Unmarshal(buf); err != nil {
+			return nil, x.Wrapf(err, "while unmarshalling nquad at index %d", len(nquads))
+		}
+		nquads = append(nquads, NQuad{nq})
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gql
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/types/facets"
+)
+
+func streamTestNQuads(t *testing.T) []NQuad {
+	since, err := facets.FacetFor("since", "2006-01-02T15:04:05")
+	require.NoError(t, err)
+	return []NQuad{
+		{&protos.NQuad{Subject: "_:alice", Predicate: "friend", ObjectId: "_:bob",
+			Facets: []*protos.Facet{since}}},
+		{&protos.NQuad{Subject: "_:alice", Predicate: "name", Lang: "en",
+			ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}}},
+		{&protos.NQuad{Subject: "0x1", Predicate: "avatar",
+			ObjectValue: &protos.Value{Val: &protos.Value_BytesVal{BytesVal: []byte{0, 1, 2}}}}},
+		{&protos.NQuad{SubjectVar: "v", Predicate: "visits", Increment: true,
+			ObjectValue: &protos.Value{Val: &protos.Value_IntVal{IntVal: 1}}}},
+		{&protos.NQuad{Subject: "0x1", Predicate: "score", DefaultLang: true,
+			ObjectValue: &protos.Value{Val: &protos.Value_DoubleVal{DoubleVal: 0.25}}}},
+		{&protos.NQuad{Subject: "0x1", Predicate: "member", ObjectIds: []string{"0x2", "_:c"}}},
+		{&protos.NQuad{Subject: "0x1", Predicate: "seen", Label: "import",
+			ObjectValue: &protos.Value{Val: &protos.Value_DatetimeVal{DatetimeVal: []byte{1, 0}}}}},
+	}
+}
+
+func TestNQuadStreamRoundTrip(t *testing.T) {
+	nqs := streamTestNQuads(t)
+	var buf bytes.Buffer
+	require.NoError(t, WriteNQuadStream(&buf, nqs))
+	out, err := ReadNQuadStream(&buf)
+	require.NoError(t, err)
+	require.Equal(t, len(nqs), len(out))
+	for i := range nqs {
+		require.True(t, nqs[i].Equals(out[i]), "nquad at index %d: %v != %v", i, nqs[i], out[i])
+	}
+
+	buf.Reset()
+	require.NoError(t, WriteNQuadStream(&buf, nil))
+	out, err = ReadNQuadStream(&buf)
+	require.NoError(t, err)
+	require.Empty(t, out)
+}
+
+func TestNQuadStreamTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteNQuadStream(&buf, streamTestNQuads(t)))
+	b := buf.Bytes()
+	// Cutting the stream anywhere but between NQuads must fail.
+	for _, cut := range []int{1, 5, len(b) - 1} {
+		_, err := ReadNQuadStream(bytes.NewReader(b[:cut]))
+		require.Error(t, err, "stream cut at %d", cut)
+	}
+
+	// A size over the limit.
+	_, err := ReadNQuadStream(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x0f}))
+	require.Error(t, err)
+}
@@ -305,6 +305,12 @@ func (txn *Txn) updateCount(ctx context.Context, params countParams) error {
 	return nil
 }
 
+// errNotApplied is returned by addMutationHelper when a delete wasn't applied,
+// as the edge didn't satisfy its facet condition or the value stored wasn't
+// the one deleted, when there was no edge to delete facets from or to mark as
+// a tombstone, or when a create only set found a value already.
+var errNotApplied = x.Errorf("Mutation didn't apply to any edge")
+
 func (txn *Txn) addMutationHelper(ctx context.Context, l *List, doUpdateIndex bool,
 	hasCountIndex bool, t *protos.DirectedEdge) (types.Val, bool, countParams, error) {
 	var val types.Val
@@ -338,10 +344,14 @@ func (txn *Txn) addMutationHelper(ctx context.Context, l *List, doUpdateIndex bo
 			return val, found, emptyCountParams, ErrTsTooOld
 		}
 	}
-	_, err = l.addMutation(ctx, txn, t)
+	mutated, err := l.addMutation(ctx, txn, t)
 	if err != nil {
 		return val, found, emptyCountParams, err
 	}
+	if !mutated && (len(t.FacetCondOp) > 0 || len(t.DelFacets) > 0 || t.CreateOnly ||
+		t.Tombstone || deletesSharedValue(t)) {
+		return val, found, emptyCountParams, errNotApplied
+	}
 	if hasCountIndex {
 		countAfter = l.length(txn.StartTs, 0)
 		if countAfter == -1 {
@@ -373,6 +383,10 @@ func (l *List) AddMutationWithIndex(ctx context.Context, t *protos.DirectedEdge,
 	doUpdateIndex := pstore != nil && (t.Value != nil) && schema.State().IsIndexed(t.Attr)
 	hasCountIndex := schema.State().HasCount(t.Attr)
 	val, found, cp, err := txn.addMutationHelper(ctx, l, doUpdateIndex, hasCountIndex, t)
+	if err == errNotApplied {
+		// Nothing changed, so the indexes stay as they are.
+		return nil
+	}
 	if err != nil {
 		return err
 	}
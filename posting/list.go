@@ -221,6 +221,8 @@ func NewPosting(t *protos.DirectedEdge) *protos.Posting {
 		Label:       t.Label,
 		Op:          op,
 		Facets:      t.Facets,
+		DefaultLang: t.DefaultLang && postingType == protos.Posting_VALUE,
+		Tombstone:   t.Tombstone && op == Set,
 	}
 }
 
@@ -383,6 +385,56 @@ func (l *List) addMutation(ctx context.Context, txn *Txn, t *protos.DirectedEdge
 		return false, err
 	}
 
+	if deletesSharedValue(t) {
+		// Only delete the value stored if it's the one given.
+		found, p, err := l.findPosting(txn.StartTs, t.ValueId)
+		if err != nil || !found || !bytes.Equal(p.Value, t.Value) {
+			return false, err
+		}
+	}
+
+	if t.Op == protos.DirectedEdge_SET && len(t.DelFacets) > 0 {
+		found, p, err := l.findPosting(txn.StartTs, t.ValueId)
+		if err != nil || !found {
+			return false, err
+		}
+		// Keep the edge as it is, without the facets.
+		t.Value, t.ValueType = p.Value, p.ValType
+		t.Facets = facetsWithout(p.Facets, t.DelFacets)
+	}
+
+	if t.Op == protos.DirectedEdge_SET && t.CreateOnly {
+		exists, err := l.hasValueFor(txn.StartTs, t)
+		if err != nil || exists {
+			return false, err
+		}
+	}
+
+	if t.Op == protos.DirectedEdge_DEL && len(t.FacetCondOp) > 0 {
+		ok, err := l.satisfiesFacetCond(txn.StartTs, t)
+		if err != nil || !ok {
+			return false, err
+		}
+		// The facets only held the condition.
+		t.Facets = nil
+	}
+
+	if t.Op == protos.DirectedEdge_DEL && t.Tombstone {
+		found, p, err := l.findPosting(txn.StartTs, t.ValueId)
+		if err != nil || !found {
+			return false, err
+		}
+		// Keep the edge as it is, with the facets recording the delete. The
+		// edge given stays a delete, for the indexes and the reverse edge.
+		tomb := *t
+		tomb.Op = protos.DirectedEdge_SET
+		tomb.Value, tomb.ValueType = p.Value, p.ValType
+		if tomb.Facets, err = tombstoneFacets(p.Facets, t.Facets); err != nil {
+			return false, err
+		}
+		t = &tomb
+	}
+
 	mpost := NewPosting(t)
 	mpost.StartTs = txn.StartTs
 	t1 := time.Now()
@@ -398,6 +450,77 @@ func (l *List) addMutation(ctx context.Context, txn *Txn, t *protos.DirectedEdge
 	return hasMutated, nil
 }
 
+// deletesSharedValue returns whether t deletes a value from a posting which
+// holds whatever value the subject has, as values of single valued predicates
+// and values in a language share one posting.
+func deletesSharedValue(t *protos.DirectedEdge) bool {
+	return t.Op == protos.DirectedEdge_DEL && t.Value != nil &&
+		!bytes.Equal(t.Value, []byte(x.Star)) &&
+		(len(t.Lang) > 0 || !schema.State().IsList(t.Attr))
+}
+
+// hasValueFor returns whether the subject already has a value for the create
+// only set edge t. Values of single valued predicates are looked up in the
+// language of the edge, while list and uid predicates have a value if they
+// have any posting.
+func (l *List) hasValueFor(readTs uint64, t *protos.DirectedEdge) (bool, error) {
+	if t.Value != nil && !schema.State().IsList(t.Attr) {
+		found, _, err := l.findPosting(readTs, t.ValueId)
+		return found, err
+	}
+	n := l.length(readTs, 0)
+	if n == -1 {
+		return false, ErrTsTooOld
+	}
+	return n > 0, nil
+}
+
+// satisfiesFacetCond returns whether the posting deleted by the edge has a
+// facet which satisfies the condition carried by the edge.
+func (l *List) satisfiesFacetCond(readTs uint64, t *protos.DirectedEdge) (bool, error) {
+	if !facets.IsConditionOp(t.FacetCondOp) || len(t.Facets) != 1 {
+		return false, x.Errorf("Invalid facet condition %s with %d facets for edge: %+v",
+			t.FacetCondOp, len(t.Facets), t)
+	}
+	found, p, err := l.findPosting(readTs, t.ValueId)
+	if err != nil || !found {
+		return false, err
+	}
+	return facets.SatisfiesCondition(t.FacetCondOp, p.Facets, t.Facets[0]), nil
+}
+
+// tombstoneFacets returns the facets of the posting with those of the
+// tombstone added, replacing the ones left by an earlier tombstone.
+func tombstoneFacets(fs, tombstone []*protos.Facet) ([]*protos.Facet, error) {
+	keys := make([]string, 0, len(tombstone))
+	for _, f := range tombstone {
+		keys = append(keys, f.Key)
+	}
+	out := append(facetsWithout(fs, keys), tombstone...)
+	if err := facets.SortAndValidate(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// facetsWithout returns the facets, leaving out those with the given keys.
+func facetsWithout(fs []*protos.Facet, keys []string) []*protos.Facet {
+	out := make([]*protos.Facet, 0, len(fs))
+	for _, f := range fs {
+		drop := false
+		for _, key := range keys {
+			if f.Key == key {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
 func (l *List) AbortTransaction(ctx context.Context, startTs uint64) error {
 	l.Lock()
 	defer l.Unlock()
@@ -533,7 +656,16 @@ func (l *List) inSnapshot(mpost *protos.Posting, readTs, deleteTs uint64) bool {
 	return mpost.CommitTs <= readTs && mpost.CommitTs >= deleteTs
 }
 
+// iterate is iterateAll without the tombstones, which are deleted edges to
+// everything but rollups.
 func (l *List) iterate(readTs uint64, afterUid uint64, f func(obj *protos.Posting) bool) error {
+	return l.iterateAll(readTs, afterUid, func(p *protos.Posting) bool {
+		return p.Tombstone || f(p)
+	})
+}
+
+func (l *List) iterateAll(readTs uint64, afterUid uint64,
+	f func(obj *protos.Posting) bool) error {
 	l.AssertRLock()
 	midx := 0
 	var deleteTs uint64
@@ -684,7 +816,7 @@ func (l *List) rollup() error {
 
 	// Pick all committed entries
 	x.AssertTrue(l.minTs <= l.commitTs)
-	err := l.iterate(l.commitTs, 0, func(p *protos.Posting) bool {
+	err := l.iterateAll(l.commitTs, 0, func(p *protos.Posting) bool {
 		if p.CommitTs == 0 || p.CommitTs > l.commitTs {
 			return true
 		}
@@ -814,7 +946,7 @@ func (l *List) Uids(opt ListOptions) (*protos.List, error) {
 	// Use approximate length for initial capacity.
 	res := make([]uint64, 0, len(l.mlayer)+bp128.NumIntegers(l.plist.Uids))
 	out := &protos.List{}
-	if len(l.mlayer) == 0 && opt.Intersect != nil {
+	if len(l.mlayer) == 0 && opt.Intersect != nil && !hasTombstone(l.plist) {
 		if opt.ReadTs < l.minTs {
 			l.RUnlock()
 			return out, ErrTsTooOld
@@ -843,6 +975,17 @@ func (l *List) Uids(opt ListOptions) (*protos.List, error) {
 	return out, nil
 }
 
+// hasTombstone returns whether the posting list has a tombstone, whose uid is
+// in the list of uids of the posting list like any other.
+func hasTombstone(plist *protos.PostingList) bool {
+	for _, p := range plist.Postings {
+		if p.Tombstone {
+			return true
+		}
+	}
+	return false
+}
+
 // Postings calls postFn with the postings that are common with
 // uids in the opt ListOptions.
 func (l *List) Postings(opt ListOptions, postFn func(*protos.Posting) bool) error {
@@ -891,6 +1034,8 @@ func (l *List) Value(readTs uint64) (rval types.Val, rerr error) {
 // smallest Uid is returned.
 // If list consists of one or more languages, first available value is returned; if no language
 // from list match the values, processing is the same as for empty list.
+// Without ".", the value without language is only returned for a list of languages if it was
+// set as the default for all the languages.
 func (l *List) ValueFor(readTs uint64, langs []string) (rval types.Val, rerr error) {
 	p, err := l.postingFor(readTs, langs)
 	if err != nil {
@@ -940,12 +1085,10 @@ func (l *List) postingForLangs(readTs uint64, langs []string) (pos *protos.Posti
 	}
 
 	// look for value without language
-	if any || len(langs) == 0 {
-		if found, pos, err := l.findPosting(readTs, math.MaxUint64); err != nil {
-			return nil, err
-		} else if found {
-			return pos, nil
-		}
+	if found, pos, err := l.findPosting(readTs, math.MaxUint64); err != nil {
+		return nil, err
+	} else if found && (any || len(langs) == 0 || pos.DefaultLang) {
+		return pos, nil
 	}
 
 	var found bool
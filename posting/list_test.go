@@ -31,6 +31,7 @@ import (
 
 	"github.com/dgraph-io/dgraph/protos"
 	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/types/facets"
 	"github.com/dgraph-io/dgraph/x"
 )
 
@@ -722,3 +723,238 @@ func BenchmarkAddMutations(b *testing.B) {
 		}
 	}
 }
+
+func TestAddMutation_DelFacetCond(t *testing.T) {
+	key := x.DataKey("friend", 11)
+	l := Get(key)
+
+	weight := func(v string) []*protos.Facet {
+		f, err := facets.FacetFor("weight", v)
+		require.NoError(t, err)
+		return []*protos.Facet{f}
+	}
+	txn := &Txn{StartTs: 1}
+	for uid, w := range map[uint64]string{2: "0.05", 3: "0.5"} {
+		addMutationHelper(t, l, &protos.DirectedEdge{ValueId: uid, Facets: weight(w)}, Set, txn)
+	}
+	require.NoError(t, l.CommitMutation(context.Background(), 1, 2))
+
+	txn = &Txn{StartTs: 3}
+	for _, uid := range []uint64{2, 3} {
+		edge := &protos.DirectedEdge{ValueId: uid, Facets: weight("0.1"), FacetCondOp: "lt"}
+		addMutationHelper(t, l, edge, Del, txn)
+	}
+	require.NoError(t, l.CommitMutation(context.Background(), 3, 4))
+	require.Equal(t, []uint64{3}, listToArray(t, 0, l, 5))
+
+	edge := &protos.DirectedEdge{ValueId: 3, Facets: weight("0.1"), FacetCondOp: "like",
+		Op: protos.DirectedEdge_DEL}
+	_, err := l.AddMutation(context.Background(), &Txn{StartTs: 6}, edge)
+	require.Error(t, err)
+}
+
+func TestAddMutation_DelFacetCondTypes(t *testing.T) {
+	key := x.DataKey("friend", 13)
+	l := Get(key)
+
+	weight := func(v string) []*protos.Facet {
+		f, err := facets.FacetFor("weight", v)
+		require.NoError(t, err)
+		return []*protos.Facet{f}
+	}
+	txn := &Txn{StartTs: 1}
+	for uid, w := range map[uint64]string{2: "0.5", 3: "3", 4: "7"} {
+		addMutationHelper(t, l, &protos.DirectedEdge{ValueId: uid, Facets: weight(w)}, Set, txn)
+	}
+	require.NoError(t, l.CommitMutation(context.Background(), 1, 2))
+
+	// An int condition against a float facet.
+	txn = &Txn{StartTs: 3}
+	for _, uid := range []uint64{2, 3, 4} {
+		edge := &protos.DirectedEdge{ValueId: uid, Facets: weight("1"), FacetCondOp: "lt"}
+		addMutationHelper(t, l, edge, Del, txn)
+	}
+	require.NoError(t, l.CommitMutation(context.Background(), 3, 4))
+	require.Equal(t, []uint64{3, 4}, listToArray(t, 0, l, 5))
+
+	// A float condition against an int facet keeps its fraction.
+	txn = &Txn{StartTs: 5}
+	for _, uid := range []uint64{3, 4} {
+		edge := &protos.DirectedEdge{ValueId: uid, Facets: weight("3.5"), FacetCondOp: "lt"}
+		addMutationHelper(t, l, edge, Del, txn)
+	}
+	require.NoError(t, l.CommitMutation(context.Background(), 5, 6))
+	require.Equal(t, []uint64{4}, listToArray(t, 0, l, 7))
+}
+
+func TestAddMutation_DelFacets(t *testing.T) {
+	key := x.DataKey("friend", 12)
+	l := Get(key)
+
+	facet := func(k, v string) *protos.Facet {
+		f, err := facets.FacetFor(k, v)
+		require.NoError(t, err)
+		return f
+	}
+	txn := &Txn{StartTs: 1}
+	edge := &protos.DirectedEdge{ValueId: 2,
+		Facets: []*protos.Facet{facet("close", "true"), facet("since", "2006")}}
+	addMutationHelper(t, l, edge, Set, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 1, 2))
+
+	txn = &Txn{StartTs: 3}
+	addMutationHelper(t, l, &protos.DirectedEdge{ValueId: 2, DelFacets: []string{"close"}},
+		Set, txn)
+	// There is no edge to 3, so nothing is added.
+	addMutationHelper(t, l, &protos.DirectedEdge{ValueId: 3, DelFacets: []string{"close"}},
+		Set, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 3, 4))
+	require.Equal(t, []uint64{2}, listToArray(t, 0, l, 5))
+
+	var fs []*protos.Facet
+	require.NoError(t, l.Iterate(5, 0, func(p *protos.Posting) bool {
+		fs = p.Facets
+		return false
+	}))
+	require.Equal(t, 1, len(fs))
+	require.Equal(t, "since", fs[0].Key)
+}
+
+func TestAddMutation_Tombstone(t *testing.T) {
+	key := x.DataKey("friend", 14)
+	l := Get(key)
+
+	facet := func(k, v string) *protos.Facet {
+		f, err := facets.FacetFor(k, v)
+		require.NoError(t, err)
+		return f
+	}
+	txn := &Txn{StartTs: 1}
+	addMutationHelper(t, l, &protos.DirectedEdge{ValueId: 2,
+		Facets: []*protos.Facet{facet("since", "2006")}}, Set, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 1, 2))
+
+	txn = &Txn{StartTs: 3}
+	edge := &protos.DirectedEdge{ValueId: 2, Tombstone: true,
+		Facets: []*protos.Facet{facet("deletedBy", `"auditor"`)}}
+	addMutationHelper(t, l, edge, Del, txn)
+	require.Equal(t, protos.DirectedEdge_DEL, edge.Op)
+	// There is no edge to 3, so there's nothing to mark.
+	addMutationHelper(t, l, &protos.DirectedEdge{ValueId: 3, Tombstone: true,
+		Facets: []*protos.Facet{facet("deletedBy", `"auditor"`)}}, Del, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 3, 4))
+	// Reads don't see the edge, which is only kept with the facets of the
+	// tombstone added, even after a rollup.
+	_, err := l.SyncIfDirty(false)
+	require.NoError(t, err)
+	require.Empty(t, listToArray(t, 0, l, 5))
+	uids, err := l.Uids(ListOptions{ReadTs: 5, Intersect: &protos.List{Uids: []uint64{2}}})
+	require.NoError(t, err)
+	require.Empty(t, uids.Uids)
+
+	var fs []*protos.Facet
+	l.RLock()
+	require.NoError(t, l.iterateAll(5, 0, func(p *protos.Posting) bool {
+		require.True(t, p.Tombstone)
+		fs = p.Facets
+		return false
+	}))
+	l.RUnlock()
+	require.Equal(t, 2, len(fs))
+	require.Equal(t, "deletedBy", fs[0].Key)
+	require.Equal(t, "since", fs[1].Key)
+
+	// A set brings the edge back.
+	txn = &Txn{StartTs: 5}
+	addMutationHelper(t, l, &protos.DirectedEdge{ValueId: 2}, Set, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 5, 6))
+	require.Equal(t, []uint64{2}, listToArray(t, 0, l, 7))
+}
+
+func TestValueForDefaultLang(t *testing.T) {
+	// A value without language is only returned for other languages with ".".
+	l := Get(x.DataKey("title", 15))
+	txn := &Txn{StartTs: 1}
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("Boss")}, Set, txn)
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("Chef"), Lang: "fr"}, Set, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 1, 2))
+
+	val, err := l.ValueFor(3, []string{"fr"})
+	require.NoError(t, err)
+	require.EqualValues(t, "Chef", val.Value)
+	_, err = l.ValueFor(3, []string{"en"})
+	require.Equal(t, ErrNoValue, err)
+	val, err = l.ValueFor(3, []string{"en", "."})
+	require.NoError(t, err)
+	require.EqualValues(t, "Boss", val.Value)
+
+	// The default for all the languages is returned when none of them has a value.
+	l = Get(x.DataKey("title", 16))
+	txn = &Txn{StartTs: 1}
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("Boss"), DefaultLang: true},
+		Set, txn)
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("Chef"), Lang: "fr"}, Set, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 1, 2))
+
+	val, err = l.ValueFor(3, []string{"fr"})
+	require.NoError(t, err)
+	require.EqualValues(t, "Chef", val.Value)
+	val, err = l.ValueFor(3, []string{"en"})
+	require.NoError(t, err)
+	require.EqualValues(t, "Boss", val.Value)
+	val, err = l.ValueFor(3, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, "Boss", val.Value)
+}
+
+func TestAddMutation_CreateOnly(t *testing.T) {
+	l := Get(x.DataKey("nick", 13))
+	txn := &Txn{StartTs: 1}
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("al"), CreateOnly: true},
+		Set, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 1, 2))
+	checkValue(t, l, "al", 3)
+
+	// There is a value already, so the create only set is skipped.
+	txn = &Txn{StartTs: 3}
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("ally"), CreateOnly: true},
+		Set, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 3, 4))
+	checkValue(t, l, "al", 5)
+
+	// A normal set replaces it.
+	txn = &Txn{StartTs: 5}
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("ally")}, Set, txn)
+	checkValue(t, l, "ally", 5)
+}
+
+func TestAddMutation_DelValue(t *testing.T) {
+	l := Get(x.DataKey("nick", 14))
+	txn := &Txn{StartTs: 1}
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("al")}, Set, txn)
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("alice"), Lang: "en"},
+		Set, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 1, 2))
+
+	// Deleting another value leaves the stored ones alone.
+	txn = &Txn{StartTs: 3}
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("bob")}, Del, txn)
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("al"), Lang: "en"}, Del, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 3, 4))
+	val, err := l.Value(5)
+	require.NoError(t, err)
+	require.EqualValues(t, "al", val.Value)
+	val, err = l.ValueForTag(5, "en")
+	require.NoError(t, err)
+	require.EqualValues(t, "alice", val.Value)
+
+	txn = &Txn{StartTs: 5}
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("al")}, Del, txn)
+	addMutationHelper(t, l, &protos.DirectedEdge{Value: []byte("alice"), Lang: "en"},
+		Del, txn)
+	require.NoError(t, l.CommitMutation(context.Background(), 5, 6))
+	_, err = l.Value(7)
+	require.Equal(t, ErrNoValue, err)
+	_, err = l.ValueForTag(7, "en")
+	require.Error(t, err)
+}
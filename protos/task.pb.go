@@ -93,15 +93,18 @@ type DirectedEdge_Op int32
 const (
 	DirectedEdge_SET DirectedEdge_Op = 0
 	DirectedEdge_DEL DirectedEdge_Op = 1
+	DirectedEdge_INC DirectedEdge_Op = 2
 )
 
 var DirectedEdge_Op_name = map[int32]string{
 	0: "SET",
 	1: "DEL",
+	2: "INC",
 }
 var DirectedEdge_Op_value = map[string]int32{
 	"SET": 0,
 	"DEL": 1,
+	"INC": 2,
 }
 
 func (x DirectedEdge_Op) String() string {
@@ -122,19 +125,33 @@ const (
 	Posting_UID      Posting_ValType = 7
 	Posting_PASSWORD Posting_ValType = 8
 	Posting_STRING   Posting_ValType = 9
+	Posting_URI      Posting_ValType = 10
+	Posting_JSON     Posting_ValType = 11
+	Posting_IP       Posting_ValType = 12
+	Posting_CIDR     Posting_ValType = 13
+	Posting_MONEY    Posting_ValType = 14
+	Posting_UUID     Posting_ValType = 15
+	Posting_RANGE    Posting_ValType = 16
 )
 
 var Posting_ValType_name = map[int32]string{
-	0: "DEFAULT",
-	1: "BINARY",
-	2: "INT",
-	3: "FLOAT",
-	4: "BOOL",
-	5: "DATETIME",
-	6: "GEO",
-	7: "UID",
-	8: "PASSWORD",
-	9: "STRING",
+	0:  "DEFAULT",
+	1:  "BINARY",
+	2:  "INT",
+	3:  "FLOAT",
+	4:  "BOOL",
+	5:  "DATETIME",
+	6:  "GEO",
+	7:  "UID",
+	8:  "PASSWORD",
+	9:  "STRING",
+	10: "URI",
+	11: "JSON",
+	12: "IP",
+	13: "CIDR",
+	14: "MONEY",
+	15: "UUID",
+	16: "RANGE",
 }
 var Posting_ValType_value = map[string]int32{
 	"DEFAULT":  0,
@@ -147,6 +164,13 @@ var Posting_ValType_value = map[string]int32{
 	"UID":      7,
 	"PASSWORD": 8,
 	"STRING":   9,
+	"URI":      10,
+	"JSON":     11,
+	"IP":       12,
+	"CIDR":     13,
+	"MONEY":    14,
+	"UUID":     15,
+	"RANGE":    16,
 }
 
 func (x Posting_ValType) String() string {
@@ -953,15 +977,20 @@ func (m *Tablet) GetSpace() int64 {
 }
 
 type DirectedEdge struct {
-	Entity    uint64          `protobuf:"fixed64,1,opt,name=entity,proto3" json:"entity,omitempty"`
-	Attr      string          `protobuf:"bytes,2,opt,name=attr,proto3" json:"attr,omitempty"`
-	Value     []byte          `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
-	ValueType Posting_ValType `protobuf:"varint,4,opt,name=value_type,json=valueType,proto3,enum=protos.Posting_ValType" json:"value_type,omitempty"`
-	ValueId   uint64          `protobuf:"fixed64,5,opt,name=value_id,json=valueId,proto3" json:"value_id,omitempty"`
-	Label     string          `protobuf:"bytes,6,opt,name=label,proto3" json:"label,omitempty"`
-	Lang      string          `protobuf:"bytes,7,opt,name=lang,proto3" json:"lang,omitempty"`
-	Op        DirectedEdge_Op `protobuf:"varint,8,opt,name=op,proto3,enum=protos.DirectedEdge_Op" json:"op,omitempty"`
-	Facets    []*Facet        `protobuf:"bytes,9,rep,name=facets" json:"facets,omitempty"`
+	Entity      uint64          `protobuf:"fixed64,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	Attr        string          `protobuf:"bytes,2,opt,name=attr,proto3" json:"attr,omitempty"`
+	Value       []byte          `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	ValueType   Posting_ValType `protobuf:"varint,4,opt,name=value_type,json=valueType,proto3,enum=protos.Posting_ValType" json:"value_type,omitempty"`
+	ValueId     uint64          `protobuf:"fixed64,5,opt,name=value_id,json=valueId,proto3" json:"value_id,omitempty"`
+	Label       string          `protobuf:"bytes,6,opt,name=label,proto3" json:"label,omitempty"`
+	Lang        string          `protobuf:"bytes,7,opt,name=lang,proto3" json:"lang,omitempty"`
+	Op          DirectedEdge_Op `protobuf:"varint,8,opt,name=op,proto3,enum=protos.DirectedEdge_Op" json:"op,omitempty"`
+	Facets      []*Facet        `protobuf:"bytes,9,rep,name=facets" json:"facets,omitempty"`
+	Tombstone   bool            `protobuf:"varint,10,opt,name=tombstone,proto3" json:"tombstone,omitempty"`
+	FacetCondOp string          `protobuf:"bytes,11,opt,name=facet_cond_op,json=facetCondOp,proto3" json:"facet_cond_op,omitempty"`
+	DefaultLang bool            `protobuf:"varint,12,opt,name=default_lang,json=defaultLang,proto3" json:"default_lang,omitempty"`
+	DelFacets   []string        `protobuf:"bytes,13,rep,name=del_facets,json=delFacets" json:"del_facets,omitempty"`
+	CreateOnly  bool            `protobuf:"varint,15,opt,name=create_only,json=createOnly,proto3" json:"create_only,omitempty"`
 }
 
 func (m *DirectedEdge) Reset()                    { *m = DirectedEdge{} }
@@ -1032,6 +1061,41 @@ func (m *DirectedEdge) GetFacets() []*Facet {
 	return nil
 }
 
+func (m *DirectedEdge) GetTombstone() bool {
+	if m != nil {
+		return m.Tombstone
+	}
+	return false
+}
+
+func (m *DirectedEdge) GetFacetCondOp() string {
+	if m != nil {
+		return m.FacetCondOp
+	}
+	return ""
+}
+
+func (m *DirectedEdge) GetDefaultLang() bool {
+	if m != nil {
+		return m.DefaultLang
+	}
+	return false
+}
+
+func (m *DirectedEdge) GetDelFacets() []string {
+	if m != nil {
+		return m.DelFacets
+	}
+	return nil
+}
+
+func (m *DirectedEdge) GetCreateOnly() bool {
+	if m != nil {
+		return m.CreateOnly
+	}
+	return false
+}
+
 type Mutations struct {
 	GroupId uint32          `protobuf:"varint,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
 	StartTs uint64          `protobuf:"varint,2,opt,name=start_ts,json=startTs,proto3" json:"start_ts,omitempty"`
@@ -1250,9 +1314,11 @@ type Posting struct {
 	Label       string              `protobuf:"bytes,6,opt,name=label,proto3" json:"label,omitempty"`
 	Facets      []*Facet            `protobuf:"bytes,9,rep,name=facets" json:"facets,omitempty"`
 	// TODO: op is only used temporarily. See if we can remove it from here.
-	Op       uint32 `protobuf:"varint,12,opt,name=op,proto3" json:"op,omitempty"`
-	StartTs  uint64 `protobuf:"varint,13,opt,name=start_ts,json=startTs,proto3" json:"start_ts,omitempty"`
-	CommitTs uint64 `protobuf:"varint,14,opt,name=commit_ts,json=commitTs,proto3" json:"commit_ts,omitempty"`
+	Op          uint32 `protobuf:"varint,12,opt,name=op,proto3" json:"op,omitempty"`
+	StartTs     uint64 `protobuf:"varint,13,opt,name=start_ts,json=startTs,proto3" json:"start_ts,omitempty"`
+	CommitTs    uint64 `protobuf:"varint,14,opt,name=commit_ts,json=commitTs,proto3" json:"commit_ts,omitempty"`
+	DefaultLang bool   `protobuf:"varint,15,opt,name=default_lang,json=defaultLang,proto3" json:"default_lang,omitempty"`
+	Tombstone   bool   `protobuf:"varint,16,opt,name=tombstone,proto3" json:"tombstone,omitempty"`
 }
 
 func (m *Posting) Reset()                    { *m = Posting{} }
@@ -1330,6 +1396,20 @@ func (m *Posting) GetCommitTs() uint64 {
 	return 0
 }
 
+func (m *Posting) GetDefaultLang() bool {
+	if m != nil {
+		return m.DefaultLang
+	}
+	return false
+}
+
+func (m *Posting) GetTombstone() bool {
+	if m != nil {
+		return m.Tombstone
+	}
+	return false
+}
+
 type PostingList struct {
 	Postings []*Posting `protobuf:"bytes,1,rep,name=postings" json:"postings,omitempty"`
 	Checksum []byte     `protobuf:"bytes,2,opt,name=checksum,proto3" json:"checksum,omitempty"`
@@ -1664,6 +1744,7 @@ type SchemaUpdate struct {
 	Count     bool                   `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
 	List      bool                   `protobuf:"varint,6,opt,name=list,proto3" json:"list,omitempty"`
 	Explicit  bool                   `protobuf:"varint,7,opt,name=explicit,proto3" json:"explicit,omitempty"`
+	Upsert    bool                   `protobuf:"varint,8,opt,name=upsert,proto3" json:"upsert,omitempty"`
 }
 
 func (m *SchemaUpdate) Reset()                    { *m = SchemaUpdate{} }
@@ -1720,6 +1801,13 @@ func (m *SchemaUpdate) GetExplicit() bool {
 	return false
 }
 
+func (m *SchemaUpdate) GetUpsert() bool {
+	if m != nil {
+		return m.Upsert
+	}
+	return false
+}
+
 // Bulk loader proto.
 type MapEntry struct {
 	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -2032,6 +2120,14 @@ type NQuad struct {
 	Facets      []*Facet `protobuf:"bytes,7,rep,name=facets" json:"facets,omitempty"`
 	SubjectVar  string   `protobuf:"bytes,8,opt,name=subject_var,json=subjectVar,proto3" json:"subject_var,omitempty"`
 	ObjectVar   string   `protobuf:"bytes,9,opt,name=object_var,json=objectVar,proto3" json:"object_var,omitempty"`
+	Increment   bool     `protobuf:"varint,10,opt,name=increment,proto3" json:"increment,omitempty"`
+	ObjectIds   []string `protobuf:"bytes,11,rep,name=object_ids,json=objectIds" json:"object_ids,omitempty"`
+	DefaultLang bool     `protobuf:"varint,12,opt,name=default_lang,json=defaultLang,proto3" json:"default_lang,omitempty"`
+	CreateOnly  bool     `protobuf:"varint,13,opt,name=create_only,json=createOnly,proto3" json:"create_only,omitempty"`
+	FacetCondOp string   `protobuf:"bytes,14,opt,name=facet_cond_op,json=facetCondOp,proto3" json:"facet_cond_op,omitempty"`
+	DelFacets   []string `protobuf:"bytes,15,rep,name=del_facets,json=delFacets" json:"del_facets,omitempty"`
+	Tombstone   bool     `protobuf:"varint,16,opt,name=tombstone,proto3" json:"tombstone,omitempty"`
+	DeletedBy   string   `protobuf:"bytes,17,opt,name=deleted_by,json=deletedBy,proto3" json:"deleted_by,omitempty"`
 }
 
 func (m *NQuad) Reset()                    { *m = NQuad{} }
@@ -2102,6 +2198,62 @@ func (m *NQuad) GetObjectVar() string {
 	return ""
 }
 
+func (m *NQuad) GetIncrement() bool {
+	if m != nil {
+		return m.Increment
+	}
+	return false
+}
+
+func (m *NQuad) GetObjectIds() []string {
+	if m != nil {
+		return m.ObjectIds
+	}
+	return nil
+}
+
+func (m *NQuad) GetDefaultLang() bool {
+	if m != nil {
+		return m.DefaultLang
+	}
+	return false
+}
+
+func (m *NQuad) GetCreateOnly() bool {
+	if m != nil {
+		return m.CreateOnly
+	}
+	return false
+}
+
+func (m *NQuad) GetFacetCondOp() string {
+	if m != nil {
+		return m.FacetCondOp
+	}
+	return ""
+}
+
+func (m *NQuad) GetDelFacets() []string {
+	if m != nil {
+		return m.DelFacets
+	}
+	return nil
+}
+
+func (m *NQuad) GetTombstone() bool {
+	if m != nil {
+		return m.Tombstone
+	}
+	return false
+}
+
+func (m *NQuad) GetDeletedBy() string {
+	if m != nil {
+		return m.DeletedBy
+	}
+	return ""
+}
+
 type Value struct {
 	// Types that are valid to be assigned to Val:
 	//	*Value_DefaultVal
@@ -2115,6 +2267,8 @@ type Value struct {
 	//	*Value_DatetimeVal
 	//	*Value_PasswordVal
 	//	*Value_UidVal
+	//	*Value_UriVal
+	//	*Value_JsonVal
 	Val isValue_Val `protobuf_oneof:"val"`
 }
 
@@ -2162,6 +2316,12 @@ type Value_PasswordVal struct {
 type Value_UidVal struct {
 	UidVal uint64 `protobuf:"varint,11,opt,name=uid_val,json=uidVal,proto3,oneof"`
 }
+type Value_JsonVal struct {
+	JsonVal []byte `protobuf:"bytes,13,opt,name=json_val,json=jsonVal,proto3,oneof"`
+}
+type Value_UriVal struct {
+	UriVal string `protobuf:"bytes,12,opt,name=uri_val,json=uriVal,proto3,oneof"`
+}
 
 func (*Value_DefaultVal) isValue_Val()  {}
 func (*Value_BytesVal) isValue_Val()    {}
@@ -2174,6 +2334,8 @@ func (*Value_DateVal) isValue_Val()     {}
 func (*Value_DatetimeVal) isValue_Val() {}
 func (*Value_PasswordVal) isValue_Val() {}
 func (*Value_UidVal) isValue_Val()      {}
+func (*Value_JsonVal) isValue_Val()     {}
+func (*Value_UriVal) isValue_Val()      {}
 
 func (m *Value) GetVal() isValue_Val {
 	if m != nil {
@@ -2259,6 +2421,20 @@ func (m *Value) GetUidVal() uint64 {
 	return 0
 }
 
+func (m *Value) GetUriVal() string {
+	if x, ok := m.GetVal().(*Value_UriVal); ok {
+		return x.UriVal
+	}
+	return ""
+}
+
+func (m *Value) GetJsonVal() []byte {
+	if x, ok := m.GetVal().(*Value_JsonVal); ok {
+		return x.JsonVal
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Value) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Value_OneofMarshaler, _Value_OneofUnmarshaler, _Value_OneofSizer, []interface{}{
@@ -2273,6 +2449,8 @@ func (*Value) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error,
 		(*Value_DatetimeVal)(nil),
 		(*Value_PasswordVal)(nil),
 		(*Value_UidVal)(nil),
+		(*Value_UriVal)(nil),
+		(*Value_JsonVal)(nil),
 	}
 }
 
@@ -2317,6 +2495,12 @@ func _Value_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 	case *Value_UidVal:
 		_ = b.EncodeVarint(11<<3 | proto.WireVarint)
 		_ = b.EncodeVarint(uint64(x.UidVal))
+	case *Value_UriVal:
+		_ = b.EncodeVarint(12<<3 | proto.WireBytes)
+		_ = b.EncodeStringBytes(x.UriVal)
+	case *Value_JsonVal:
+		_ = b.EncodeVarint(13<<3 | proto.WireBytes)
+		_ = b.EncodeRawBytes(x.JsonVal)
 	case nil:
 	default:
 		return fmt.Errorf("Value.Val has unexpected type %T", x)
@@ -2404,6 +2588,20 @@ func _Value_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer)
 		x, err := b.DecodeVarint()
 		m.Val = &Value_UidVal{x}
 		return true, err
+	case 12: // val.uri_val
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeStringBytes()
+		m.Val = &Value_UriVal{x}
+		return true, err
+	case 13: // val.json_val
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeRawBytes(true)
+		m.Val = &Value_JsonVal{x}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -2453,6 +2651,14 @@ func _Value_OneofSizer(msg proto.Message) (n int) {
 	case *Value_UidVal:
 		n += proto.SizeVarint(11<<3 | proto.WireVarint)
 		n += proto.SizeVarint(uint64(x.UidVal))
+	case *Value_UriVal:
+		n += proto.SizeVarint(12<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(len(x.UriVal)))
+		n += len(x.UriVal)
+	case *Value_JsonVal:
+		n += proto.SizeVarint(13<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(len(x.JsonVal)))
+		n += len(x.JsonVal)
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -2469,6 +2675,7 @@ type Mutation struct {
 	Del               []*NQuad `protobuf:"bytes,11,rep,name=del" json:"del,omitempty"`
 	StartTs           uint64   `protobuf:"varint,13,opt,name=start_ts,json=startTs,proto3" json:"start_ts,omitempty"`
 	CommitImmediately bool     `protobuf:"varint,14,opt,name=commit_immediately,json=commitImmediately,proto3" json:"commit_immediately,omitempty"`
+	Cond              *NQuad   `protobuf:"bytes,15,opt,name=cond" json:"cond,omitempty"`
 }
 
 func (m *Mutation) Reset()                    { *m = Mutation{} }
@@ -2532,6 +2739,13 @@ func (m *Mutation) GetCommitImmediately() bool {
 	return false
 }
 
+func (m *Mutation) GetCond() *NQuad {
+	if m != nil {
+		return m.Cond
+	}
+	return nil
+}
+
 type Operation struct {
 	Schema   string `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
 	DropAttr string `protobuf:"bytes,2,opt,name=drop_attr,json=dropAttr,proto3" json:"drop_attr,omitempty"`
@@ -4905,6 +5119,57 @@ func (m *DirectedEdge) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if m.Tombstone {
+		dAtA[i] = 0x50
+		i++
+		if m.Tombstone {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.FacetCondOp) > 0 {
+		dAtA[i] = 0x5a
+		i++
+		i = encodeVarintTask(dAtA, i, uint64(len(m.FacetCondOp)))
+		i += copy(dAtA[i:], m.FacetCondOp)
+	}
+	if m.DefaultLang {
+		dAtA[i] = 0x60
+		i++
+		if m.DefaultLang {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.DelFacets) > 0 {
+		for _, s := range m.DelFacets {
+			dAtA[i] = 0x6a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if m.CreateOnly {
+		dAtA[i] = 0x78
+		i++
+		if m.CreateOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -5251,6 +5516,28 @@ func (m *Posting) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintTask(dAtA, i, uint64(m.CommitTs))
 	}
+	if m.DefaultLang {
+		dAtA[i] = 0x78
+		i++
+		if m.DefaultLang {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.Tombstone {
+		dAtA[i] = 0x80
+		i++
+		dAtA[i] = 0x1
+		i++
+		if m.Tombstone {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -5795,6 +6082,16 @@ func (m *SchemaUpdate) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i++
 	}
+	if m.Upsert {
+		dAtA[i] = 0x40
+		i++
+		if m.Upsert {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -6277,6 +6574,92 @@ func (m *NQuad) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintTask(dAtA, i, uint64(len(m.ObjectVar)))
 		i += copy(dAtA[i:], m.ObjectVar)
 	}
+	if m.Increment {
+		dAtA[i] = 0x50
+		i++
+		if m.Increment {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.ObjectIds) > 0 {
+		for _, s := range m.ObjectIds {
+			dAtA[i] = 0x5a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if m.DefaultLang {
+		dAtA[i] = 0x60
+		i++
+		if m.DefaultLang {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.CreateOnly {
+		dAtA[i] = 0x68
+		i++
+		if m.CreateOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.FacetCondOp) > 0 {
+		dAtA[i] = 0x72
+		i++
+		i = encodeVarintTask(dAtA, i, uint64(len(m.FacetCondOp)))
+		i += copy(dAtA[i:], m.FacetCondOp)
+	}
+	if len(m.DelFacets) > 0 {
+		for _, s := range m.DelFacets {
+			dAtA[i] = 0x7a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if m.Tombstone {
+		dAtA[i] = 0x80
+		i++
+		dAtA[i] = 0x1
+		i++
+		if m.Tombstone {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.DeletedBy) > 0 {
+		dAtA[i] = 0x8a
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintTask(dAtA, i, uint64(len(m.DeletedBy)))
+		i += copy(dAtA[i:], m.DeletedBy)
+	}
 	return i, nil
 }
 
@@ -6402,6 +6785,24 @@ func (m *Value_UidVal) MarshalTo(dAtA []byte) (int, error) {
 	i = encodeVarintTask(dAtA, i, uint64(m.UidVal))
 	return i, nil
 }
+func (m *Value_UriVal) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	dAtA[i] = 0x62
+	i++
+	i = encodeVarintTask(dAtA, i, uint64(len(m.UriVal)))
+	i += copy(dAtA[i:], m.UriVal)
+	return i, nil
+}
+func (m *Value_JsonVal) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	if m.JsonVal != nil {
+		dAtA[i] = 0x6a
+		i++
+		i = encodeVarintTask(dAtA, i, uint64(len(m.JsonVal)))
+		i += copy(dAtA[i:], m.JsonVal)
+	}
+	return i, nil
+}
 func (m *Mutation) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -6480,6 +6881,16 @@ func (m *Mutation) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i++
 	}
+	if m.Cond != nil {
+		dAtA[i] = 0x7a
+		i++
+		i = encodeVarintTask(dAtA, i, uint64(m.Cond.Size()))
+		n37, err := m.Cond.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n37
+	}
 	return i, nil
 }
 
@@ -7184,6 +7595,25 @@ func (m *DirectedEdge) Size() (n int) {
 			n += 1 + l + sovTask(uint64(l))
 		}
 	}
+	if m.Tombstone {
+		n += 2
+	}
+	l = len(m.FacetCondOp)
+	if l > 0 {
+		n += 1 + l + sovTask(uint64(l))
+	}
+	if m.DefaultLang {
+		n += 2
+	}
+	if len(m.DelFacets) > 0 {
+		for _, s := range m.DelFacets {
+			l = len(s)
+			n += 1 + l + sovTask(uint64(l))
+		}
+	}
+	if m.CreateOnly {
+		n += 2
+	}
 	return n
 }
 
@@ -7345,8 +7775,14 @@ func (m *Posting) Size() (n int) {
 	if m.CommitTs != 0 {
 		n += 1 + sovTask(uint64(m.CommitTs))
 	}
-	return n
-}
+	if m.DefaultLang {
+		n += 2
+	}
+	if m.Tombstone {
+		n += 3
+	}
+	return n
+}
 
 func (m *PostingList) Size() (n int) {
 	var l int
@@ -7570,6 +8006,9 @@ func (m *SchemaUpdate) Size() (n int) {
 	if m.Explicit {
 		n += 2
 	}
+	if m.Upsert {
+		n += 2
+	}
 	return n
 }
 
@@ -7784,6 +8223,38 @@ func (m *NQuad) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTask(uint64(l))
 	}
+	if m.Increment {
+		n += 2
+	}
+	if len(m.ObjectIds) > 0 {
+		for _, s := range m.ObjectIds {
+			l = len(s)
+			n += 1 + l + sovTask(uint64(l))
+		}
+	}
+	if m.DefaultLang {
+		n += 2
+	}
+	if m.CreateOnly {
+		n += 2
+	}
+	l = len(m.FacetCondOp)
+	if l > 0 {
+		n += 1 + l + sovTask(uint64(l))
+	}
+	if len(m.DelFacets) > 0 {
+		for _, s := range m.DelFacets {
+			l = len(s)
+			n += 1 + l + sovTask(uint64(l))
+		}
+	}
+	if m.Tombstone {
+		n += 3
+	}
+	l = len(m.DeletedBy)
+	if l > 0 {
+		n += 2 + l + sovTask(uint64(l))
+	}
 	return n
 }
 
@@ -7877,6 +8348,22 @@ func (m *Value_UidVal) Size() (n int) {
 	n += 1 + sovTask(uint64(m.UidVal))
 	return n
 }
+func (m *Value_UriVal) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.UriVal)
+	n += 1 + l + sovTask(uint64(l))
+	return n
+}
+func (m *Value_JsonVal) Size() (n int) {
+	var l int
+	_ = l
+	if m.JsonVal != nil {
+		l = len(m.JsonVal)
+		n += 1 + l + sovTask(uint64(l))
+	}
+	return n
+}
 func (m *Mutation) Size() (n int) {
 	var l int
 	_ = l
@@ -7914,6 +8401,10 @@ func (m *Mutation) Size() (n int) {
 	if m.CommitImmediately {
 		n += 2
 	}
+	if m.Cond != nil {
+		l = m.Cond.Size()
+		n += 1 + l + sovTask(uint64(l))
+	}
 	return n
 }
 
@@ -11331,6 +11822,124 @@ func (m *DirectedEdge) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tombstone", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Tombstone = bool(v != 0)
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FacetCondOp", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTask
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FacetCondOp = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DefaultLang", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.DefaultLang = bool(v != 0)
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DelFacets", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTask
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DelFacets = append(m.DelFacets, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreateOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.CreateOnly = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTask(dAtA[iNdEx:])
@@ -12456,6 +13065,46 @@ func (m *Posting) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DefaultLang", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.DefaultLang = bool(v != 0)
+		case 16:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tombstone", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Tombstone = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTask(dAtA[iNdEx:])
@@ -13977,6 +14626,26 @@ func (m *SchemaUpdate) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.Explicit = bool(v != 0)
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Upsert", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Upsert = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTask(dAtA[iNdEx:])
@@ -15676,6 +16345,202 @@ func (m *NQuad) Unmarshal(dAtA []byte) error {
 			}
 			m.ObjectVar = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Increment", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Increment = bool(v != 0)
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ObjectIds", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTask
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ObjectIds = append(m.ObjectIds, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DefaultLang", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.DefaultLang = bool(v != 0)
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreateOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.CreateOnly = bool(v != 0)
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FacetCondOp", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTask
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FacetCondOp = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DelFacets", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTask
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DelFacets = append(m.DelFacets, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 16:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tombstone", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Tombstone = bool(v != 0)
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeletedBy", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTask
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DeletedBy = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTask(dAtA[iNdEx:])
@@ -16012,6 +16877,65 @@ func (m *Value) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.Val = &Value_UidVal{v}
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UriVal", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTask
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Val = &Value_UriVal{string(dAtA[iNdEx:postIndex])}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field JsonVal", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthTask
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := make([]byte, postIndex-iNdEx)
+			copy(v, dAtA[iNdEx:postIndex])
+			m.Val = &Value_JsonVal{v}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTask(dAtA[iNdEx:])
@@ -16287,6 +17211,39 @@ func (m *Mutation) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.CommitImmediately = bool(v != 0)
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cond", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTask
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTask
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Cond == nil {
+				m.Cond = &NQuad{}
+			}
+			if err := m.Cond.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTask(dAtA[iNdEx:])
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
 	"golang.org/x/net/trace"
 
 	"github.com/dgraph-io/dgraph/gql"
 	"github.com/dgraph-io/dgraph/protos"
+	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/types"
 	"github.com/dgraph-io/dgraph/types/facets"
 	"github.com/dgraph-io/dgraph/worker"
 	"github.com/dgraph-io/dgraph/x"
@@ -44,8 +47,9 @@ func ApplyMutations(ctx context.Context, m *protos.Mutations) (*protos.TxnContex
 func handleInternalEdge(ctx context.Context, m *protos.Mutations) error {
 	newEdges := make([]*protos.DirectedEdge, 0, 2*len(m.Edges))
 	for _, mu := range m.Edges {
-		x.AssertTrue(mu.Op == protos.DirectedEdge_DEL || mu.Op == protos.DirectedEdge_SET)
-		if mu.Op == protos.DirectedEdge_SET {
+		x.AssertTrue(mu.Op == protos.DirectedEdge_DEL || mu.Op == protos.DirectedEdge_SET ||
+			mu.Op == protos.DirectedEdge_INC)
+		if mu.Op == protos.DirectedEdge_SET || mu.Op == protos.DirectedEdge_INC {
 			edge := &protos.DirectedEdge{
 				Op:     protos.DirectedEdge_SET,
 				Entity: mu.GetEntity(),
@@ -75,18 +79,30 @@ func handleInternalEdge(ctx context.Context, m *protos.Mutations) error {
 						mu.GetEntity())
 				}
 				preds := valMatrix[0].Values
+				// S * <value> only deletes the value, so the predicates stay.
+				deleteAll := bytes.Equal(val, []byte(x.Star))
 				for _, pred := range preds {
 					if bytes.Equal(pred.Val, x.Nilbyte) {
 						continue
 					}
+					if !deleteAll {
+						if edge := valueDeleteEdge(string(pred.Val), mu); edge != nil {
+							newEdges = append(newEdges, edge)
+						}
+						continue
+					}
 					edge := &protos.DirectedEdge{
-						Op:     protos.DirectedEdge_DEL,
-						Entity: mu.GetEntity(),
-						Attr:   string(pred.Val),
-						Value:  val,
+						Op:        protos.DirectedEdge_DEL,
+						Entity:    mu.GetEntity(),
+						Attr:      string(pred.Val),
+						Value:     val,
+						ValueType: mu.ValueType,
 					}
 					newEdges = append(newEdges, edge)
 				}
+				if !deleteAll {
+					continue
+				}
 				edge := &protos.DirectedEdge{
 					Op:     protos.DirectedEdge_DEL,
 					Entity: mu.GetEntity(),
@@ -122,6 +138,31 @@ func handleInternalEdge(ctx context.Context, m *protos.Mutations) error {
 	return nil
 }
 
+// valueDeleteEdge returns the edge deleting the value of the S * <value>
+// delete edge from the predicate attr, in the language of the edge if it has
+// one, or nil if attr can't have the value. Values can only be deleted from
+// scalar predicates whose type the value converts to. Values of single valued
+// predicates and values in a language are deleted only if the one stored is
+// equal to it.
+func valueDeleteEdge(attr string, edge *protos.DirectedEdge) *protos.DirectedEdge {
+	typ, err := schema.State().TypeOf(attr)
+	if err != nil || !typ.IsScalar() {
+		return nil
+	}
+	if _, err = types.Convert(types.Val{Tid: types.TypeID(edge.ValueType), Value: edge.Value},
+		typ); err != nil {
+		return nil
+	}
+	return &protos.DirectedEdge{
+		Op:        protos.DirectedEdge_DEL,
+		Entity:    edge.Entity,
+		Attr:      attr,
+		Value:     edge.Value,
+		ValueType: edge.ValueType,
+		Lang:      edge.Lang,
+	}
+}
+
 func AssignUids(ctx context.Context, nquads []*protos.NQuad) (map[string]uint64, error) {
 	newUids := make(map[string]uint64)
 	num := &protos.Num{}
@@ -141,10 +182,14 @@ func AssignUids(ctx context.Context, nquads []*protos.NQuad) (map[string]uint64,
 			}
 		}
 
+		objectIds := nq.ObjectIds
 		if len(nq.ObjectId) > 0 {
-			if strings.HasPrefix(nq.ObjectId, "_:") {
-				newUids[nq.ObjectId] = 0
-			} else if uid, err := gql.ParseUid(nq.ObjectId); err != nil || uid > maxLeaseId {
+			objectIds = append([]string{nq.ObjectId}, objectIds...)
+		}
+		for _, id := range objectIds {
+			if strings.HasPrefix(id, "_:") {
+				newUids[id] = 0
+			} else if uid, err := gql.ParseUid(id); err != nil || uid > maxLeaseId {
 				return newUids, err
 			}
 		}
@@ -172,24 +217,90 @@ func AssignUids(ctx context.Context, nquads []*protos.NQuad) (map[string]uint64,
 	return newUids, nil
 }
 
+// UidForCond returns the uid of the node which satisfies cond as of readTs,
+// or an empty string if there is none. The predicate of cond needs an index
+// supporting eq, and at most one node may satisfy it.
+func UidForCond(ctx context.Context, readTs uint64, linRead *protos.LinRead,
+	cond *gql.Condition) (string, error) {
+	fn, err := cond.Function()
+	if err != nil {
+		return "", err
+	}
+	req := QueryRequest{
+		ReadTs:  readTs,
+		LinRead: linRead,
+		Latency: &Latency{},
+		GqlQuery: &gql.Result{
+			Query:     []*gql.GraphQuery{{Alias: "cond", Func: fn}},
+			QueryVars: []*gql.Vars{{}},
+		},
+	}
+	if err := req.ProcessQuery(ctx); err != nil {
+		return "", x.Wrapf(err, "While resolving the condition on %s", cond.Predicate)
+	}
+	uids := req.Subgraphs[0].DestUIDs.GetUids()
+	switch len(uids) {
+	case 0:
+		return "", nil
+	case 1:
+		return fmt.Sprintf("%#x", uids[0]), nil
+	}
+	return "", x.Errorf("Condition on %s is satisfied by %d nodes, expected at most one",
+		cond.Predicate, len(uids))
+}
+
 func ToInternal(gmu *gql.Mutation,
 	newUids map[string]uint64) (edges []*protos.DirectedEdge, err error) {
+	if gmu.Cond != nil {
+		// The blank node of the condition would otherwise always get a new uid.
+		return edges, x.Errorf("Condition on predicate %s should be resolved with "+
+			"ResolveCond before applying the mutation", gmu.Cond.Predicate)
+	}
 
 	// Wrapper for a pointer to protos.Nquad
 	var wnq *gql.NQuad
 
-	parse := func(nq *protos.NQuad, op protos.DirectedEdge_Op) error {
-		wnq = &gql.NQuad{nq}
+	var parse func(nq *protos.NQuad, op protos.DirectedEdge_Op) error
+	parse = func(nq *protos.NQuad, op protos.DirectedEdge_Op) error {
 		if len(nq.Subject) == 0 {
 			return nil
 		}
+		if nq.ObjectIds != nil {
+			// An NQuad with a list of uids gives an edge for each of them.
+			expanded, err := gql.NQuad{NQuad: nq}.ExpandObjectIds()
+			if err != nil {
+				return err
+			}
+			for _, enq := range expanded {
+				if err := parse(enq.NQuad, op); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		wnq = &gql.NQuad{nq}
 		// Get edge from nquad using newUids.
 		var edge *protos.DirectedEdge
-		edge, err = wnq.ToEdgeUsing(newUids)
+		if nq.Increment && op != protos.DirectedEdge_SET {
+			return x.Errorf("Increment is only allowed in set mutations. Got: %+v", nq)
+		}
+		if op == protos.DirectedEdge_SET && nq.Predicate == x.Star {
+			return x.Errorf("Predicate * is only allowed in delete mutations. Got: %+v", nq)
+		}
+		if op == protos.DirectedEdge_SET && wnq.IsLabelDelete() {
+			return x.Errorf("Only a label was given as the object for set mutation: %+v", nq)
+		}
+		if op == protos.DirectedEdge_DEL {
+			edge, err = wnq.ToDeleteEdgeUsing(newUids)
+		} else {
+			edge, err = wnq.ToEdgeUsing(newUids)
+		}
 		if err != nil {
 			return x.Wrap(err)
 		}
-		edge.Op = op
+		if edge.Op != protos.DirectedEdge_INC {
+			edge.Op = op
+		}
 		edges = append(edges, edge)
 		return nil
 	}
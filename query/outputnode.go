@@ -159,10 +159,14 @@ func valToBytes(v types.Val) ([]byte, error) {
 			return []byte("true"), nil
 		}
 		return []byte("false"), nil
-	case types.StringID, types.DefaultID:
+	case types.StringID, types.DefaultID, types.UriID:
 		return []byte(strconv.Quote(v.Value.(string))), nil
 	case types.DateTimeID:
 		return v.Value.(time.Time).MarshalJSON()
+	case types.JsonID:
+		return v.Value.([]byte), nil
+	case types.IpID, types.CidrID, types.MoneyID, types.UuidID, types.RangeID:
+		return v.MarshalJSON()
 	case types.GeoID:
 		return geojson.Marshal(v.Value.(geom.T))
 	case types.UidID:
@@ -19,6 +19,7 @@ package query
 
 import (
 	"encoding/base64"
+	"net"
 	"time"
 
 	"github.com/dgraph-io/dgraph/protos"
@@ -70,6 +71,27 @@ func toProtoValue(v types.Val) *protos.Value {
 	case types.UidID:
 		return &protos.Value{&protos.Value_UidVal{v.Value.(uint64)}}
 
+	case types.UriID:
+		return &protos.Value{&protos.Value_UriVal{v.Value.(string)}}
+
+	case types.JsonID:
+		return &protos.Value{&protos.Value_JsonVal{v.Value.([]byte)}}
+
+	case types.IpID:
+		return &protos.Value{&protos.Value_StrVal{v.Value.(net.IP).String()}}
+
+	case types.CidrID:
+		return &protos.Value{&protos.Value_StrVal{v.Value.(*net.IPNet).String()}}
+
+	case types.MoneyID:
+		return &protos.Value{&protos.Value_StrVal{v.Value.(types.Money).String()}}
+
+	case types.UuidID:
+		return &protos.Value{&protos.Value_StrVal{v.Value.(types.UUID).String()}}
+
+	case types.RangeID:
+		return &protos.Value{&protos.Value_StrVal{v.Value.(types.Range).String()}}
+
 	case types.DefaultID:
 		return &protos.Value{&protos.Value_DefaultVal{v.Value.(string)}}
 
@@ -7568,3 +7568,100 @@ func TestCountPanic(t *testing.T) {
 	js := processToFastJSON(t, query)
 	require.JSONEq(t, `{"data": {"q":[{"uid":"0x1","name":"Michonne","count(name)":1},{"uid":"0x12c","count(name)":0}]}}`, js)
 }
+
+func TestValueDeleteEdge(t *testing.T) {
+	populateGraph(t)
+	del := &protos.DirectedEdge{Op: protos.DirectedEdge_DEL, Entity: 1, Attr: x.Star,
+		Value: []byte("Michonne"), ValueType: protos.Posting_STRING, Lang: "en"}
+
+	// Single valued and list predicates both get the value, with its language.
+	for _, attr := range []string{"name", "occupations"} {
+		edge := valueDeleteEdge(attr, del)
+		require.NotNil(t, edge)
+		require.Equal(t, &protos.DirectedEdge{Op: protos.DirectedEdge_DEL, Entity: 1,
+			Attr: attr, Value: del.Value, ValueType: del.ValueType, Lang: "en"}, edge)
+	}
+	// Uid predicates and predicates the value doesn't convert to don't.
+	require.Nil(t, valueDeleteEdge("friend", del))
+	require.Nil(t, valueDeleteEdge("age", del))
+}
+
+func TestToInternalStarPredicate(t *testing.T) {
+	nq := &protos.NQuad{Subject: "0x1", Predicate: x.Star,
+		ObjectValue: &protos.Value{Val: &protos.Value_DefaultVal{DefaultVal: "Alice"}}}
+	_, err := ToInternal(&gql.Mutation{Set: []*protos.NQuad{nq}}, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only allowed in delete")
+
+	edges, err := ToInternal(&gql.Mutation{Del: []*protos.NQuad{nq}}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(edges))
+	require.Equal(t, x.Star, edges[0].Attr)
+	require.Equal(t, protos.DirectedEdge_DEL, edges[0].Op)
+}
+
+func TestToInternalObjectIds(t *testing.T) {
+	nq := &protos.NQuad{Subject: "_:a", Predicate: "friend",
+		ObjectIds: []string{"_:b", "0x3"}}
+	newUids := map[string]uint64{"_:a": 10, "_:b": 11}
+	edges, err := ToInternal(&gql.Mutation{Set: []*protos.NQuad{nq}}, newUids)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(edges))
+	for i, uid := range []uint64{11, 3} {
+		require.Equal(t, uint64(10), edges[i].Entity)
+		require.Equal(t, uid, edges[i].ValueId)
+		require.Equal(t, protos.DirectedEdge_SET, edges[i].Op)
+	}
+
+	edges, err = ToInternal(&gql.Mutation{Del: []*protos.NQuad{nq}}, newUids)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(edges))
+	require.Equal(t, protos.DirectedEdge_DEL, edges[1].Op)
+}
+
+func TestUidForCond(t *testing.T) {
+	populateGraph(t)
+	cond := func(pred string, val *protos.Value) *gql.Condition {
+		return &gql.Condition{Subject: "_:user", Predicate: pred, Value: val}
+	}
+	readTs := timestamp()
+	maxPendingCh <- readTs
+	ctx := defaultContext()
+
+	uid, err := UidForCond(ctx, readTs, nil, cond("full_name",
+		&protos.Value{Val: &protos.Value_StrVal{StrVal: "Michonne's large name for hashing"}}))
+	require.NoError(t, err)
+	require.Equal(t, "0x1", uid)
+
+	uid, err = UidForCond(ctx, readTs, nil, cond("full_name",
+		&protos.Value{Val: &protos.Value_StrVal{StrVal: "Nobody"}}))
+	require.NoError(t, err)
+	require.Equal(t, "", uid)
+
+	_, err = UidForCond(ctx, readTs, nil, cond("alive",
+		&protos.Value{Val: &protos.Value_BoolVal{BoolVal: true}}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "expected at most one")
+
+	// Without an index the predicate can't be used.
+	_, err = UidForCond(ctx, readTs, nil, cond("noindex_name",
+		&protos.Value{Val: &protos.Value_StrVal{StrVal: "Michonne's name not indexed"}}))
+	require.Error(t, err)
+}
+
+func TestToInternalUnresolvedCond(t *testing.T) {
+	email := &protos.Value{Val: &protos.Value_StrVal{StrVal: "alice@dgraph.io"}}
+	nq := gql.NQuad{NQuad: &protos.NQuad{Subject: "_:user", Predicate: "name",
+		ObjectValue: &protos.Value{Val: &protos.Value_StrVal{StrVal: "Alice"}}}}
+	m, err := gql.UpsertByPredicate("email", email, []gql.NQuad{nq})
+	require.NoError(t, err)
+	_, err = ToInternal(m, map[string]uint64{"_:user": 10})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "ResolveCond")
+
+	m.ResolveCond("0x5")
+	edges, err := ToInternal(m, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(edges))
+	require.Equal(t, uint64(5), edges[0].Entity)
+}
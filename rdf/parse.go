@@ -20,12 +20,14 @@ package rdf
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
 	"errors"
 	"io"
 	"log"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/dgraph-io/dgraph/lex"
 	"github.com/dgraph-io/dgraph/protos"
@@ -103,7 +105,7 @@ L:
 			}
 		case itemLiteral:
 			var err error
-			oval, err = strconv.Unquote(item.Val)
+			oval, err = unescapeLiteral(item.Val)
 			if err != nil {
 				return rnq, x.Wrapf(err, "while unquoting")
 			}
@@ -126,8 +128,8 @@ L:
 					"itemObject should be emitted before itemObjectType. Input: [%s]",
 					line)
 			}
-			if rnq.Predicate == x.Star || rnq.Subject == x.Star {
-				return rnq, x.Errorf("If predicate/subject is *, value should be * as well")
+			if rnq.Subject == x.Star {
+				return rnq, x.Errorf("If subject is *, value should be * as well")
 			}
 
 			val := strings.Trim(item.Val, " ")
@@ -145,6 +147,14 @@ L:
 			}
 			src := types.ValueForType(types.StringID)
 			src.Value = []byte(oval)
+			if t == types.BinaryID {
+				// Binary values are base64 encoded in RDF.
+				b, err := base64.StdEncoding.DecodeString(oval)
+				if err != nil {
+					return rnq, x.Wrapf(err, "while decoding base64 value")
+				}
+				src.Value = b
+			}
 			p, err := types.Convert(src, t)
 			if err != nil {
 				return rnq, err
@@ -202,6 +212,9 @@ L:
 	if len(rnq.ObjectId) == 0 && rnq.ObjectValue == nil {
 		return rnq, x.Errorf("No Object in NQuad. Input: [%s]", line)
 	}
+	if rnq.Predicate == x.Star && len(rnq.ObjectId) > 0 {
+		return rnq, x.Errorf("If predicate is *, object can't be a uid. Input: [%s]", line)
+	}
 	if !sane(rnq.Subject) || !sane(rnq.Predicate) ||
 		!sane(rnq.ObjectId) || !sane(rnq.Label) {
 		return rnq, x.Errorf("NQuad failed sanity check:%+v", rnq)
@@ -210,19 +223,37 @@ L:
 	return rnq, nil
 }
 
+// LineFacet is the facet recording the line an NQuad was parsed from, when
+// ParseOptions.LineFacets is set.
+const LineFacet = "_line"
+
+// ParseOptions holds the options used by ConvertToNQuadsWith.
+type ParseOptions struct {
+	// LineFacets adds the LineFacet int facet to every NQuad, holding the
+	// number of the line it came from, starting at 1.
+	LineFacets bool
+}
+
 // ConvertToNQuads parses multi line mutation string to a list of NQuads.
 func ConvertToNQuads(mutation string) ([]*protos.NQuad, error) {
+	return ConvertToNQuadsWith(mutation, ParseOptions{})
+}
+
+// ConvertToNQuadsWith is like ConvertToNQuads, with the given options.
+func ConvertToNQuadsWith(mutation string, opts ParseOptions) ([]*protos.NQuad, error) {
 	var nquads []*protos.NQuad
 	r := strings.NewReader(mutation)
 	reader := bufio.NewReader(r)
 
 	var strBuf bytes.Buffer
 	var err error
+	var line int
 	for {
 		err = x.ReadLine(reader, &strBuf)
 		if err != nil {
 			break
 		}
+		line++
 		ln := strings.Trim(strBuf.String(), " \t")
 		if len(ln) == 0 {
 			continue
@@ -235,7 +266,13 @@ func ConvertToNQuads(mutation string) ([]*protos.NQuad, error) {
 		if err == ErrEmpty { // special case: comment/empty line
 			continue
 		} else if err != nil {
-			return nquads, x.Wrapf(err, "While parsing RDF: %s", strBuf.String())
+			return nquads, x.Wrapf(err, "While parsing RDF at line %d: %s", line, strBuf.String())
+		}
+		if opts.LineFacets {
+			if err := addLineFacet(&nq, line); err != nil {
+				return nquads, x.Wrapf(err, "While parsing RDF at line %d: %s", line,
+					strBuf.String())
+			}
 		}
 		nquads = append(nquads, &nq)
 	}
@@ -245,6 +282,75 @@ func ConvertToNQuads(mutation string) ([]*protos.NQuad, error) {
 	return nquads, nil
 }
 
+func addLineFacet(nq *protos.NQuad, line int) error {
+	f, err := facets.FacetFor(LineFacet, strconv.Itoa(line))
+	if err != nil {
+		return err
+	}
+	nq.Facets = append(nq.Facets, f)
+	return facets.SortAndValidate(nq.Facets)
+}
+
+// unescapeLiteral returns the value of a quoted RDF literal, replacing the
+// ECHAR and UCHAR escapes allowed by the N-Quads grammar.
+func unescapeLiteral(lit string) (string, error) {
+	if len(lit) < 2 || lit[0] != '"' || lit[len(lit)-1] != '"' {
+		return "", x.Errorf("Literal should be enclosed in quotes. Got: %s", lit)
+	}
+	s := lit[1 : len(lit)-1]
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			buf.WriteByte(s[i])
+			i++
+			continue
+		}
+		if i+1 == len(s) {
+			return "", x.Errorf("Unexpected end of literal after \\")
+		}
+		switch c := s[i+1]; c {
+		case 't':
+			buf.WriteByte('\t')
+		case 'b':
+			buf.WriteByte('\b')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 'f':
+			buf.WriteByte('\f')
+		case '"', '\'', '\\':
+			buf.WriteByte(c)
+		case 'u', 'U':
+			n := 4
+			if c == 'U' {
+				n = 8
+			}
+			if i+2+n > len(s) || !isValidUChar(s[i:i+2+n]) {
+				return "", x.Errorf("Invalid unicode escape in literal: %s", lit)
+			}
+			v, _ := strconv.ParseUint(s[i+2:i+2+n], 16, 32)
+			buf.WriteRune(rune(v))
+			i += 2 + n
+			continue
+		default:
+			return "", x.Errorf("Invalid escape character '%c' in literal: %s", c, lit)
+		}
+		i += 2
+	}
+	return buf.String(), nil
+}
+
+// isValidUChar returns whether esc, like \u00e9 or \U0001F600, escapes a valid
+// unicode code point.
+func isValidUChar(esc string) bool {
+	v, err := strconv.ParseUint(esc[2:], 16, 32)
+	return err == nil && utf8.ValidRune(rune(v))
+}
+
 func parseFacets(it *lex.ItemIterator, rnq *protos.NQuad) error {
 	if !it.Next() {
 		return x.Errorf("Unexpected end of facets.")
@@ -328,7 +434,10 @@ var typeMap = map[string]types.TypeID{
 	"xs:double":                                        types.FloatID,
 	"xs:float":                                         types.FloatID,
 	"xs:base64Binary":                                  types.BinaryID,
+	"xs:anyURI":                                        types.UriID,
 	"geo:geojson":                                      types.GeoID,
+	"geo:wktLiteral":                                   types.GeoID,
+	"json":                                             types.JsonID,
 	"http://www.w3.org/2001/XMLSchema#string":          types.StringID,
 	"http://www.w3.org/2001/XMLSchema#dateTime":        types.DateTimeID,
 	"http://www.w3.org/2001/XMLSchema#date":            types.DateTimeID,
@@ -340,4 +449,6 @@ var typeMap = map[string]types.TypeID{
 	"http://www.w3.org/2001/XMLSchema#float":           types.FloatID,
 	"http://www.w3.org/2001/XMLSchema#gYear":           types.DateTimeID,
 	"http://www.w3.org/2001/XMLSchema#gYearMonth":      types.DateTimeID,
+	"http://www.w3.org/2001/XMLSchema#anyURI":          types.UriID,
+	"http://www.w3.org/2001/XMLSchema#base64Binary":    types.BinaryID,
 }
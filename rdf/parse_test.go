@@ -198,6 +198,30 @@ var testNQuads = []struct {
 		},
 		expectedErr: false,
 	},
+	{
+		input: `<alice> * "Alice" .`,
+		nq: protos.NQuad{
+			Subject:     "alice",
+			Predicate:   x.Star,
+			ObjectId:    "",
+			ObjectValue: &protos.Value{&protos.Value_DefaultVal{"Alice"}},
+		},
+		expectedErr: false,
+	},
+	{
+		input: `<alice> * "26"^^<xs:int> .`,
+		nq: protos.NQuad{
+			Subject:     "alice",
+			Predicate:   x.Star,
+			ObjectId:    "",
+			ObjectValue: &protos.Value{&protos.Value_IntVal{26}},
+		},
+		expectedErr: false,
+	},
+	{
+		input:       `<alice> * <bob> .`,
+		expectedErr: true,
+	},
 	{
 		input:       "<alice> <knows> .",
 		expectedErr: true,
@@ -493,8 +517,33 @@ var testNQuads = []struct {
 		},
 	},
 	{
-		input:       `<alice> <lives> "\'" .`,
-		expectedErr: true, // \' isn't a valid escape sequence
+		input: `<alice> <lives> "\'" .`,
+		nq: protos.NQuad{
+			Subject:     "alice",
+			Predicate:   "lives",
+			ObjectValue: &protos.Value{&protos.Value_DefaultVal{"'"}},
+		},
+	},
+	{
+		input: `<alice> <quote> "She said \"hi\"\nand left\tquietly." .`,
+		nq: protos.NQuad{
+			Subject:     "alice",
+			Predicate:   "quote",
+			ObjectValue: &protos.Value{&protos.Value_DefaultVal{"She said \"hi\"\nand left\tquietly."}},
+		},
+	},
+	{
+		input: `<alice> <lives> "Z\u00FCrich \U0001F600"@de .`,
+		nq: protos.NQuad{
+			Subject:     "alice",
+			Predicate:   "lives",
+			Lang:        "de",
+			ObjectValue: &protos.Value{&protos.Value_DefaultVal{"Zürich 😀"}},
+		},
+	},
+	{
+		input:       `<alice> <lives> "\uD800" .`,
+		expectedErr: true, // surrogate halves aren't valid code points
 	},
 	{
 		input:       `<alice> <lives> "\a" .`,
@@ -817,6 +866,34 @@ var testNQuads = []struct {
 		input:       `<alice> <age> "13"^^<xs:double> (salary=NaN) .`,
 		expectedErr: true,
 	},
+	{
+		input: `<alice> <homepage> "https://dgraph.io/alice"^^<xs:anyURI> .`,
+		nq: protos.NQuad{
+			Subject:     "alice",
+			Predicate:   "homepage",
+			ObjectValue: &protos.Value{&protos.Value_UriVal{"https://dgraph.io/alice"}},
+		},
+	},
+	{
+		input:       `<alice> <homepage> "dgraph.io/alice"^^<xs:anyURI> .`,
+		expectedErr: true,
+	},
+	{
+		input: `<alice> <avatar> "aGVsbG8="^^<xs:base64Binary> .`,
+		nq: protos.NQuad{
+			Subject:     "alice",
+			Predicate:   "avatar",
+			ObjectValue: &protos.Value{&protos.Value_BytesVal{[]byte("hello")}},
+		},
+	},
+	{
+		input:       `<alice> <avatar> "not base64!"^^<xs:base64Binary> .`,
+		expectedErr: true,
+	},
+	{
+		input:       `<alice> <avatar> "aGVsbG8="^^<xs:hexBinary> .`,
+		expectedErr: true,
+	},
 }
 
 func TestLex(t *testing.T) {
@@ -835,3 +912,50 @@ func TestLex(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertToNQuadsEscapeError(t *testing.T) {
+	input := "<alice> <name> \"Alice\" .\n\n<alice> <bio> \"Bad \\q escape\" .\n"
+	_, err := ConvertToNQuads(input)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3")
+	assert.Contains(t, err.Error(), "column 20")
+	assert.Contains(t, err.Error(), `"\\q"`)
+}
+
+func TestParseWKTLiteral(t *testing.T) {
+	nq, err := Parse(`<alice> <loc> "POINT(1 2)"^^<geo:wktLiteral> .`)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, nq.ObjectValue.GetGeoVal())
+}
+
+func TestConvertToNQuadsLineFacets(t *testing.T) {
+	rdf := `<alice> <name> "Alice" .
+
+# Bob is a friend.
+<alice> <friend> <bob> (since=2006) .
+<bob> <name> "Bob" (zeta=true) .`
+	nquads, err := ConvertToNQuadsWith(rdf, ParseOptions{LineFacets: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(nquads))
+	lineOf := func(nq *protos.NQuad) int64 {
+		for _, f := range nq.Facets {
+			if f.Key == LineFacet {
+				assert.Equal(t, protos.Facet_INT, f.ValType)
+				return facets.ValFor(f).Value.(int64)
+			}
+		}
+		return 0
+	}
+	assert.Equal(t, int64(1), lineOf(nquads[0]))
+	assert.Equal(t, int64(4), lineOf(nquads[1]))
+	assert.Equal(t, int64(5), lineOf(nquads[2]))
+	// The facets stay sorted by key.
+	assert.Equal(t, []string{LineFacet, "since"},
+		[]string{nquads[1].Facets[0].Key, nquads[1].Facets[1].Key})
+
+	nquads, err = ConvertToNQuads(rdf)
+	assert.NoError(t, err)
+	assert.Empty(t, nquads[0].Facets)
+	assert.Equal(t, 1, len(nquads[1].Facets))
+	assert.Equal(t, 1, len(nquads[2].Facets))
+}
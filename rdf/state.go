@@ -21,6 +21,7 @@ package rdf
 
 import (
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/dgraph-io/dgraph/lex"
 )
@@ -280,13 +281,18 @@ func lexLanguage(l *lex.Lexer) lex.StateFn {
 // STRING_LITERAL_QUOTE ::= '"' ([^#x22#x5C#xA#xD] | ECHAR | UCHAR)* '"'
 func lexLiteral(l *lex.Lexer) lex.StateFn {
 	for {
+		start := l.Pos
 		r := l.Next()
 		if r == '\u005c' { // backslash
 			r = l.Next()
-			if l.IsEscChar(r) || lex.HasUChars(r, l) {
+			if l.IsEscChar(r) {
 				continue // This would skip over the escaped rune.
 			}
-			return l.Errorf("Invalid escape character : '%c' in literal", r)
+			if lex.HasUChars(r, l) && isValidUChar(l.Input[start:l.Pos]) {
+				continue
+			}
+			return l.Errorf("Invalid escape sequence %q in literal at column %d",
+				l.Input[start:l.Pos], utf8.RuneCountInString(l.Input[:start])+1)
 		}
 
 		if r == 0x5c || r == 0xa || r == 0xd { // 0x22 ('"') is endLiteral
@@ -71,6 +71,8 @@ func parseDirective(it *lex.ItemIterator, schema *protos.SchemaUpdate, t types.T
 		}
 	case "count":
 		schema.Count = true
+	case "upsert":
+		schema.Upsert = true
 	default:
 		return x.Errorf("Invalid index specification")
 	}
@@ -132,14 +134,8 @@ func parseScalarPair(it *lex.ItemIterator, predicate string) (*protos.SchemaUpda
 		}
 		next = it.Item()
 	}
-	if next.Typ == itemAt {
-		if err := parseDirective(it, schema, t); err != nil {
-			return nil, err
-		}
-		next = it.Item()
-	}
-	// Check for another directive, we could have @count too.
-	if next.Typ == itemAt {
+	// There can be several directives, like @index(exact) @count @upsert.
+	for next.Typ == itemAt {
 		if err := parseDirective(it, schema, t); err != nil {
 			return nil, err
 		}
@@ -244,6 +240,10 @@ func resolveTokenizers(updates []*protos.SchemaUpdate) error {
 				schema.Predicate, typ.Name())
 		}
 
+		if schema.Upsert && schema.Directive != protos.SchemaUpdate_INDEX {
+			return x.Errorf("@upsert requires an index on predicate %s", schema.Predicate)
+		}
+
 		if typ == types.UidID {
 			continue
 		}
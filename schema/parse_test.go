@@ -158,6 +158,21 @@ func TestSchemaIndex_Error2(t *testing.T) {
 	require.Error(t, ParseBytes([]byte(schemaIndexVal3Password), 1))
 }
 
+var schemaIndexValIP = `
+addr: ip @index(ip) .
+block: cidr @index(cidr) .
+`
+
+var schemaIndexValIPExact = `
+addr: ip @index(exact) .
+`
+
+func TestSchemaIndexIP(t *testing.T) {
+	require.NoError(t, ParseBytes([]byte(schemaIndexValIP), 1))
+	require.Equal(t, 2, len(State().IndexedFields()))
+	require.Error(t, ParseBytes([]byte(schemaIndexValIPExact), 1))
+}
+
 var schemaIndexVal4 = `
 name:string @index(exact term) .
 `
@@ -378,3 +393,32 @@ func TestParseUnderscore(t *testing.T) {
 	_, err := Parse("_share_:string @index(term) .")
 	require.NoError(t, err)
 }
+
+func TestParseUpsert(t *testing.T) {
+	reset()
+	schemas, err := Parse(`
+		email: string @index(exact) @upsert .
+		name: string @index(term) @count @upsert .
+	`)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(schemas))
+	require.EqualValues(t, &protos.SchemaUpdate{
+		Predicate: "email",
+		ValueType: 9,
+		Directive: protos.SchemaUpdate_INDEX,
+		Tokenizer: []string{"exact"},
+		Explicit:  true,
+		Upsert:    true,
+	}, schemas[0])
+	require.True(t, schemas[1].Count)
+	require.True(t, schemas[1].Upsert)
+}
+
+func TestParseUpsertNotIndexed(t *testing.T) {
+	reset()
+	_, err := Parse(`
+		email: string @upsert .
+	`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "@upsert requires an index on predicate email")
+}
@@ -86,6 +86,8 @@ func init() {
 	registerTokenizer(BoolTokenizer{})
 	registerTokenizer(TrigramTokenizer{})
 	registerTokenizer(HashTokenizer{})
+	registerTokenizer(IPTokenizer{})
+	registerTokenizer(CIDRTokenizer{})
 	initFullTextTokenizers()
 }
 
@@ -368,6 +370,38 @@ func (t HashTokenizer) Identifier() byte { return 0xB }
 func (t HashTokenizer) IsSortable() bool { return false }
 func (t HashTokenizer) IsLossy() bool    { return true }
 
+// binaryToken returns the stored form of the value of type tid, which orders
+// values in the same way as comparing them.
+func binaryToken(tid types.TypeID, v interface{}) ([]string, error) {
+	out := types.ValueForType(types.BinaryID)
+	if err := types.Marshal(types.Val{Tid: tid, Value: v}, &out); err != nil {
+		return nil, err
+	}
+	return []string{string(out.Value.([]byte))}, nil
+}
+
+type IPTokenizer struct{}
+
+func (t IPTokenizer) Name() string { return "ip" }
+func (t IPTokenizer) Type() string { return "ip" }
+func (t IPTokenizer) Tokens(v interface{}) ([]string, error) {
+	return binaryToken(types.IpID, v)
+}
+func (t IPTokenizer) Identifier() byte { return 0xC }
+func (t IPTokenizer) IsSortable() bool { return true }
+func (t IPTokenizer) IsLossy() bool    { return false }
+
+type CIDRTokenizer struct{}
+
+func (t CIDRTokenizer) Name() string { return "cidr" }
+func (t CIDRTokenizer) Type() string { return "cidr" }
+func (t CIDRTokenizer) Tokens(v interface{}) ([]string, error) {
+	return binaryToken(types.CidrID, v)
+}
+func (t CIDRTokenizer) Identifier() byte { return 0xD }
+func (t CIDRTokenizer) IsSortable() bool { return true }
+func (t CIDRTokenizer) IsLossy() bool    { return false }
+
 // PluginTokenizer is implemented by external plugins loaded dynamically via
 // *.so files. It follows the implementation semantics of the Tokenizer
 // interface.
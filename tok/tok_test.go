@@ -24,6 +24,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/dgraph-io/dgraph/types"
 )
 
 type encL struct {
@@ -130,6 +132,36 @@ func TestDateTimeTokenizer(t *testing.T) {
 	require.Equal(t, 1+2, len(tokens[0]))
 }
 
+func TestIPTokenizer(t *testing.T) {
+	tokenizer, has := GetTokenizer("ip")
+	require.True(t, has)
+	var tokens []string
+	for _, s := range []string{"10.0.0.2", "10.0.0.10", "::1"} {
+		val, err := types.Convert(types.Val{Tid: types.StringID, Value: []byte(s)}, types.IpID)
+		require.NoError(t, err)
+		toks, err := BuildTokens(val.Value, tokenizer)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(toks))
+		require.Equal(t, 1+16, len(toks[0]))
+		tokens = append(tokens, toks[0])
+	}
+	// Tokens order like the addresses.
+	require.True(t, tokens[2] < tokens[0])
+	require.True(t, tokens[0] < tokens[1])
+}
+
+func TestCIDRTokenizer(t *testing.T) {
+	tokenizer, has := GetTokenizer("cidr")
+	require.True(t, has)
+	val, err := types.Convert(types.Val{Tid: types.StringID, Value: []byte("10.0.0.0/8")},
+		types.CidrID)
+	require.NoError(t, err)
+	tokens, err := BuildTokens(val.Value, tokenizer)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tokens))
+	require.Equal(t, 1+17, len(tokens[0]))
+}
+
 func TestFullTextTokenizerLang(t *testing.T) {
 	tokenizer, has := GetTokenizer(FtsTokenizerName("de"))
 	require.True(t, has)
@@ -22,7 +22,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"net"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	geom "github.com/twpayne/go-geom"
@@ -33,6 +36,32 @@ import (
 	"github.com/dgraph-io/dgraph/x"
 )
 
+// Options holds the settings for converting values between types.
+type Options struct {
+	// YesNoBools makes strings convert to bools also accept yes and no.
+	YesNoBools bool
+}
+
+var Config Options
+
+// ParseBool parses the string value of a bool. Like strconv.ParseBool, it
+// accepts 1, t, T, TRUE, true, True, 0, f, F, FALSE, false and False. If
+// Config.YesNoBools is set, it also accepts yes and no, in any case.
+func ParseBool(s string) (bool, error) {
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b, nil
+	}
+	if Config.YesNoBools {
+		switch strings.ToLower(s) {
+		case "yes":
+			return true, nil
+		case "no":
+			return false, nil
+		}
+	}
+	return false, x.Errorf("Unrecognized value for bool: %q", s)
+}
+
 // Convert converts the value to given scalar type.
 func Convert(from Val, toID TypeID) (Val, error) {
 	to := ValueForType(toID)
@@ -85,6 +114,43 @@ func Convert(from Val, toID TypeID) (Val, error) {
 				*res = w
 			case PasswordID:
 				*res = string(data)
+			case UriID:
+				*res = string(data)
+			case JsonID:
+				if err := validateJSON(data); err != nil {
+					return to, err
+				}
+				*res = data
+			case IpID:
+				ip, err := decodeIP(data)
+				if err != nil {
+					return to, err
+				}
+				*res = ip
+			case CidrID:
+				ipnet, err := decodeCIDR(data)
+				if err != nil {
+					return to, err
+				}
+				*res = ipnet
+			case MoneyID:
+				m, err := decodeMoney(data)
+				if err != nil {
+					return to, err
+				}
+				*res = m
+			case UuidID:
+				u, err := decodeUUID(data)
+				if err != nil {
+					return to, err
+				}
+				*res = u
+			case RangeID:
+				r, err := decodeRange(data)
+				if err != nil {
+					return to, err
+				}
+				*res = r
 			default:
 				return to, cantConvert(fromID, toID)
 			}
@@ -111,11 +177,14 @@ func Convert(from Val, toID TypeID) (Val, error) {
 				if math.IsNaN(val) {
 					return to, fmt.Errorf("Got invalid value: NaN.")
 				}
+				if math.IsInf(val, 0) {
+					return to, fmt.Errorf("Got invalid value: %v.", val)
+				}
 				*res = float64(val)
 			case StringID, DefaultID:
 				*res = string(vc)
 			case BoolID:
-				val, err := strconv.ParseBool(string(vc))
+				val, err := ParseBool(vc)
 				if err != nil {
 					return to, err
 				}
@@ -127,6 +196,14 @@ func Convert(from Val, toID TypeID) (Val, error) {
 				}
 				*res = t
 			case GeoID:
+				if isWKT(vc) {
+					g, err := parseWKT(vc)
+					if err != nil {
+						return to, err
+					}
+					*res = g
+					break
+				}
 				var g geom.T
 				text := bytes.Replace([]byte(vc), []byte("'"), []byte("\""), -1)
 				if err := geojson.Unmarshal(text, &g); err != nil {
@@ -139,6 +216,46 @@ func Convert(from Val, toID TypeID) (Val, error) {
 					return to, err
 				}
 				*res = password
+			case UriID:
+				if err := validateURI(vc); err != nil {
+					return to, err
+				}
+				*res = vc
+			case JsonID:
+				if err := validateJSON(data); err != nil {
+					return to, err
+				}
+				*res = data
+			case IpID:
+				ip, err := parseIP(vc)
+				if err != nil {
+					return to, err
+				}
+				*res = ip
+			case CidrID:
+				ipnet, err := parseCIDR(vc)
+				if err != nil {
+					return to, err
+				}
+				*res = ipnet
+			case MoneyID:
+				m, err := parseMoney(vc)
+				if err != nil {
+					return to, err
+				}
+				*res = m
+			case UuidID:
+				u, err := parseUUID(vc)
+				if err != nil {
+					return to, err
+				}
+				*res = u
+			case RangeID:
+				r, err := parseRange(vc)
+				if err != nil {
+					return to, err
+				}
+				*res = r
 			default:
 				return to, cantConvert(fromID, toID)
 			}
@@ -321,6 +438,115 @@ func Convert(from Val, toID TypeID) (Val, error) {
 				return to, cantConvert(fromID, toID)
 			}
 		}
+	case UriID:
+		{
+			vc := string(data)
+			switch toID {
+			case BinaryID:
+				// Marshal Binary
+				*res = []byte(vc)
+			case StringID, DefaultID, UriID:
+				*res = vc
+			default:
+				return to, cantConvert(fromID, toID)
+			}
+		}
+	case JsonID:
+		{
+			switch toID {
+			case BinaryID, JsonID:
+				*res = data
+			case StringID, DefaultID:
+				*res = string(data)
+			default:
+				return to, cantConvert(fromID, toID)
+			}
+		}
+	case IpID:
+		{
+			ip, err := decodeIP(data)
+			if err != nil {
+				return to, err
+			}
+			switch toID {
+			case BinaryID:
+				*res = data
+			case IpID:
+				*res = ip
+			case StringID, DefaultID:
+				*res = ip.String()
+			default:
+				return to, cantConvert(fromID, toID)
+			}
+		}
+	case CidrID:
+		{
+			ipnet, err := decodeCIDR(data)
+			if err != nil {
+				return to, err
+			}
+			switch toID {
+			case BinaryID:
+				*res = data
+			case CidrID:
+				*res = ipnet
+			case StringID, DefaultID:
+				*res = ipnet.String()
+			default:
+				return to, cantConvert(fromID, toID)
+			}
+		}
+	case MoneyID:
+		{
+			m, err := decodeMoney(data)
+			if err != nil {
+				return to, err
+			}
+			switch toID {
+			case BinaryID:
+				*res = data
+			case MoneyID:
+				*res = m
+			case StringID, DefaultID:
+				*res = m.String()
+			default:
+				return to, cantConvert(fromID, toID)
+			}
+		}
+	case UuidID:
+		{
+			u, err := decodeUUID(data)
+			if err != nil {
+				return to, err
+			}
+			switch toID {
+			case BinaryID:
+				*res = data
+			case UuidID:
+				*res = u
+			case StringID, DefaultID:
+				*res = u.String()
+			default:
+				return to, cantConvert(fromID, toID)
+			}
+		}
+	case RangeID:
+		{
+			r, err := decodeRange(data)
+			if err != nil {
+				return to, err
+			}
+			switch toID {
+			case BinaryID:
+				*res = data
+			case RangeID:
+				*res = r
+			case StringID, DefaultID:
+				*res = r.String()
+			default:
+				return to, cantConvert(fromID, toID)
+			}
+		}
 	default:
 		return to, cantConvert(fromID, toID)
 	}
@@ -451,6 +677,81 @@ func Marshal(from Val, to *Val) error {
 		default:
 			return cantConvert(fromID, toID)
 		}
+	case UriID:
+		vc := val.(string)
+		switch toID {
+		case StringID, DefaultID:
+			*res = vc
+		case BinaryID:
+			// Marshal Binary
+			*res = []byte(vc)
+		default:
+			return cantConvert(fromID, toID)
+		}
+	case JsonID:
+		vc := val.([]byte)
+		switch toID {
+		case StringID, DefaultID:
+			*res = string(vc)
+		case BinaryID:
+			*res = vc
+		default:
+			return cantConvert(fromID, toID)
+		}
+	case IpID:
+		vc := val.(net.IP)
+		switch toID {
+		case StringID, DefaultID:
+			*res = vc.String()
+		case BinaryID:
+			*res = []byte(vc.To16())
+		default:
+			return cantConvert(fromID, toID)
+		}
+	case CidrID:
+		vc := val.(*net.IPNet)
+		switch toID {
+		case StringID, DefaultID:
+			*res = vc.String()
+		case BinaryID:
+			*res = encodeCIDR(vc)
+		default:
+			return cantConvert(fromID, toID)
+		}
+	case MoneyID:
+		vc := val.(Money)
+		switch toID {
+		case StringID, DefaultID:
+			*res = vc.String()
+		case BinaryID:
+			*res = encodeMoney(vc)
+		default:
+			return cantConvert(fromID, toID)
+		}
+	case UuidID:
+		vc := val.(UUID)
+		switch toID {
+		case StringID, DefaultID:
+			*res = vc.String()
+		case BinaryID:
+			*res = vc[:]
+		default:
+			return cantConvert(fromID, toID)
+		}
+	case RangeID:
+		vc := val.(Range)
+		switch toID {
+		case StringID, DefaultID:
+			*res = vc.String()
+		case BinaryID:
+			b, err := encodeRange(vc)
+			if err != nil {
+				return err
+			}
+			*res = b
+		default:
+			return cantConvert(fromID, toID)
+		}
 
 	default:
 		return cantConvert(fromID, toID)
@@ -520,6 +821,26 @@ func ObjectValue(id TypeID, value interface{}) (*protos.Value, error) {
 			return def, x.Errorf("Expected value of type password. Got : %v", value)
 		}
 		return &protos.Value{&protos.Value_PasswordVal{v}}, nil
+	case UriID:
+		var v string
+		if v, ok = value.(string); !ok {
+			return def, x.Errorf("Expected value of type uri. Got : %v", value)
+		}
+		return &protos.Value{&protos.Value_UriVal{v}}, nil
+	case JsonID:
+		var v []byte
+		switch t := value.(type) {
+		case []byte:
+			v = t
+		case json.RawMessage:
+			v = t
+		default:
+			return def, x.Errorf("Expected value of type json. Got : %v", value)
+		}
+		if err := validateJSON(v); err != nil {
+			return def, err
+		}
+		return &protos.Value{&protos.Value_JsonVal{v}}, nil
 	default:
 		return def, x.Errorf("ObjectValue not available for: %v", id)
 	}
@@ -533,6 +854,26 @@ func toBinary(id TypeID, b interface{}) ([]byte, error) {
 	return p1.Value.([]byte), nil
 }
 
+// validateURI checks that s is an absolute URI, i.e. one with a scheme.
+func validateURI(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if !u.IsAbs() {
+		return x.Errorf("URI should be absolute. Got: %s", s)
+	}
+	return nil
+}
+
+// validateJSON checks that b is a single valid JSON value.
+func validateJSON(b []byte) error {
+	if !json.Valid(b) {
+		return x.Errorf("Invalid JSON value: %s", b)
+	}
+	return nil
+}
+
 func cantConvert(from TypeID, to TypeID) error {
 	return x.Errorf("Cannot convert %s to type %s", from.Name(), to.Name())
 }
@@ -551,8 +892,20 @@ func (v Val) MarshalJSON() ([]byte, error) {
 		return geojson.Marshal(v.Value.(geom.T))
 	case StringID, DefaultID:
 		return json.Marshal(v.Value.(string))
-	case PasswordID:
+	case PasswordID, UriID:
 		return json.Marshal(v.Value.(string))
+	case JsonID:
+		return v.Value.([]byte), nil
+	case IpID:
+		return json.Marshal(v.Value.(net.IP).String())
+	case CidrID:
+		return json.Marshal(v.Value.(*net.IPNet).String())
+	case MoneyID:
+		return json.Marshal(v.Value.(Money).String())
+	case UuidID:
+		return json.Marshal(v.Value.(UUID).String())
+	case RangeID:
+		return json.Marshal(v.Value.(Range).String())
 	}
 	return nil, x.Errorf("Invalid type for MarshalJSON: %v", v.Tid)
 }
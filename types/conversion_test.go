@@ -17,6 +17,7 @@
 package types
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 	"time"
@@ -82,6 +83,291 @@ func TestConvertFromDefault(t *testing.T) {
 	}
 }
 
+func TestConvertToFloatInvalid(t *testing.T) {
+	for _, in := range []string{"NaN", "Inf", "+Inf", "-Inf", "1e400"} {
+		if v, err := Convert(Val{StringID, []byte(in)}, FloatID); err == nil {
+			t.Errorf("Expected error converting %q to float, got %+v", in, v)
+		}
+	}
+	if v, err := Convert(Val{StringID, []byte("-2.5e3")}, FloatID); err != nil {
+		t.Errorf("Unexpected error converting string to float: %v", err)
+	} else if v.Value.(float64) != -2500 {
+		t.Errorf("Converting string to float: Expected -2500, got %+v", v.Value)
+	}
+}
+
+func TestConvertToBool(t *testing.T) {
+	defer func() { Config.YesNoBools = false }()
+	convert := func(in string) (bool, error) {
+		v, err := Convert(Val{StringID, []byte(in)}, BoolID)
+		if err != nil {
+			return false, err
+		}
+		return v.Value.(bool), nil
+	}
+	tests := []struct {
+		in  string
+		out bool
+	}{
+		{"true", true}, {"false", false}, {"TRUE", true}, {"1", true}, {"0", false},
+	}
+	for _, tc := range tests {
+		if b, err := convert(tc.in); err != nil || b != tc.out {
+			t.Errorf("Converting %q to bool: Expected %v, got %v, %v", tc.in, tc.out, b, err)
+		}
+	}
+	for _, in := range []string{"yes", "no", "on", "2", ""} {
+		if b, err := convert(in); err == nil {
+			t.Errorf("Expected error converting %q to bool, got %v", in, b)
+		}
+	}
+
+	Config.YesNoBools = true
+	tests = []struct {
+		in  string
+		out bool
+	}{
+		{"yes", true}, {"No", false}, {"YES", true}, {"true", true}, {"0", false},
+	}
+	for _, tc := range tests {
+		if b, err := convert(tc.in); err != nil || b != tc.out {
+			t.Errorf("Converting %q to bool: Expected %v, got %v, %v", tc.in, tc.out, b, err)
+		}
+	}
+	if b, err := convert("on"); err == nil {
+		t.Errorf("Expected error converting %q to bool, got %v", "on", b)
+	}
+}
+
+func TestConvertIP(t *testing.T) {
+	tests := []struct {
+		tid     TypeID
+		in, out string
+	}{
+		{IpID, "192.168.1.20", "192.168.1.20"},
+		{IpID, "2001:0db8:0000:0000:0000:0000:0000:0001", "2001:db8::1"},
+		{CidrID, "192.168.1.20/24", "192.168.1.0/24"},
+		{CidrID, "2001:db8::/32", "2001:db8::/32"},
+	}
+	for _, tc := range tests {
+		v, err := Convert(Val{StringID, []byte(tc.in)}, tc.tid)
+		if err != nil {
+			t.Errorf("Unexpected error converting %q to %s: %v", tc.in, tc.tid.Name(), err)
+			continue
+		}
+		// Round trip through the stored form.
+		b := ValueForType(BinaryID)
+		if err := Marshal(v, &b); err != nil {
+			t.Errorf("Unexpected error marshalling %q: %v", tc.in, err)
+			continue
+		}
+		v, err = Convert(Val{tc.tid, b.Value.([]byte)}, StringID)
+		if err != nil {
+			t.Errorf("Unexpected error converting %q back to string: %v", tc.in, err)
+		} else if v.Value.(string) != tc.out {
+			t.Errorf("Converting %q to %s: Expected %q, got %q", tc.in, tc.tid.Name(), tc.out,
+				v.Value)
+		}
+	}
+
+	for _, in := range []string{"256.1.1.1", "10.0.0", "not an ip", "10.0.0.0/8"} {
+		if v, err := Convert(Val{StringID, []byte(in)}, IpID); err == nil {
+			t.Errorf("Expected error converting %q to ip, got %+v", in, v)
+		}
+	}
+	for _, in := range []string{"10.0.0.0/33", "10.0.0.0", "::1/129"} {
+		if v, err := Convert(Val{StringID, []byte(in)}, CidrID); err == nil {
+			t.Errorf("Expected error converting %q to cidr, got %+v", in, v)
+		}
+	}
+}
+
+func TestConvertMoney(t *testing.T) {
+	tests := []struct {
+		in, out string
+		minor   int64
+	}{
+		{"USD 12.34", "USD 12.34", 1234},
+		{"USD 12", "USD 12.00", 1200},
+		{"USD 0.5", "USD 0.50", 50},
+		{"EUR -3.07", "EUR -3.07", -307},
+		{" GBP  0.01 ", "GBP 0.01", 1},
+		{"JPY 500", "JPY 500", 500},
+		{"KWD 1.5", "KWD 1.500", 1500},
+	}
+	for _, tc := range tests {
+		v, err := Convert(Val{StringID, []byte(tc.in)}, MoneyID)
+		if err != nil {
+			t.Errorf("Unexpected error converting %q to money: %v", tc.in, err)
+			continue
+		}
+		if m := v.Value.(Money); m.AmountMinor != tc.minor {
+			t.Errorf("Converting %q to money: Expected %d minor units, got %d", tc.in,
+				tc.minor, m.AmountMinor)
+		}
+		// Round trip through the stored form.
+		b := ValueForType(BinaryID)
+		if err := Marshal(v, &b); err != nil {
+			t.Errorf("Unexpected error marshalling %q: %v", tc.in, err)
+			continue
+		}
+		v, err = Convert(Val{MoneyID, b.Value.([]byte)}, StringID)
+		if err != nil {
+			t.Errorf("Unexpected error converting %q back to string: %v", tc.in, err)
+		} else if v.Value.(string) != tc.out {
+			t.Errorf("Converting %q to money: Expected %q, got %q", tc.in, tc.out, v.Value)
+		}
+	}
+
+	for _, in := range []string{"12.34", "usd 12.34", "USD", "USD 1.234", "JPY 1.5",
+		"USD 1.", "USD 1.-5", "USD abc", "USD 1 2", "USD 99999999999999999999"} {
+		if v, err := Convert(Val{StringID, []byte(in)}, MoneyID); err == nil {
+			t.Errorf("Expected error converting %q to money, got %+v", in, v)
+		}
+	}
+}
+
+func TestConvertUUID(t *testing.T) {
+	in := "F47AC10B-58CC-4372-A567-0E02B2C3D479"
+	v, err := Convert(Val{StringID, []byte(in)}, UuidID)
+	if err != nil {
+		t.Fatalf("Unexpected error converting %q to uuid: %v", in, err)
+	}
+	u := v.Value.(UUID)
+	if u[0] != 0xf4 || u[6]>>4 != 4 || u[15] != 0x79 {
+		t.Errorf("Converting %q to uuid: got bytes %x", in, u[:])
+	}
+	// Round trip through the stored form.
+	b := ValueForType(BinaryID)
+	if err := Marshal(v, &b); err != nil {
+		t.Fatalf("Unexpected error marshalling %q: %v", in, err)
+	}
+	if n := len(b.Value.([]byte)); n != 16 {
+		t.Errorf("Expected 16 bytes for uuid, got %d", n)
+	}
+	v, err = Convert(Val{UuidID, b.Value.([]byte)}, StringID)
+	if err != nil {
+		t.Errorf("Unexpected error converting %q back to string: %v", in, err)
+	} else if out := "f47ac10b-58cc-4372-a567-0e02b2c3d479"; v.Value.(string) != out {
+		t.Errorf("Converting %q to uuid: Expected %q, got %q", in, out, v.Value)
+	}
+
+	for _, in := range []string{"f47ac10b58cc4372a5670e02b2c3d479",
+		"f47ac10b-58cc-4372-a567-0e02b2c3d47", "f47ac10b-58cc-4372-a567-0e02b2c3d47z",
+		"f47ac10b-58cc-4372-a5670-e02b2c3d479", "{f47ac10b-58cc-4372-a567-0e02b2c3d479}"} {
+		if v, err := Convert(Val{StringID, []byte(in)}, UuidID); err == nil {
+			t.Errorf("Expected error converting %q to uuid, got %+v", in, v)
+		}
+	}
+}
+
+func TestConvertDateTimeRange(t *testing.T) {
+	in := "[2017-03-01T09:00:00Z, 2017-03-01T10:30:00Z)"
+	v, err := Convert(Val{StringID, []byte(in)}, RangeID)
+	if err != nil {
+		t.Fatalf("Unexpected error converting %q to range: %v", in, err)
+	}
+	r := v.Value.(Range)
+	if r.Tid != DateTimeID || !r.Start.(time.Time).Equal(time.Date(2017, 3, 1, 9, 0, 0, 0,
+		time.UTC)) {
+		t.Errorf("Converting %q to range: got %+v", in, r)
+	}
+	// Round trip through the stored form.
+	b := ValueForType(BinaryID)
+	if err := Marshal(v, &b); err != nil {
+		t.Fatalf("Unexpected error marshalling %q: %v", in, err)
+	}
+	v, err = Convert(Val{RangeID, b.Value.([]byte)}, StringID)
+	if err != nil {
+		t.Errorf("Unexpected error converting %q back to string: %v", in, err)
+	} else if v.Value.(string) != in {
+		t.Errorf("Converting %q to range: Expected %q, got %q", in, in, v.Value)
+	}
+
+	noon := Val{DateTimeID, time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)}
+	if !r.Contains(noon) {
+		t.Errorf("Expected %v to contain %v", r, noon.Value)
+	}
+	end := Val{DateTimeID, r.End}
+	if r.Contains(end) {
+		t.Errorf("Expected %v not to contain its end", r)
+	}
+}
+
+func TestConvertNumberRange(t *testing.T) {
+	parse := func(s string) Range {
+		v, err := Convert(Val{StringID, []byte(s)}, RangeID)
+		if err != nil {
+			t.Fatalf("Unexpected error converting %q to range: %v", s, err)
+		}
+		return v.Value.(Range)
+	}
+	a, b, c := parse("[1, 5)"), parse("[4, 10)"), parse("[5, 7)")
+	if a.Tid != IntID || a.Start.(int64) != 1 || a.End.(int64) != 5 {
+		t.Errorf("Converting [1, 5) to range: got %+v", a)
+	}
+	if !a.Overlaps(b) || !b.Overlaps(a) {
+		t.Errorf("Expected %v and %v to overlap", a, b)
+	}
+	if a.Overlaps(c) {
+		t.Errorf("Expected %v and %v not to overlap", a, c)
+	}
+	if f := parse("[0.5, 2.5)"); f.Tid != FloatID || a.Overlaps(f) {
+		t.Errorf("Expected a float range not to overlap an int one, got %+v", f)
+	}
+
+	b2 := ValueForType(BinaryID)
+	if err := Marshal(Val{RangeID, a}, &b2); err != nil {
+		t.Fatalf("Unexpected error marshalling %v: %v", a, err)
+	}
+	v, err := Convert(Val{BinaryID, b2.Value.([]byte)}, RangeID)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding %v: %v", a, err)
+	}
+	if compareRange(v.Value.(Range), a) != 0 {
+		t.Errorf("Expected %v after a round trip, got %v", a, v.Value)
+	}
+}
+
+func TestConvertRangeInvalid(t *testing.T) {
+	for _, in := range []string{"[5, 1)", "[2017-03-02T00:00:00Z, 2017-03-01T00:00:00Z)",
+		"[1, 5]", "(1, 5)", "[1, 2, 3)", "[1, 2017-03-01T00:00:00Z)", "[a, b)"} {
+		if v, err := Convert(Val{StringID, []byte(in)}, RangeID); err == nil {
+			t.Errorf("Expected error converting %q to range, got %+v", in, v)
+		}
+	}
+	if _, err := NewRange(StringID, "a", "b"); err == nil {
+		t.Errorf("Expected error for a range of strings")
+	}
+	if _, err := Convert(Val{RangeID, []byte{byte(IntID), 8, 1}}, StringID); err == nil {
+		t.Errorf("Expected error decoding a truncated range")
+	}
+}
+
+func TestConvertToJson(t *testing.T) {
+	for _, in := range []string{`{"a": [1, 2], "b": {"c": null}}`, `[1, "two", 3.0]`} {
+		v, err := Convert(Val{BinaryID, []byte(in)}, JsonID)
+		if err != nil {
+			t.Errorf("Unexpected error converting %q to json: %v", in, err)
+			continue
+		}
+		if string(v.Value.([]byte)) != in {
+			t.Errorf("Converting to json: Expected %q, got %q", in, v.Value)
+		}
+		if _, err := ObjectValue(JsonID, json.RawMessage(in)); err != nil {
+			t.Errorf("Unexpected error creating json value for %q: %v", in, err)
+		}
+	}
+	for _, in := range []string{`{"a": 1`, `[1, 2,]`, ``} {
+		if v, err := Convert(Val{StringID, []byte(in)}, JsonID); err == nil {
+			t.Errorf("Expected error converting %q to json, got %+v", in, v)
+		}
+		if _, err := ObjectValue(JsonID, []byte(in)); err == nil {
+			t.Errorf("Expected error creating json value for %q", in)
+		}
+	}
+}
+
 func TestConversionToDateTime(t *testing.T) {
 	data := []struct {
 		in  Val
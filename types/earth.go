@@ -20,6 +20,8 @@ import (
 	"fmt"
 
 	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	geom "github.com/twpayne/go-geom"
 )
 
 // Helper functions for earth distances
@@ -40,6 +42,13 @@ func EarthAngle(dist float64) s1.Angle {
 	return s1.Angle(dist / EarthRadiusMeters)
 }
 
+// PointDistance returns the great-circle distance on earth between two points.
+func PointDistance(a, b *geom.Point) Length {
+	la := s2.LatLngFromDegrees(a.Y(), a.X())
+	lb := s2.LatLngFromDegrees(b.Y(), b.X())
+	return EarthDistance(la.Distance(lb))
+}
+
 // Area denotes an area on Earth
 type Area float64
 
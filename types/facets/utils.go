@@ -148,6 +148,47 @@ func FacetFor(key, val string) (*protos.Facet, error) {
 	return res, err
 }
 
+// FloatFacet returns a float Facet for given key and val. Unlike FacetFor, the
+// type doesn't depend on whether val happens to be a whole number.
+func FloatFacet(key string, val float64) (*protos.Facet, error) {
+	if math.IsNaN(val) {
+		return nil, x.Errorf("Got invalid value: NaN.")
+	}
+	fVal := &types.Val{Tid: types.BinaryID}
+	if err := types.Marshal(types.Val{Tid: types.FloatID, Value: val}, fVal); err != nil {
+		return nil, err
+	}
+	return &protos.Facet{Key: key, Value: fVal.Value.([]byte), ValType: protos.Facet_FLOAT}, nil
+}
+
+// BucketFor returns a facet keyed <key>_bucket, which holds the bucket the
+// numeric facet f falls into when values are split into buckets of given width.
+func BucketFor(f *protos.Facet, width float64) (*protos.Facet, error) {
+	if width <= 0 || math.IsNaN(width) || math.IsInf(width, 0) {
+		return nil, x.Errorf("Bucket width should be a positive number. Got: %v", width)
+	}
+	var num float64
+	switch f.ValType {
+	case protos.Facet_INT:
+		v, err := types.Convert(types.Val{Tid: types.BinaryID, Value: f.Value}, types.IntID)
+		if err != nil {
+			return nil, err
+		}
+		num = float64(v.Value.(int64))
+	case protos.Facet_FLOAT:
+		v, err := types.Convert(types.Val{Tid: types.BinaryID, Value: f.Value}, types.FloatID)
+		if err != nil {
+			return nil, err
+		}
+		num = v.Value.(float64)
+	default:
+		return nil, x.Errorf("Can only bucket numeric facets. Facet %s has type %s",
+			f.Key, f.ValType)
+	}
+	bucket := int64(math.Floor(num / width))
+	return FacetFor(f.Key+"_bucket", strconv.FormatInt(bucket, 10))
+}
+
 // SameFacets returns whether two facets are same or not.
 // both should be sorted by key.
 func SameFacets(a []*protos.Facet, b []*protos.Facet) bool {
@@ -192,3 +233,40 @@ func ValFor(f *protos.Facet) types.Val {
 		"We should always be able to covert facet into val. %v %v", f.Value, typId)
 	return v
 }
+
+// SatisfiesCondition returns whether the facet in fs with the key of cond
+// compares to cond with the function op, which is one of lt, le, gt, ge or eq.
+// The type of cond is inferred from its text, so it is converted to the type
+// of the stored facet first; an int facet is compared to a float condition as
+// a float so the fraction isn't lost. It returns false if fs has no facet with
+// that key or cond can't be converted.
+func SatisfiesCondition(op string, fs []*protos.Facet, cond *protos.Facet) bool {
+	for _, f := range fs {
+		if f.Key != cond.Key {
+			continue
+		}
+		fv, cv := ValFor(f), ValFor(cond)
+		if fv.Tid == cv.Tid {
+			return types.CompareVals(op, fv, cv)
+		}
+		if fv.Tid == types.IntID && cv.Tid == types.FloatID {
+			fv = types.Val{Tid: types.FloatID, Value: float64(fv.Value.(int64))}
+			return types.CompareVals(op, fv, cv)
+		}
+		cv, err := types.Convert(types.Val{Tid: TypeIDFor(cond), Value: cond.Value}, fv.Tid)
+		if err != nil {
+			return false
+		}
+		return types.CompareVals(op, fv, cv)
+	}
+	return false
+}
+
+// IsConditionOp returns whether op can be used with SatisfiesCondition.
+func IsConditionOp(op string) bool {
+	switch op {
+	case "lt", "le", "gt", "ge", "eq":
+		return true
+	}
+	return false
+}
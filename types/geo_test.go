@@ -18,6 +18,7 @@ package types
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -70,3 +71,56 @@ func TestParseGeoJsonErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestParseWKT(t *testing.T) {
+	tests := []struct {
+		wkt, json string
+	}{
+		{`POINT(1 2)`, `{"type":"Point","coordinates":[1,2]}`},
+		{` point ( -122.4 37.77 ) `, `{"type":"Point","coordinates":[-122.4,37.77]}`},
+		{`LINESTRING(0 0, 1 1, 2 0.5)`,
+			`{"type":"LineString","coordinates":[[0,0],[1,1],[2,0.5]]}`},
+		{`POLYGON((0 0, 4 0, 4 4, 0 4, 0 0), (1 1, 2 1, 2 2, 1 1))`,
+			`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],` +
+				`[[1,1],[2,1],[2,2],[1,1]]]}`},
+		{`MULTIPOLYGON(((0 0, 1 0, 1 1, 0 0)), ((5 5, 6 5, 6 6, 5 5)))`,
+			`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]],` +
+				`[[[5,5],[6,5],[6,6],[5,5]]]]}`},
+	}
+	for _, tc := range tests {
+		g, err := Convert(Val{StringID, []byte(tc.wkt)}, GeoID)
+		if err != nil {
+			t.Errorf("Error parsing %s: %v", tc.wkt, err)
+			continue
+		}
+		want, err := Convert(Val{StringID, []byte(tc.json)}, GeoID)
+		if err != nil {
+			t.Fatalf("Error parsing %s: %v", tc.json, err)
+		}
+		if !reflect.DeepEqual(want, g) {
+			t.Errorf("Expected %#v for %s, got %#v", want, tc.wkt, g)
+		}
+	}
+}
+
+func TestParseWKTErrors(t *testing.T) {
+	tests := []struct {
+		wkt, err string
+	}{
+		{`POINT(1)`, "column 8"},
+		{`POINT 1 2`, "column 7"},
+		{`POINT(1 2`, "column 10"},
+		{`POINT(1 x)`, "column 9"},
+		{`POLYGON((0 0, 1 0, 1 1))`, "polygon rings"},
+		{`CIRCLE(1 2, 3)`, "column 1"},
+		{`LINESTRING(0 0, 1 1) extra`, "column 22"},
+	}
+	for _, tc := range tests {
+		_, err := Convert(Val{StringID, []byte(tc.wkt)}, GeoID)
+		if err == nil {
+			t.Errorf("Expected error parsing %s", tc.wkt)
+		} else if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("Expected error with %q parsing %s, got: %v", tc.err, tc.wkt, err)
+		}
+	}
+}
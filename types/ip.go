@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// IP addresses are stored in their 16 byte form, with IPv4 addresses mapped
+// into IPv6, so that comparing the bytes orders addresses of both versions.
+// CIDR blocks are stored as the 16 byte network address followed by the prefix
+// length, in bits of the 16 byte form.
+
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, x.Errorf("Invalid IP address: %q", s)
+	}
+	return ip.To16(), nil
+}
+
+func parseCIDR(s string) (*net.IPNet, error) {
+	_, ipnet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, x.Wrapf(err, "Invalid CIDR block: %q", s)
+	}
+	return ipnet, nil
+}
+
+func decodeIP(b []byte) (net.IP, error) {
+	if len(b) != net.IPv6len {
+		return nil, x.Errorf("Invalid data for ip %v", b)
+	}
+	return net.IP(b), nil
+}
+
+func encodeCIDR(ipnet *net.IPNet) []byte {
+	ones, bits := ipnet.Mask.Size()
+	if bits == 8*net.IPv4len {
+		ones += 8 * (net.IPv6len - net.IPv4len)
+	}
+	b := make([]byte, net.IPv6len+1)
+	copy(b, ipnet.IP.To16())
+	b[net.IPv6len] = byte(ones)
+	return b
+}
+
+func decodeCIDR(b []byte) (*net.IPNet, error) {
+	if len(b) != net.IPv6len+1 || int(b[net.IPv6len]) > 8*net.IPv6len {
+		return nil, x.Errorf("Invalid data for cidr %v", b)
+	}
+	ip := net.IP(b[:net.IPv6len])
+	ones := int(b[net.IPv6len])
+	// Give IPv4 blocks back in their 4 byte form, so that they print as such.
+	if ip4 := ip.To4(); ip4 != nil && ones >= 8*(net.IPv6len-net.IPv4len) {
+		return &net.IPNet{
+			IP:   ip4,
+			Mask: net.CIDRMask(ones-8*(net.IPv6len-net.IPv4len), 8*net.IPv4len),
+		}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(ones, 8*net.IPv6len)}, nil
+}
+
+// compareIP orders addresses numerically by their 16 byte form.
+func compareIP(a, b net.IP) int {
+	return bytes.Compare(a.To16(), b.To16())
+}
+
+// compareCIDR orders blocks by their network address, and blocks with the same
+// address by the length of their prefix.
+func compareCIDR(a, b *net.IPNet) int {
+	return bytes.Compare(encodeCIDR(a), encodeCIDR(b))
+}
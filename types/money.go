@@ -0,0 +1,142 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// Money is an amount of money in a currency, kept exactly as a number of the
+// minor units of the currency, e.g. cents for USD.
+type Money struct {
+	Currency    string // ISO 4217 code, e.g. USD.
+	AmountMinor int64
+}
+
+// minorUnits has the number of digits of the minor unit of the currencies
+// which don't use 2, the most common.
+var minorUnits = map[string]int{
+	"BHD": 3, "BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "IQD": 3, "ISK": 0,
+	"JOD": 3, "JPY": 0, "KMF": 0, "KRW": 0, "KWD": 3, "LYD": 3, "OMR": 3,
+	"PYG": 0, "RWF": 0, "TND": 3, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+}
+
+func minorUnitsOf(currency string) int {
+	if n, ok := minorUnits[currency]; ok {
+		return n
+	}
+	return 2
+}
+
+// String formats the money the way parseMoney reads it, e.g. USD 12.34.
+func (m Money) String() string {
+	digits := minorUnitsOf(m.Currency)
+	neg := m.AmountMinor < 0
+	// Format the magnitude as unsigned, so that MinInt64 works too.
+	abs := uint64(m.AmountMinor)
+	if neg {
+		abs = -abs
+	}
+	s := strconv.FormatUint(abs, 10)
+	if digits > 0 {
+		if len(s) <= digits {
+			s = strings.Repeat("0", digits-len(s)+1) + s
+		}
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return m.Currency + " " + s
+}
+
+func isCurrency(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseMoney parses a currency code followed by an amount, e.g. USD 12.34. The
+// amount can't have more decimals than the minor unit of the currency.
+func parseMoney(s string) (Money, error) {
+	parts := strings.Fields(s)
+	if len(parts) != 2 || !isCurrency(parts[0]) {
+		return Money{}, x.Errorf("Invalid money %q, expected a currency code and an amount, "+
+			"e.g. USD 12.34", s)
+	}
+	currency, amount := parts[0], parts[1]
+	digits := minorUnitsOf(currency)
+	whole, frac := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		whole, frac = amount[:i], amount[i+1:]
+		if len(frac) == 0 {
+			return Money{}, x.Errorf("Invalid amount %q for money", amount)
+		}
+	}
+	if len(frac) > digits {
+		return Money{}, x.Errorf("Amount %q has more decimals than the %d of %s", amount,
+			digits, currency)
+	}
+	if strings.ContainsAny(frac, "+-") {
+		return Money{}, x.Errorf("Invalid amount %q for money", amount)
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, x.Wrapf(err, "Invalid amount %q for money", amount)
+	}
+	return Money{Currency: currency, AmountMinor: minor}, nil
+}
+
+// encodeMoney gives the currency code followed by the amount in little endian
+// order, like ints.
+func encodeMoney(m Money) []byte {
+	b := make([]byte, 3+8)
+	copy(b, m.Currency)
+	binary.LittleEndian.PutUint64(b[3:], uint64(m.AmountMinor))
+	return b
+}
+
+func decodeMoney(b []byte) (Money, error) {
+	if len(b) != 3+8 || !isCurrency(string(b[:3])) {
+		return Money{}, x.Errorf("Invalid data for money %v", b)
+	}
+	return Money{
+		Currency:    string(b[:3]),
+		AmountMinor: int64(binary.LittleEndian.Uint64(b[3:])),
+	}, nil
+}
+
+// checkSameCurrency returns an error if a and b are in different currencies,
+// as there is no fixed rate to compare them with.
+func checkSameCurrency(a, b Money) error {
+	if a.Currency != b.Currency {
+		return x.Errorf("Can't compare money in %s with money in %s", a.Currency, b.Currency)
+	}
+	return nil
+}
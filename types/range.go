@@ -0,0 +1,171 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// Range is the half-open interval [Start, End) of datetimes, ints or floats.
+// Start and End are of the Go type used for values of type Tid. A range can be
+// empty, with Start equal to End, but can't end before it starts.
+//
+// Ranges are written as "[start, end)", and stored as the type of the
+// endpoints, the size of the start, and the binary forms of both endpoints.
+type Range struct {
+	Tid   TypeID
+	Start interface{}
+	End   interface{}
+}
+
+// NewRange returns the range [start, end) of values of type tid, which must be
+// datetime, int or float.
+func NewRange(tid TypeID, start, end interface{}) (Range, error) {
+	r := Range{Tid: tid, Start: start, End: end}
+	switch tid {
+	case DateTimeID, IntID, FloatID:
+	default:
+		return r, x.Errorf("Ranges can't have endpoints of type %s", tid.Name())
+	}
+	if less(r.end(), r.start()) {
+		return r, x.Errorf("Range ends at %v before it starts at %v", end, start)
+	}
+	return r, nil
+}
+
+func (r Range) start() Val { return Val{Tid: r.Tid, Value: r.Start} }
+func (r Range) end() Val   { return Val{Tid: r.Tid, Value: r.End} }
+
+func (r Range) String() string {
+	format := func(v interface{}) string {
+		if t, ok := v.(time.Time); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+		return fmt.Sprint(v)
+	}
+	return "[" + format(r.Start) + ", " + format(r.End) + ")"
+}
+
+// Contains returns true if v is of the type of the endpoints and within the
+// range.
+func (r Range) Contains(v Val) bool {
+	return v.Tid == r.Tid && !less(v, r.start()) && less(v, r.end())
+}
+
+// Overlaps returns true if the ranges have endpoints of the same type and
+// share at least one value. Ranges which only touch, with one ending where the
+// other starts, don't overlap.
+func (r Range) Overlaps(o Range) bool {
+	return r.Tid == o.Tid && less(r.start(), o.end()) && less(o.start(), r.end())
+}
+
+// parseRangeEndpoint parses an endpoint as an int, a float or a datetime, in
+// this order.
+func parseRangeEndpoint(s string) (Val, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return Val{Tid: IntID, Value: i}, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return Val{Tid: FloatID, Value: f}, nil
+	}
+	if t, err := ParseTime(s); err == nil {
+		return Val{Tid: DateTimeID, Value: t}, nil
+	}
+	return Val{}, x.Errorf("Invalid range endpoint: %q", s)
+}
+
+func parseRange(s string) (Range, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, ")") {
+		return Range{}, x.Errorf("Range should be of the form [start, end). Got: %q", s)
+	}
+	parts := strings.Split(s[1:len(s)-1], ",")
+	if len(parts) != 2 {
+		return Range{}, x.Errorf("Range should be of the form [start, end). Got: %q", s)
+	}
+	start, err := parseRangeEndpoint(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return Range{}, err
+	}
+	end, err := parseRangeEndpoint(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return Range{}, err
+	}
+	if start.Tid != end.Tid {
+		return Range{}, x.Errorf("Endpoints of range %q should be of the same type", s)
+	}
+	return NewRange(start.Tid, start.Value, end.Value)
+}
+
+func encodeRange(r Range) ([]byte, error) {
+	start, end := ValueForType(BinaryID), ValueForType(BinaryID)
+	if err := Marshal(r.start(), &start); err != nil {
+		return nil, err
+	}
+	if err := Marshal(r.end(), &end); err != nil {
+		return nil, err
+	}
+	sb, eb := start.Value.([]byte), end.Value.([]byte)
+	b := make([]byte, 0, 2+len(sb)+len(eb))
+	b = append(b, byte(r.Tid), byte(len(sb)))
+	b = append(b, sb...)
+	return append(b, eb...), nil
+}
+
+func decodeRange(b []byte) (Range, error) {
+	if len(b) < 2 || len(b) < 2+int(b[1]) {
+		return Range{}, x.Errorf("Invalid data for range %v", b)
+	}
+	tid := TypeID(b[0])
+	switch tid {
+	case DateTimeID, IntID, FloatID:
+	default:
+		return Range{}, x.Errorf("Invalid data for range %v", b)
+	}
+	start, err := Convert(Val{Tid: BinaryID, Value: b[2 : 2+b[1]]}, tid)
+	if err != nil {
+		return Range{}, err
+	}
+	end, err := Convert(Val{Tid: BinaryID, Value: b[2+b[1]:]}, tid)
+	if err != nil {
+		return Range{}, err
+	}
+	return NewRange(tid, start.Value, end.Value)
+}
+
+// compareRange orders ranges by their start, and ranges with the same start
+// by their end. Ranges of different types are ordered by their type.
+func compareRange(a, b Range) int {
+	switch {
+	case a.Tid != b.Tid:
+		return int(a.Tid) - int(b.Tid)
+	case less(a.start(), b.start()):
+		return -1
+	case less(b.start(), a.start()):
+		return 1
+	case less(a.end(), b.end()):
+		return -1
+	case less(b.end(), a.end()):
+		return 1
+	}
+	return 0
+}
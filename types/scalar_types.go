@@ -17,6 +17,7 @@
 package types
 
 import (
+	"net"
 	"time"
 
 	"github.com/dgraph-io/dgraph/protos"
@@ -42,6 +43,13 @@ const (
 	UidID      = TypeID(protos.Posting_UID)
 	PasswordID = TypeID(protos.Posting_PASSWORD)
 	DefaultID  = TypeID(protos.Posting_DEFAULT)
+	UriID      = TypeID(protos.Posting_URI)
+	JsonID     = TypeID(protos.Posting_JSON)
+	IpID       = TypeID(protos.Posting_IP)
+	CidrID     = TypeID(protos.Posting_CIDR)
+	MoneyID    = TypeID(protos.Posting_MONEY)
+	UuidID     = TypeID(protos.Posting_UUID)
+	RangeID    = TypeID(protos.Posting_RANGE)
 )
 
 var typeNameMap = map[string]TypeID{
@@ -54,6 +62,13 @@ var typeNameMap = map[string]TypeID{
 	"uid":      UidID,
 	"password": PasswordID,
 	"default":  DefaultID,
+	"uri":      UriID,
+	"json":     JsonID,
+	"ip":       IpID,
+	"cidr":     CidrID,
+	"money":    MoneyID,
+	"uuid":     UuidID,
+	"range":    RangeID,
 }
 
 type TypeID protos.Posting_ValType
@@ -84,10 +99,51 @@ func (t TypeID) Name() string {
 		return "default"
 	case BinaryID:
 		return "binary"
+	case UriID:
+		return "uri"
+	case JsonID:
+		return "json"
+	case IpID:
+		return "ip"
+	case CidrID:
+		return "cidr"
+	case MoneyID:
+		return "money"
+	case UuidID:
+		return "uuid"
+	case RangeID:
+		return "range"
 	}
 	return ""
 }
 
+// Map from our types to the RDF types understood by the RDF parser. The dgraph
+// type name and the RDF type might not be the same (e.g. datetime and bool).
+var rdfTypeMap = map[TypeID]string{
+	StringID:   "xs:string",
+	DateTimeID: "xs:dateTime",
+	IntID:      "xs:int",
+	FloatID:    "xs:float",
+	BoolID:     "xs:boolean",
+	GeoID:      "geo:geojson",
+	BinaryID:   "xs:base64Binary",
+	PasswordID: "xs:string",
+	UriID:      "xs:anyURI",
+	JsonID:     "json",
+	IpID:       "xs:string",
+	CidrID:     "xs:string",
+	MoneyID:    "xs:string",
+	UuidID:     "xs:string",
+	RangeID:    "xs:string",
+}
+
+// RDFType returns the RDF type that values of type t are written with, and
+// whether there is one.
+func (t TypeID) RDFType() (string, bool) {
+	rdfType, ok := rdfTypeMap[t]
+	return rdfType, ok
+}
+
 // Val is a value with type information.
 type Val struct {
 	Tid   TypeID
@@ -148,6 +204,34 @@ func ValueForType(id TypeID) Val {
 		var p string
 		return Val{PasswordID, p}
 
+	case UriID:
+		var u string
+		return Val{UriID, u}
+
+	case JsonID:
+		var j []byte
+		return Val{JsonID, j}
+
+	case IpID:
+		var ip net.IP
+		return Val{IpID, ip}
+
+	case CidrID:
+		var ipnet *net.IPNet
+		return Val{CidrID, ipnet}
+
+	case MoneyID:
+		var m Money
+		return Val{MoneyID, m}
+
+	case UuidID:
+		var u UUID
+		return Val{UuidID, u}
+
+	case RangeID:
+		var r Range
+		return Val{RangeID, r}
+
 	default:
 		return Val{}
 	}
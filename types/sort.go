@@ -18,6 +18,7 @@ package types
 
 import (
 	"fmt"
+	"net"
 	"sort"
 	"time"
 
@@ -87,8 +88,21 @@ func SortWithFacet(v [][]Val, ul *protos.List, l []*protos.Facets, desc []bool)
 
 	typ := v[0][0].Tid
 	switch typ {
-	case DateTimeID, IntID, FloatID, StringID, DefaultID:
+	case DateTimeID, IntID, FloatID, StringID, DefaultID, UriID, IpID, CidrID, UuidID,
+		RangeID:
 		// Don't do anything, we can sort values of this type.
+	case MoneyID:
+		// Money can only be sorted if it's all in the same currency.
+		for _, vals := range v {
+			for _, val := range vals {
+				if val.Tid != MoneyID {
+					continue
+				}
+				if err := checkSameCurrency(v[0][0].Value.(Money), val.Value.(Money)); err != nil {
+					return err
+				}
+			}
+		}
 	default:
 		return fmt.Errorf("Value of type: %s isn't sortable.", typ.Name())
 	}
@@ -111,8 +125,13 @@ func Less(a, b Val) (bool, error) {
 	}
 	typ := a.Tid
 	switch typ {
-	case DateTimeID, UidID, IntID, FloatID, StringID, DefaultID:
+	case DateTimeID, UidID, IntID, FloatID, StringID, DefaultID, UriID, IpID, CidrID, UuidID,
+		RangeID:
 		// Don't do anything, we can sort values of this type.
+	case MoneyID:
+		if err := checkSameCurrency(a.Value.(Money), b.Value.(Money)); err != nil {
+			return false, err
+		}
 	default:
 		return false, x.Errorf("Compare not supported for type: %v", a.Tid)
 	}
@@ -132,8 +151,18 @@ func less(a, b Val) bool {
 		return (a.Value.(float64)) < (b.Value.(float64))
 	case UidID:
 		return (a.Value.(uint64) < b.Value.(uint64))
-	case StringID, DefaultID:
+	case StringID, DefaultID, UriID:
 		return (a.Value.(string)) < (b.Value.(string))
+	case IpID:
+		return compareIP(a.Value.(net.IP), b.Value.(net.IP)) < 0
+	case CidrID:
+		return compareCIDR(a.Value.(*net.IPNet), b.Value.(*net.IPNet)) < 0
+	case MoneyID:
+		return a.Value.(Money).AmountMinor < b.Value.(Money).AmountMinor
+	case UuidID:
+		return compareUUID(a.Value.(UUID), b.Value.(UUID)) < 0
+	case RangeID:
+		return compareRange(a.Value.(Range), b.Value.(Range)) < 0
 	}
 	return false
 }
@@ -164,7 +193,8 @@ func Equal(a, b Val) (bool, error) {
 	}
 	typ := a.Tid
 	switch typ {
-	case DateTimeID, IntID, FloatID, StringID, DefaultID, BoolID:
+	case DateTimeID, IntID, FloatID, StringID, DefaultID, BoolID, UriID, IpID, CidrID, MoneyID,
+		UuidID, RangeID:
 		// Don't do anything, we can sort values of this type.
 	default:
 		return false, x.Errorf("Equal not supported for type: %v", a.Tid)
@@ -183,10 +213,21 @@ func equal(a, b Val) bool {
 		return (a.Value.(int64)) == (b.Value.(int64))
 	case FloatID:
 		return (a.Value.(float64)) == (b.Value.(float64))
-	case StringID, DefaultID:
+	case StringID, DefaultID, UriID:
 		return (a.Value.(string)) == (b.Value.(string))
 	case BoolID:
 		return a.Value.(bool) == (b.Value.(bool))
+	case IpID:
+		return compareIP(a.Value.(net.IP), b.Value.(net.IP)) == 0
+	case CidrID:
+		return compareCIDR(a.Value.(*net.IPNet), b.Value.(*net.IPNet)) == 0
+	case MoneyID:
+		// Money in different currencies is never equal.
+		return a.Value.(Money) == b.Value.(Money)
+	case UuidID:
+		return a.Value.(UUID) == b.Value.(UUID)
+	case RangeID:
+		return compareRange(a.Value.(Range), b.Value.(Range)) == 0
 	}
 	return false
 }
@@ -107,6 +107,24 @@ func TestSortDateTimes(t *testing.T) {
 		toString(t, list, DateTimeID))
 }
 
+func TestSortIPs(t *testing.T) {
+	list := getInput(t, IpID, []string{"10.0.0.10", "2001:db8::1", "10.0.0.9", "9.255.0.1"})
+	ul := getUIDList(4)
+	require.NoError(t, Sort(list, ul, []bool{false}))
+	require.EqualValues(t, []uint64{400, 300, 100, 200}, ul.Uids)
+	require.EqualValues(t, []string{"9.255.0.1", "10.0.0.9", "10.0.0.10", "2001:db8::1"},
+		toString(t, list, IpID))
+}
+
+func TestSortCIDRs(t *testing.T) {
+	list := getInput(t, CidrID, []string{"10.1.0.0/16", "10.0.0.0/8", "10.0.0.0/24"})
+	ul := getUIDList(3)
+	require.NoError(t, Sort(list, ul, []bool{false}))
+	require.EqualValues(t, []uint64{200, 300, 100}, ul.Uids)
+	require.EqualValues(t, []string{"10.0.0.0/8", "10.0.0.0/24", "10.1.0.0/16"},
+		toString(t, list, CidrID))
+}
+
 func TestSortIntAndFloat(t *testing.T) {
 	list := [][]Val{
 		[]Val{Val{Tid: IntID, Value: int64(55)}},
@@ -159,3 +177,54 @@ func TestSortMismatchedTypes(t *testing.T) {
 	require.True(t, idx21 < idx33)
 	require.True(t, idx33 < idx55)
 }
+
+func TestSortMoney(t *testing.T) {
+	list := getInput(t, MoneyID, []string{"USD 10.00", "USD -1.50", "USD 9.99", "USD 0.01"})
+	ul := getUIDList(4)
+	require.NoError(t, Sort(list, ul, []bool{false}))
+	require.EqualValues(t, []uint64{200, 400, 300, 100}, ul.Uids)
+	require.EqualValues(t, []string{"USD -1.50", "USD 0.01", "USD 9.99", "USD 10.00"},
+		toString(t, list, MoneyID))
+
+	less, err := Less(list[0][0], list[1][0])
+	require.NoError(t, err)
+	require.True(t, less)
+}
+
+func TestCompareMoneyCurrencies(t *testing.T) {
+	list := getInput(t, MoneyID, []string{"USD 10.00", "EUR 1.00"})
+	_, err := Less(list[0][0], list[1][0])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "USD")
+
+	require.Error(t, Sort(list, getUIDList(2), []bool{false}))
+
+	eq, err := Equal(list[0][0], list[1][0])
+	require.NoError(t, err)
+	require.False(t, eq)
+}
+
+func TestSortUUID(t *testing.T) {
+	list := getInput(t, UuidID, []string{
+		"f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		"00000000-0000-4000-8000-000000000001",
+		"9b2a7d4e-0c1f-4e2a-9d3b-5f6a7b8c9d0e",
+	})
+	ul := getUIDList(3)
+	require.NoError(t, Sort(list, ul, []bool{false}))
+	require.EqualValues(t, []uint64{200, 300, 100}, ul.Uids)
+
+	eq, err := Equal(list[0][0], list[0][0])
+	require.NoError(t, err)
+	require.True(t, eq)
+	eq, err = Equal(list[0][0], list[1][0])
+	require.NoError(t, err)
+	require.False(t, eq)
+}
+
+func TestSortRange(t *testing.T) {
+	list := getInput(t, RangeID, []string{"[3, 4)", "[1, 9)", "[1, 2)"})
+	ul := getUIDList(3)
+	require.NoError(t, Sort(list, ul, []bool{false}))
+	require.EqualValues(t, []uint64{300, 200, 100}, ul.Uids)
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// UUID is a universally unique identifier, stored in its 16 byte form. UUIDs
+// are ordered by their bytes, which is also the order of their string forms.
+type UUID [16]byte
+
+// String returns the UUID in its canonical form, in lowercase hexadecimal
+// digits grouped as 8-4-4-4-12.
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// parseUUID parses the canonical form of a UUID, with hexadecimal digits in
+// either case.
+func parseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, x.Errorf("Invalid UUID: %q", s)
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, x.Errorf("Invalid UUID: %q", s)
+	}
+	return u, nil
+}
+
+func decodeUUID(b []byte) (UUID, error) {
+	var u UUID
+	if len(b) != len(u) {
+		return u, x.Errorf("Invalid data for uuid %v", b)
+	}
+	copy(u[:], b)
+	return u, nil
+}
+
+// compareUUID orders UUIDs by their bytes.
+func compareUUID(a, b UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
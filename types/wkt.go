@@ -0,0 +1,210 @@
+/*
+ * Copyright (C) 2017 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"strconv"
+	"strings"
+
+	geom "github.com/twpayne/go-geom"
+
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// isWKT returns whether s looks like WKT rather than GeoJSON, which always
+// starts with an object.
+func isWKT(s string) bool {
+	s = strings.TrimSpace(s)
+	return len(s) > 0 && isWKTLetter(s[0])
+}
+
+func isWKTLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// parseWKT parses the Well-Known Text for a 2D point, line string, polygon or
+// multi polygon, e.g. POINT(1 2) or POLYGON((0 0, 1 0, 1 1, 0 0)).
+func parseWKT(s string) (geom.T, error) {
+	p := wktParser{s: s}
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && isWKTLetter(p.s[p.pos]) {
+		p.pos++
+	}
+	kind := strings.ToUpper(p.s[start:p.pos])
+
+	var g geom.T
+	var err error
+	switch kind {
+	case "POINT":
+		var c geom.Coord
+		if err = p.expect('('); err != nil {
+			return nil, err
+		}
+		if c, err = p.coord(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(')'); err != nil {
+			return nil, err
+		}
+		g, err = geom.NewPoint(geom.XY).SetCoords(c)
+	case "LINESTRING":
+		var cs []geom.Coord
+		if cs, err = p.coords(); err != nil {
+			return nil, err
+		}
+		g, err = geom.NewLineString(geom.XY).SetCoords(cs)
+	case "POLYGON":
+		var rings [][]geom.Coord
+		if rings, err = p.rings(); err != nil {
+			return nil, err
+		}
+		g, err = geom.NewPolygon(geom.XY).SetCoords(rings)
+	case "MULTIPOLYGON":
+		var polys [][][]geom.Coord
+		if err = p.expect('('); err != nil {
+			return nil, err
+		}
+		for {
+			rings, err := p.rings()
+			if err != nil {
+				return nil, err
+			}
+			polys = append(polys, rings)
+			if !p.accept(',') {
+				break
+			}
+		}
+		if err = p.expect(')'); err != nil {
+			return nil, err
+		}
+		g, err = geom.NewMultiPolygon(geom.XY).SetCoords(polys)
+	default:
+		p.pos = start
+		return nil, p.errorf("unsupported geometry type %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q after geometry", p.s[p.pos:])
+	}
+	return g, nil
+}
+
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) errorf(format string, args ...interface{}) error {
+	return x.Errorf("Invalid WKT at column %d: "+format,
+		append([]interface{}{p.pos + 1}, args...)...)
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// accept consumes c if it is the next non space character.
+func (p *wktParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *wktParser) expect(c byte) error {
+	if !p.accept(c) {
+		return p.errorf("expected %q", c)
+	}
+	return nil
+}
+
+func (p *wktParser) number() (float64, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("0123456789+-.eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("expected a number")
+	}
+	return f, nil
+}
+
+// coord parses a point given as its x and y separated by space.
+func (p *wktParser) coord() (geom.Coord, error) {
+	cx, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+	cy, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+	return geom.Coord{cx, cy}, nil
+}
+
+// coords parses a parenthesized list of points separated by commas.
+func (p *wktParser) coords() ([]geom.Coord, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var cs []geom.Coord
+	for {
+		c, err := p.coord()
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, c)
+		if !p.accept(',') {
+			break
+		}
+	}
+	return cs, p.expect(')')
+}
+
+// rings parses the parenthesized rings of a polygon.
+func (p *wktParser) rings() ([][]geom.Coord, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var rings [][]geom.Coord
+	for {
+		ring, err := p.coords()
+		if err != nil {
+			return nil, err
+		}
+		if len(ring) < 4 || !ring[0].Equal(geom.XY, ring[len(ring)-1]) {
+			return nil, p.errorf("polygon rings need at least 4 points, with the last " +
+				"one the same as the first")
+		}
+		rings = append(rings, ring)
+		if !p.accept(',') {
+			break
+		}
+	}
+	return rings, p.expect(')')
+}
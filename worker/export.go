@@ -21,6 +21,7 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"encoding/base64"
 	"fmt"
 	"math/rand"
 	"os"
@@ -54,20 +55,6 @@ type skv struct {
 	schema *protos.SchemaUpdate
 }
 
-// Map from our types to RDF type. Useful when writing storage types
-// for RDF's in export. This is the dgraph type name and rdf storage type
-// might not be the same always (e.g. - datetime and bool).
-var rdfTypeMap = map[types.TypeID]string{
-	types.StringID:   "xs:string",
-	types.DateTimeID: "xs:dateTime",
-	types.IntID:      "xs:int",
-	types.FloatID:    "xs:float",
-	types.BoolID:     "xs:boolean",
-	types.GeoID:      "geo:geojson",
-	types.BinaryID:   "xs:base64Binary",
-	types.PasswordID: "xs:string",
-}
-
 func toRDF(buf *bytes.Buffer, item kv, readTs uint64) {
 	l := posting.GetNoStore(item.key)
 	err := l.Iterate(readTs, 0, func(p *protos.Posting) bool {
@@ -83,12 +70,16 @@ func toRDF(buf *bytes.Buffer, item kv, readTs uint64) {
 				str.Value = ""
 			}
 			x.Check(err)
+			if vID == types.BinaryID {
+				// xs:base64Binary values are expected to be base64 encoded.
+				str.Value = base64.StdEncoding.EncodeToString(p.Value)
+			}
 			buf.WriteString(strconv.Quote(str.Value.(string)))
 			if p.PostingType == protos.Posting_VALUE_LANG {
 				buf.WriteByte('@')
 				buf.WriteString(string(p.Metadata))
 			} else if vID != types.DefaultID {
-				rdfType, ok := rdfTypeMap[vID]
+				rdfType, ok := vID.RDFType()
 				x.AssertTruef(ok, "Didn't find RDF type for dgraph type: %+v", vID.Name())
 				buf.WriteString("^^<")
 				buf.WriteString(rdfType)
@@ -163,6 +154,9 @@ func toSchema(buf *bytes.Buffer, s *skv) {
 	if s.schema.Count {
 		buf.WriteString(" @count")
 	}
+	if s.schema.Upsert {
+		buf.WriteString(" @upsert")
+	}
 	buf.WriteString(" . \n")
 }
 
@@ -48,6 +48,51 @@ func deletePredicateEdge(edge *protos.DirectedEdge) bool {
 	return edge.Entity == 0 && bytes.Equal(edge.Value, []byte(x.Star))
 }
 
+// labelDeleteEdge returns true for the edges which delete the values of the
+// predicate that have the label of the edge. They have no value or object.
+func labelDeleteEdge(edge *protos.DirectedEdge) bool {
+	return edge.Op == protos.DirectedEdge_DEL && len(edge.Label) > 0 &&
+		len(edge.Value) == 0 && edge.ValueId == 0
+}
+
+// deleteLabelled deletes the postings in plist which have the label of edge.
+func deleteLabelled(ctx context.Context, edge *protos.DirectedEdge, plist *posting.List,
+	txn *posting.Txn) error {
+	var dels []*protos.DirectedEdge
+	err := plist.Iterate(txn.StartTs, 0, func(p *protos.Posting) bool {
+		if p.Label != edge.Label {
+			return true
+		}
+		del := &protos.DirectedEdge{
+			Entity: edge.Entity,
+			Attr:   edge.Attr,
+			Label:  edge.Label,
+			Op:     protos.DirectedEdge_DEL,
+		}
+		if len(p.Value) == 0 {
+			del.ValueId = p.Uid
+		} else {
+			del.Value = p.Value
+			del.ValueType = p.ValType
+			if p.PostingType == protos.Posting_VALUE_LANG {
+				del.Lang = string(p.Metadata)
+			}
+		}
+		dels = append(dels, del)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	// The mutations can only be added after iterating, as that holds the lock.
+	for _, del := range dels {
+		if err := plist.AddMutationWithIndex(ctx, del, txn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // runMutation goes through all the edges and applies them. It returns the
 // mutations which were not applied in left.
 func runMutation(ctx context.Context, edge *protos.DirectedEdge, txn *posting.Txn) error {
@@ -62,13 +107,20 @@ func runMutation(ctx context.Context, edge *protos.DirectedEdge, txn *posting.Tx
 	if deletePredicateEdge(edge) {
 		return errors.New("We should never reach here")
 	}
+	key := x.DataKey(edge.Attr, edge.Entity)
+	if labelDeleteEdge(edge) {
+		return deleteLabelled(ctx, edge, posting.Get(key), txn)
+	}
+	if edge.Op == protos.DirectedEdge_INC {
+		if err := applyIncrement(edge, typ, posting.Get(key), txn); err != nil {
+			return err
+		}
+	}
 	// Once mutation comes via raft we do best effort conversion
 	// Type check is done before proposing mutation, in case schema is not
 	// present, some invalid entries might be written initially
 	err = ValidateAndConvert(edge, typ)
 
-	key := x.DataKey(edge.Attr, edge.Entity)
-
 	t := time.Now()
 	plist := posting.Get(key)
 	if dur := time.Since(t); dur > time.Millisecond {
@@ -283,10 +335,48 @@ func checkSchema(s *protos.SchemaUpdate) error {
 	return nil
 }
 
+// applyIncrement turns an increment edge into a set edge, whose value is the
+// delta added to the value the transaction reads for the predicate. Two
+// concurrent increments of the same value conflict, so one of them is aborted.
+func applyIncrement(edge *protos.DirectedEdge, typ types.TypeID, plist *posting.List,
+	txn *posting.Txn) error {
+	if typ != types.IntID {
+		return x.Errorf("Increment is only supported for int predicates. Predicate %s is %s",
+			edge.Attr, typ.Name())
+	}
+	delta, err := types.Convert(types.Val{Tid: types.BinaryID, Value: edge.Value}, types.IntID)
+	if err != nil {
+		return err
+	}
+	sum := delta.Value.(int64)
+	val, err := plist.Value(txn.StartTs)
+	switch {
+	case err == posting.ErrNoValue:
+		// Incrementing a missing value starts from zero.
+	case err != nil:
+		return err
+	default:
+		cur, err := types.Convert(val, types.IntID)
+		if err != nil {
+			return x.Wrapf(err, "while reading value to increment for predicate %s", edge.Attr)
+		}
+		sum += cur.Value.(int64)
+	}
+
+	b := types.ValueForType(types.BinaryID)
+	if err := types.Marshal(types.Val{Tid: types.IntID, Value: sum}, &b); err != nil {
+		return err
+	}
+	edge.Value = b.Value.([]byte)
+	edge.ValueType = types.IntID.Enum()
+	edge.Op = protos.DirectedEdge_SET
+	return nil
+}
+
 // If storage type is specified, then check compatibility or convert to schema type
 // if no storage type is specified then convert to schema type.
 func ValidateAndConvert(edge *protos.DirectedEdge, schemaType types.TypeID) error {
-	if deletePredicateEdge(edge) {
+	if deletePredicateEdge(edge) || labelDeleteEdge(edge) {
 		return nil
 	}
 	if types.TypeID(edge.ValueType) == types.DefaultID && string(edge.Value) == x.Star {
@@ -295,14 +385,6 @@ func ValidateAndConvert(edge *protos.DirectedEdge, schemaType types.TypeID) erro
 		}
 		return nil
 	}
-	// <s> <p> <o> Del on non list scalar type.
-	if len(edge.Value) > 0 && !bytes.Equal(edge.Value, []byte(x.Star)) &&
-		edge.Op == protos.DirectedEdge_DEL {
-		if !schema.State().IsList(edge.Attr) {
-			return x.Errorf("Please use * with delete operation for non-list type")
-		}
-	}
-
 	storageType := posting.TypeID(edge)
 	if !schemaType.IsScalar() && !storageType.IsScalar() {
 		return nil
@@ -18,6 +18,7 @@
 package worker
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"reflect"
@@ -25,9 +26,11 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/dgraph-io/dgraph/posting"
 	"github.com/dgraph-io/dgraph/protos"
 	"github.com/dgraph-io/dgraph/schema"
 	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/x"
 )
 
 func TestConvertEdgeType(t *testing.T) {
@@ -60,7 +63,14 @@ func TestConvertEdgeType(t *testing.T) {
 				Op:    protos.DirectedEdge_DEL,
 			},
 			to:        types.StringID,
-			expectErr: true,
+			expectErr: false,
+			output: &protos.DirectedEdge{
+				Value:     []byte("set edge"),
+				Label:     "test-mutation",
+				Attr:      "name",
+				Op:        protos.DirectedEdge_DEL,
+				ValueType: 9,
+			},
 		},
 		{
 			input: &protos.DirectedEdge{
@@ -185,3 +195,35 @@ func TestNeedReindexing(t *testing.T) {
 	s2 = protos.SchemaUpdate{ValueType: protos.Posting_FLOAT, Directive: protos.SchemaUpdate_NONE}
 	require.True(t, needReindexing(s1, s2))
 }
+
+func TestDeleteLabelled(t *testing.T) {
+	dir, ps := initTest(t, `friend: uid .`)
+	defer os.RemoveAll(dir)
+	defer ps.Close()
+
+	key := x.DataKey("friend", 200)
+	for uid, label := range map[uint64]string{1: "a", 2: "b", 3: "a"} {
+		edge := &protos.DirectedEdge{Entity: 200, Attr: "friend", ValueId: uid, Label: label}
+		addEdge(t, edge, posting.Get(key))
+	}
+
+	startTs := timestamp()
+	txn := posting.Txns().PutOrMergeIndex(&posting.Txn{StartTs: startTs})
+	del := &protos.DirectedEdge{
+		Entity: 200,
+		Attr:   "friend",
+		Label:  "a",
+		Op:     protos.DirectedEdge_DEL,
+	}
+	require.NoError(t, ValidateAndConvert(del, types.UidID))
+	require.NoError(t, runMutation(context.Background(), del, txn))
+	require.NoError(t, txn.CommitMutations(context.Background(), commitTs(startTs)))
+
+	var uids []uint64
+	err := posting.Get(key).Iterate(timestamp(), 0, func(p *protos.Posting) bool {
+		uids = append(uids, p.Uid)
+		return true
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, uids)
+}
@@ -26,6 +26,10 @@ const (
 	ValuePlain                        // plain old value without defined language tag
 	// Value which is part of a multi-value posting list (like language).
 	ValueMulti
+	// Int value which is added to the existing value, instead of replacing it.
+	ValueIncrement
+	// No value and no UID, but a label. Used to delete the edges with the label.
+	ValueLabel
 )
 
 // Helper function, to decide value type of DirectedEdge/Posting/NQuad